@@ -0,0 +1,227 @@
+// Command gazetteer converts the GeoNames postal code dump for Germany into
+// the gazetteer the geocoder consults before Nominatim.
+//
+// The dump is published under CC BY 4.0 at
+// https://download.geonames.org/export/zip/DE.zip and lists one row per postal
+// code and place. Rows are grouped by place name and state; the coordinates of
+// a place are the mean of its postal code areas.
+//
+// Usage:
+//
+//	curl -sO https://download.geonames.org/export/zip/DE.zip
+//	go run ./cmd/gazetteer -in DE.zip -out pkg/gazetteer/data/gazetteer.json
+//
+// The generated file names the dump's date in its source, so the data version
+// is recorded with the data.
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/gazetteer"
+)
+
+// Columns of the GeoNames postal code format.
+const (
+	colCountry = 0
+	colPostal  = 1
+	colPlace   = 2
+	colState   = 3
+	colLat     = 9
+	colLon     = 10
+	numColumns = 11
+)
+
+// minPlaces guards against converting a truncated or wrong file: the full
+// dump lists well over ten thousand places.
+const minPlaces = 5000
+
+type key struct{ name, state string }
+
+type group struct {
+	latSum, lonSum float64
+	n              int
+	postalCodes    map[string]bool
+}
+
+func main() {
+	in := flag.String("in", "DE.zip", "GeoNames postal code dump, DE.zip or the DE.txt in it")
+	out := flag.String("out", "pkg/gazetteer/data/gazetteer.json", "file to write")
+	flag.Parse()
+
+	r, dated, err := openDump(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	places, err := convert(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(places) < minPlaces {
+		fmt.Fprintf(os.Stderr, "only %d places in %s; is it the full DE dump?\n", len(places), *in)
+		os.Exit(1)
+	}
+
+	source := fmt.Sprintf("GeoNames postal codes DE, dump of %s (https://www.geonames.org), CC BY 4.0",
+		dated.Format("2006-01-02"))
+	raw, err := render(source, places)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// Refuse to write a file the service would reject at startup.
+	if _, err := gazetteer.Parse(raw); err != nil {
+		fmt.Fprintln(os.Stderr, "generated gazetteer is invalid:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, raw, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d places to %s\n", len(places), *out)
+}
+
+// openDump opens the tab-separated dump, directly or from the zip GeoNames
+// publishes, and returns when it was generated: the zip entry's time, or the
+// file's modification time.
+func openDump(path string) (io.ReadCloser, time.Time, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".zip") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, time.Time{}, err
+		}
+		return f, info.ModTime(), nil
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for _, entry := range archive.File {
+		if entry.Name != "DE.txt" {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			archive.Close()
+			return nil, time.Time{}, err
+		}
+		return zipEntry{ReadCloser: rc, archive: archive}, entry.Modified, nil
+	}
+	archive.Close()
+	return nil, time.Time{}, errors.New(path + " has no DE.txt")
+}
+
+// zipEntry closes the archive along with the entry.
+type zipEntry struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (e zipEntry) Close() error {
+	return errors.Join(e.ReadCloser.Close(), e.archive.Close())
+}
+
+func convert(r io.Reader) ([]gazetteer.Place, error) {
+	groups := make(map[key]*group)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) < numColumns || cols[colCountry] != "DE" {
+			continue
+		}
+		lat, errLat := strconv.ParseFloat(cols[colLat], 64)
+		lon, errLon := strconv.ParseFloat(cols[colLon], 64)
+		if errLat != nil || errLon != nil {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+
+		k := key{name: strings.TrimSpace(cols[colPlace]), state: strings.TrimSpace(cols[colState])}
+		if k.name == "" || k.state == "" {
+			continue
+		}
+		g := groups[k]
+		if g == nil {
+			g = &group{postalCodes: make(map[string]bool)}
+			groups[k] = g
+		}
+		g.latSum += lat
+		g.lonSum += lon
+		g.n++
+		g.postalCodes[cols[colPostal]] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	places := make([]gazetteer.Place, 0, len(groups))
+	for k, g := range groups {
+		codes := make([]string, 0, len(g.postalCodes))
+		for code := range g.postalCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		places = append(places, gazetteer.Place{
+			Name:        k.name,
+			State:       k.state,
+			Lat:         strconv.FormatFloat(g.latSum/float64(g.n), 'f', 3, 64),
+			Lon:         strconv.FormatFloat(g.lonSum/float64(g.n), 'f', 3, 64),
+			PostalCodes: codes,
+		})
+	}
+	// Sorted so that regenerating from the same dump gives the same file.
+	sort.Slice(places, func(i, j int) bool {
+		if places[i].State != places[j].State {
+			return places[i].State < places[j].State
+		}
+		return places[i].Name < places[j].Name
+	})
+	return places, nil
+}
+
+// render writes one place per line, which keeps diffs of a regenerated file
+// readable.
+func render(source string, places []gazetteer.Place) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("{\n  \"source\": ")
+	src, _ := json.Marshal(source)
+	b.Write(src)
+	b.WriteString(",\n  \"places\": [\n")
+	for i, p := range places {
+		line, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("    ")
+		b.Write(line)
+		if i < len(places)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("  ]\n}\n")
+	return []byte(b.String()), nil
+}
@@ -11,9 +11,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
@@ -92,6 +94,10 @@ func main() {
 	openstreetmap.InitCache()
 	defer openstreetmap.CloseCache()
 
+	// Ctrl-C stops the lookups instead of waiting out the rate limiter.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	var hits int
 	fmt.Printf("%-40s %-20s %-8s %s\n", "ORGANIZER", "EXPECTED", "RESULT", "RESOLVED")
 	fmt.Println(strings.Repeat("-", 110))
@@ -107,7 +113,7 @@ func main() {
 			Location:  c.location,
 		}
 
-		geo := openstreetmap.GetGeocoordinatesForFederation(fed, tournament)
+		geo := openstreetmap.GetGeocoordinatesForFederation(ctx, fed, tournament)
 
 		resolved := geo.DisplayName
 		if resolved == "" {
@@ -11,12 +11,19 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/addressprovider"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/catalog"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/geoqueue"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/metrics"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/openstreetmap"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/resultcache"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/savedsearch"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/scheduler"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/tournament"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/webpush"
 )
 
 // Global variables for components that can be reloaded
@@ -25,6 +32,10 @@ var (
 	globalSchedulerMu sync.Mutex
 	reloadMu          sync.Mutex
 	globalResultCache *resultcache.Cache
+	globalGeoQueue    *geoqueue.Queue
+	globalCatalog     *catalog.Catalog
+	globalSearches    savedsearch.Store
+	globalPush        webpush.Store
 )
 
 // newServer builds an HTTP server with defensive timeouts so slow or
@@ -41,11 +52,20 @@ func newServer(addr string, handler http.Handler) *http.Server {
 	}
 }
 
+// openBoltOrMemory opens a component's bolt file, or falls back to memory when
+// it cannot be opened. A missing volume or a locked file is no reason to keep
+// the service down: the component still works, it only forgets its data on
+// restart. The callers note what that costs.
+func openBoltOrMemory[S any](what, path string, openBolt func(path string) (S, error), newMemory func() S) S {
+	store, err := openBolt(path)
+	if err != nil {
+		logger.Error("Failed to open %s at %s, falling back to memory: %v", what, path, err)
+		return newMemory()
+	}
+	return store
+}
+
 // initResultCache wires up the tournament result cache.
-//
-// A failure to open the persistent store is not fatal: caching falls back to
-// memory, which still spares the federations most of the load and only costs
-// the cache contents on restart.
 func initResultCache() {
 	if !resultcache.Enabled() {
 		logger.Info("Result cache disabled (TTF_RESULT_CACHE=false)")
@@ -57,14 +77,11 @@ func initResultCache() {
 		path = "./data/results.bolt"
 	}
 
-	var store resultcache.Store
-	boltStore, err := resultcache.NewBoltStore(path)
-	if err != nil {
-		logger.Error("Failed to open result cache at %s, falling back to memory: %v", path, err)
-		store = resultcache.NewMemoryStore()
-	} else {
-		store = boltStore
-	}
+	// In memory the cache still spares the federations most of the load; it
+	// is only refilled after a restart.
+	store := openBoltOrMemory("result cache", path,
+		func(path string) (resultcache.Store, error) { return resultcache.NewBoltStore(path) },
+		func() resultcache.Store { return resultcache.NewMemoryStore() })
 
 	opts := resultcache.OptionsFromEnv()
 	cache := resultcache.New(store, opts)
@@ -80,7 +97,134 @@ func initResultCache() {
 		return stats
 	})
 
-	logger.Info("Result cache enabled (path=%s, ttl=%s, stale=%s)", path, opts.TTL, opts.StaleTTL)
+	logger.Info("Result cache enabled (path=%s, ttl=%s, stale=%s, revalidate=%t)", path, opts.TTL, opts.StaleTTL, opts.Revalidate)
+}
+
+// initGeocodeQueue moves geocoding misses off the request path.
+func initGeocodeQueue() {
+	if !geoqueue.Enabled() {
+		logger.Info("Background geocoding disabled (TTF_GEOCODE_ASYNC=false)")
+		return
+	}
+
+	path := os.Getenv("TTF_GEOCODE_QUEUE_PATH")
+	if path == "" {
+		path = "./data/geoqueue.bolt"
+	}
+
+	// Pending lookups lost on restart are queued again by the next request.
+	store := openBoltOrMemory("geocode queue", path,
+		func(path string) (geoqueue.Store, error) { return geoqueue.NewBoltStore(path) },
+		func() geoqueue.Store { return geoqueue.NewMemoryStore() })
+
+	// The lookup caches its outcome, which is where requests pick it up.
+	queue := geoqueue.New(store, func(ctx context.Context, fed models.Federation, t models.Tournament) {
+		openstreetmap.GetGeocoordinatesForFederation(ctx, fed, t)
+	})
+	queue.Start()
+	tournament.SetGeocodeQueue(queue)
+	globalGeoQueue = queue
+
+	metrics.SetGeocodeQueueProvider(func() any {
+		stats, err := queue.Stats()
+		if err != nil {
+			return map[string]string{"error": err.Error()}
+		}
+		return stats
+	})
+
+	logger.Info("Background geocoding enabled (path=%s)", path)
+}
+
+// initCatalog wires up the tournament catalog every refresh upserts into.
+func initCatalog() {
+	if !catalog.Enabled() {
+		logger.Info("Tournament catalog disabled (TTF_CATALOG=false)")
+		return
+	}
+
+	path := os.Getenv("TTF_CATALOG_PATH")
+	if path == "" {
+		path = "./data/tournaments.bolt"
+	}
+
+	// In memory the catalog starts empty after each restart and fills up
+	// with the next refreshes. The change feed starts over, and no push
+	// notifications are sent: every tournament would look new.
+	store := openBoltOrMemory("tournament catalog", path,
+		func(path string) (catalog.Store, error) { return catalog.NewBoltStore(path) },
+		func() catalog.Store { return catalog.NewMemoryStore() })
+
+	c := catalog.New(store)
+	tournament.SetCatalog(c)
+	globalCatalog = c
+
+	metrics.SetCatalogProvider(func() any {
+		stats, err := c.Stats()
+		if err != nil {
+			return map[string]string{"error": err.Error()}
+		}
+		return stats
+	})
+
+	logger.Info("Tournament catalog enabled (path=%s)", path)
+}
+
+// initSavedSearches wires up the store behind /searches and /feeds.
+func initSavedSearches() {
+	if !savedsearch.Enabled() {
+		logger.Info("Saved searches disabled (TTF_SAVED_SEARCHES=false)")
+		return
+	}
+
+	path := os.Getenv("TTF_SAVED_SEARCHES_PATH")
+	if path == "" {
+		path = "./data/searches.bolt"
+	}
+
+	// In memory saved searches are lost on restart, and the feed URLs
+	// handed out for them answer 404.
+	store := openBoltOrMemory("saved searches", path,
+		func(path string) (savedsearch.Store, error) { return savedsearch.NewBoltStore(path) },
+		func() savedsearch.Store { return savedsearch.NewMemoryStore() })
+
+	tournament.SetSavedSearches(store)
+	globalSearches = store
+
+	logger.Info("Saved searches enabled (path=%s)", path)
+}
+
+// initPush wires up Web Push for saved searches. The VAPID key is kept in the
+// same database as the subscriptions, unless TTF_VAPID_PRIVATE_KEY supplies
+// one; without either, a key is generated on first start.
+func initPush() {
+	if !webpush.Enabled() {
+		logger.Info("Web Push disabled (TTF_WEB_PUSH=false)")
+		return
+	}
+
+	path := os.Getenv("TTF_WEB_PUSH_PATH")
+	if path == "" {
+		path = "./data/push.bolt"
+	}
+
+	// In memory subscriptions are lost on restart, and so is a generated
+	// VAPID key, which invalidates every subscription made with it.
+	store := openBoltOrMemory("push subscriptions", path,
+		func(path string) (webpush.Store, error) { return webpush.NewBoltStore(path) },
+		func() webpush.Store { return webpush.NewMemoryStore() })
+
+	keys, err := webpush.LoadKeys(store, os.Getenv("TTF_VAPID_PRIVATE_KEY"))
+	if err != nil {
+		logger.Error("Web Push disabled, no usable VAPID key: %v", err)
+		store.Close()
+		return
+	}
+
+	tournament.SetPush(store, webpush.NewSender(keys))
+	globalPush = store
+
+	logger.Info("Web Push enabled (path=%s, public key=%s)", path, keys.PublicKey())
 }
 
 func main() {
@@ -90,10 +234,17 @@ func main() {
 	logger.Info("OpenStreetMap cache initialized")
 
 	initResultCache()
+	initCatalog()
+	initSavedSearches()
+	initPush()
+	initGeocodeQueue()
 
 	// Lightweight metrics (no Prometheus required)
 	metrics.Init()
 	metrics.SetReloadCallback(ReloadComponents)
+	metrics.SetGeocodingLimiterProvider(func() any {
+		return addressprovider.RateLimiterStats()
+	})
 
 	// Start a localhost-only diagnostics server for /stats and /debug/vars
 	diagMux := http.NewServeMux()
@@ -104,6 +255,9 @@ func main() {
 	// for club-locations.json. Wrong pins are otherwise invisible: a tournament
 	// sitting in the middle of a state looks like a working map.
 	diagMux.Handle(metrics.UnresolvedClubsPath, http.HandlerFunc(metrics.UnresolvedClubsHandler))
+	// Date texts pkg/daterange could not parse; those tournaments have no
+	// start_date/end_date.
+	diagMux.Handle(metrics.UnparsedDatesPath, http.HandlerFunc(metrics.UnparsedDatesHandler))
 	diagAddr := "127.0.0.1:9090"
 	diagServer := newServer(diagAddr, diagMux)
 
@@ -118,6 +272,17 @@ func main() {
 	// Public API with instrumentation (served on :8080)
 	apiMux := http.NewServeMux()
 	apiMux.Handle("/", metrics.Instrument(http.HandlerFunc(tournament.GetTournaments)))
+	apiMux.Handle(tournament.DetailPattern, metrics.Instrument(http.HandlerFunc(tournament.GetTournament)))
+	apiMux.Handle(tournament.ICSPath, metrics.Instrument(http.HandlerFunc(tournament.GetTournamentsICS)))
+	apiMux.Handle(tournament.ChangesPath, metrics.Instrument(http.HandlerFunc(tournament.GetChanges)))
+	apiMux.Handle(tournament.CreateSearchPattern, metrics.Instrument(http.HandlerFunc(tournament.CreateSearch)))
+	apiMux.Handle(tournament.SearchPattern, metrics.Instrument(http.HandlerFunc(tournament.GetSearch)))
+	apiMux.Handle(tournament.DeleteSearchPattern, metrics.Instrument(http.HandlerFunc(tournament.DeleteSearch)))
+	apiMux.Handle(tournament.FeedPattern, metrics.Instrument(http.HandlerFunc(tournament.GetFeed)))
+	apiMux.Handle(tournament.PushKeyPattern, metrics.Instrument(http.HandlerFunc(tournament.GetPushKey)))
+	apiMux.Handle(tournament.SubscribePattern, metrics.Instrument(http.HandlerFunc(tournament.Subscribe)))
+	apiMux.Handle(tournament.UnsubscribePattern, metrics.Instrument(http.HandlerFunc(tournament.Unsubscribe)))
+	apiMux.Handle(tournament.PushPreflightPattern, http.HandlerFunc(tournament.PushPreflight))
 	apiServer := newServer(":8080", apiMux)
 
 	// In-process scheduler (fully optional; enable with env var)
@@ -166,6 +331,31 @@ func main() {
 			}
 		}
 
+		if globalCatalog != nil {
+			if err := globalCatalog.Close(); err != nil {
+				logger.Error("Tournament catalog shutdown error: %v", err)
+			}
+		}
+
+		if globalSearches != nil {
+			if err := globalSearches.Close(); err != nil {
+				logger.Error("Saved searches shutdown error: %v", err)
+			}
+		}
+
+		if globalPush != nil {
+			if err := globalPush.Close(); err != nil {
+				logger.Error("Push subscriptions shutdown error: %v", err)
+			}
+		}
+
+		// Stop the worker before closing the geocoding cache it writes to.
+		if globalGeoQueue != nil {
+			if err := globalGeoQueue.Close(); err != nil {
+				logger.Error("Geocode queue shutdown error: %v", err)
+			}
+		}
+
 		openstreetmap.CloseCache()
 		os.Exit(0)
 	}()
@@ -184,6 +374,10 @@ func ReloadComponents() error {
 
 	logger.Info("Reloading application components...")
 
+	// A component that fails to reload keeps its current state and does not
+	// stop the others; the failures are returned together.
+	var errs []error
+
 	// Reload log level
 	if logLevel := os.Getenv("TTF_LOG_LEVEL"); logLevel != "" {
 		level := logger.ParseLogLevel(logLevel)
@@ -191,6 +385,14 @@ func ReloadComponents() error {
 		logger.Info("Log level updated to: %s", logLevel)
 	}
 
+	// Reload the federation list, so an expired TrustedProperties token can
+	// be replaced by editing TTF_FEDERATIONS instead of redeploying. A broken
+	// file keeps the current list active; the scheduler is still reloaded.
+	if err := federation.Reload(); err != nil {
+		logger.Error("Failed to reload federations, keeping the current list: %v", err)
+		errs = append(errs, err)
+	}
+
 	// Reload scheduler configuration
 	globalSchedulerMu.Lock()
 	cfg := scheduler.FromEnv()
@@ -203,18 +405,19 @@ func ReloadComponents() error {
 			s, err := scheduler.New(cfg)
 			if err != nil {
 				logger.Error("Failed to start scheduler during reload: %v", err)
-				return err
+				errs = append(errs, err)
+			} else {
+				s.Start()
+				globalSchedulerMu.Lock()
+				globalScheduler = s
+				globalSchedulerMu.Unlock()
+				logger.Info("Scheduler enabled during configuration reload")
 			}
-			s.Start()
-			globalSchedulerMu.Lock()
-			globalScheduler = s
-			globalSchedulerMu.Unlock()
-			logger.Info("Scheduler enabled during configuration reload")
 		} else {
 			// Scheduler exists, reload its configuration
 			if err := currentScheduler.Reload(); err != nil {
 				logger.Error("Failed to reload scheduler configuration: %v", err)
-				return err
+				errs = append(errs, err)
 			}
 		}
 	} else {
@@ -228,6 +431,10 @@ func ReloadComponents() error {
 		}
 	}
 
+	if err := errors.Join(errs...); err != nil {
+		logger.Warn("Component reload completed with %d error(s)", len(errs))
+		return err
+	}
 	logger.Info("Component reload completed successfully")
 	return nil
 }
@@ -6,17 +6,22 @@
 // the explicit interface and precedence order:
 //
 //  1. Curated overrides   - free, exact, manually maintained
-//  2. Name heuristics     - free, ~good, the default
-//  3. Upstream detail page - authoritative but slow, rate limited and fragile
+//  2. Offline gazetteer   - free, deterministic, municipalities only
+//  3. Nominatim           - covers everything, rate limited to 1 req/s
+//  4. Upstream detail page - authoritative but slow, rate limited and fragile
 //
-// Only the first two are enabled by default. The third is opt-in because it
-// depends on an undocumented widget protocol that can change without notice;
-// see issue #55.
+// The first three make up Default. The geocoding providers work through the
+// same Candidates (override city, published location, names derived from the
+// club name) and report which one resolved in Address.Source. The fourth is
+// opt-in because it depends on an undocumented widget protocol that can change
+// without notice; see issue #55.
 package addressprovider
 
 import (
 	"context"
 	"errors"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
 )
 
 // ErrNotFound indicates a provider has no answer for this tournament. It is a
@@ -49,7 +54,12 @@ type Address struct {
 	// empty, the caller geocodes Place.
 	Lat string
 	Lon string
-	// Source names the provider that produced this result, for diagnostics.
+	// DisplayName is the geocoder's full name for the result.
+	DisplayName string
+	// Details is the geocoder's structured address, when it returned one.
+	Details models.Address
+	// Source names what produced this result, for diagnostics: the Candidate
+	// source for geocoded results, otherwise the provider.
 	Source string
 }
 
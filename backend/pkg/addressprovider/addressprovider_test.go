@@ -171,8 +171,8 @@ func TestOverrideProviderResolvesCuratedEntry(t *testing.T) {
 	if got.Place != "Düsseldorf" || got.State != "Nordrhein-Westfalen" {
 		t.Errorf("got %+v, want the curated entry", got)
 	}
-	if got.Source != "overrides" {
-		t.Errorf("Source = %q, want overrides", got.Source)
+	if got.Source != "override" {
+		t.Errorf("Source = %q, want override", got.Source)
 	}
 }
 
@@ -240,7 +240,7 @@ func TestDefaultChainPrefersOverrides(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
-	if got.Source != "overrides" || got.Place != "Düsseldorf" {
+	if got.Source != "override" || got.Place != "Düsseldorf" {
 		t.Errorf("got %+v, want the curated override to win", got)
 	}
 }
@@ -0,0 +1,107 @@
+package addressprovider
+
+import (
+	"strings"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/clublocations"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/placename"
+)
+
+// Where a place name came from, most trustworthy first. A geocoded Address
+// reports the one it was resolved from in Source.
+const (
+	SourceOverride         = "override"
+	SourceLocation         = "location"
+	SourceLocationDerived  = "location-derived"
+	SourceOrganizerDerived = "organizer-derived"
+)
+
+// maxCandidates bounds how many place-name guesses are derived per
+// tournament.
+const maxCandidates = 4
+
+// Candidate is one place name worth geocoding.
+type Candidate struct {
+	Place  string
+	Source string
+}
+
+// Candidates returns the ordered list of place names to try for a request.
+//
+// Order of precedence:
+//  1. the city of a manual override for the organizer (highest confidence)
+//  2. the location published by the federation, when present
+//  3. candidates derived from the organizer's club name
+//
+// Geocoding providers stop at the first candidate that resolves inside an
+// accepted state, so more specific guesses must come first.
+func Candidates(req Request) []Candidate {
+	return planFor(req).candidates
+}
+
+// plan is what the geocoding providers work from: the candidates, and the
+// states a result may lie in.
+type plan struct {
+	candidates     []Candidate
+	acceptedStates []string
+}
+
+func planFor(req Request) plan {
+	p := plan{acceptedStates: req.AcceptedStates}
+	seen := make(map[string]bool)
+
+	add := func(value, source string) {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[strings.ToLower(value)] {
+			return
+		}
+		seen[strings.ToLower(value)] = true
+		p.candidates = append(p.candidates, Candidate{Place: value, Source: source})
+	}
+
+	if o := lookupOverride(req.Organizer); o != nil {
+		if o.City != "" {
+			add(o.City, SourceOverride)
+		}
+		if o.State != "" {
+			// An override may also correct the expected state.
+			p.acceptedStates = append([]string{o.State}, req.AcceptedStates...)
+		}
+	}
+
+	// The published location is usually a plain city name and is trusted
+	// before anything derived from the club name.
+	if loc := strings.TrimSpace(req.Location); loc != "" {
+		add(loc, SourceLocation)
+		for _, c := range placename.Candidates(loc) {
+			add(c, SourceLocationDerived)
+		}
+	}
+
+	for _, c := range placename.Candidates(req.Organizer) {
+		add(c, SourceOrganizerDerived)
+	}
+
+	if len(p.candidates) > maxCandidates {
+		p.candidates = p.candidates[:maxCandidates]
+	}
+
+	return p
+}
+
+// lookupOverride returns the curated entry for an organizer, or nil. A broken
+// override file is reported by OverrideProvider; here it just means no entry.
+func lookupOverride(organizer string) *clublocations.Override {
+	if organizer == "" {
+		return nil
+	}
+	table, err := clublocations.Default()
+	if err != nil {
+		return nil
+	}
+	o, ok := table.Lookup(organizer)
+	if !ok {
+		return nil
+	}
+	return &o
+}
@@ -0,0 +1,60 @@
+package addressprovider
+
+import "testing"
+
+func TestCandidatesOrder(t *testing.T) {
+	candidates := Candidates(Request{
+		Location:  "Karlsruhe",
+		Organizer: "TC Rot-Weiß Karlsruhe e.V.",
+	})
+
+	if len(candidates) == 0 {
+		t.Fatal("no candidates built")
+	}
+	// The published location is the most trustworthy signal.
+	if candidates[0].Place != "Karlsruhe" || candidates[0].Source != SourceLocation {
+		t.Errorf("first candidate = %+v, want the published location", candidates[0])
+	}
+
+	// No duplicates, even though the organizer derives the same city.
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c.Place] {
+			t.Errorf("duplicate candidate %q in %+v", c.Place, candidates)
+		}
+		seen[c.Place] = true
+	}
+}
+
+func TestCandidatesStartWithOverrideCity(t *testing.T) {
+	// "Lohausener Sport-Verein" is in the shipped override file.
+	p := planFor(Request{
+		Organizer:      "Lohausener Sport-Verein",
+		AcceptedStates: []string{"Hessen"},
+	})
+
+	if len(p.candidates) == 0 || p.candidates[0] != (Candidate{Place: "Düsseldorf", Source: SourceOverride}) {
+		t.Errorf("candidates = %+v, want the override city first", p.candidates)
+	}
+	// The override corrects the expected state without dropping the
+	// federation's own.
+	if len(p.acceptedStates) != 2 || p.acceptedStates[0] != "Nordrhein-Westfalen" {
+		t.Errorf("acceptedStates = %v, want the override's state first", p.acceptedStates)
+	}
+}
+
+func TestCandidatesWithoutUsableInput(t *testing.T) {
+	if candidates := Candidates(Request{TournamentID: "1"}); len(candidates) != 0 {
+		t.Errorf("candidates = %+v, want none", candidates)
+	}
+}
+
+func TestCandidateCountIsBounded(t *testing.T) {
+	candidates := Candidates(Request{
+		Location:  "Irgendwo an der Grenze",
+		Organizer: "TC Rot-Weiß Musterhausen-Kleinkleckersdorf 1920 e.V.",
+	})
+	if len(candidates) > maxCandidates {
+		t.Errorf("got %d candidates, want at most %d", len(candidates), maxCandidates)
+	}
+}
@@ -0,0 +1,69 @@
+package addressprovider
+
+import (
+	"context"
+	"sync"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/gazetteer"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// gazetteerErrOnce limits the report of a broken gazetteer file to one log
+// line instead of one per tournament.
+var gazetteerErrOnce sync.Once
+
+// GazetteerProvider geocodes the request's candidates offline, in the same
+// order and with the same state check as NominatimProvider.
+type GazetteerProvider struct {
+	// Gazetteer defaults to the process-wide gazetteer when nil.
+	Gazetteer *gazetteer.Gazetteer
+}
+
+func (p GazetteerProvider) Name() string { return "gazetteer" }
+
+// Resolve stops at the first candidate that names any place in an accepted
+// state: a single place is the answer, several ("Neustadt" twice in one
+// state) are left to Nominatim, which may know more from the rest of the name.
+// Continuing to a less specific candidate instead would trade an ambiguous
+// answer for a worse one.
+func (p GazetteerProvider) Resolve(_ context.Context, req Request) (Address, error) {
+	g := p.Gazetteer
+	if g == nil {
+		loaded, err := gazetteer.Default()
+		if err != nil {
+			// Not returned: a broken file must not turn every later Nominatim
+			// miss into an error.
+			gazetteerErrOnce.Do(func() {
+				logger.Error("Failed to load gazetteer, geocoding online only: %v", err)
+			})
+		}
+		g = loaded
+	}
+	if g == nil {
+		return Address{}, ErrNotFound
+	}
+
+	plan := planFor(req)
+	for _, c := range plan.candidates {
+		var accepted []models.Geocoordinates
+		for _, place := range g.Lookup(c.Place) {
+			if candidate := place.Geocoordinates(); matchesState(candidate, plan.acceptedStates) {
+				accepted = append(accepted, candidate)
+			}
+		}
+
+		switch len(accepted) {
+		case 0:
+			continue
+		case 1:
+			logger.Debug("Geocoded tournament %s via %s query %q (gazetteer) -> %s",
+				req.TournamentID, c.Source, c.Place, accepted[0].DisplayName)
+			return addressFromGeocoordinates(accepted[0], c.Source), nil
+		default:
+			return Address{}, ErrNotFound
+		}
+	}
+
+	return Address{}, ErrNotFound
+}
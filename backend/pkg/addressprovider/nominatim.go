@@ -0,0 +1,329 @@
+package addressprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
+)
+
+// defaultNominatimBaseURL is the public Nominatim search endpoint.
+const defaultNominatimBaseURL = "https://nominatim.openstreetmap.org/search.php"
+
+// maxGeocodingResponseBytes bounds how much of an upstream response is read.
+const maxGeocodingResponseBytes = 1 << 20 // 1 MiB
+
+// defaultNominatimInterval honours the Nominatim usage policy of at most one
+// request per second for the shared public instance.
+const defaultNominatimInterval = time.Second
+
+// DefaultMaxNominatimRequests bounds the total upstream requests per
+// tournament across both lookup passes. Each request is rate limited, so this
+// keeps the cost of one tournament predictable.
+const DefaultMaxNominatimRequests = 6
+
+var (
+	geocodingLimiterOnce sync.Once
+	geocodingLimiter     *ratelimit.Limiter
+)
+
+// nominatimBaseURL returns the geocoding endpoint. It is configurable so the
+// service can be pointed at a self-hosted Nominatim instance, and so tests can
+// direct it at a local mock server.
+func nominatimBaseURL() string {
+	if raw := os.Getenv("TTF_NOMINATIM_URL"); raw != "" {
+		return raw
+	}
+	return defaultNominatimBaseURL
+}
+
+// geocodingRateLimiter returns the process-wide limiter for uncached geocoding
+// requests. The interval can be lowered for a self-hosted instance via
+// TTF_NOMINATIM_INTERVAL_MS.
+func geocodingRateLimiter() *ratelimit.Limiter {
+	geocodingLimiterOnce.Do(func() {
+		interval := defaultNominatimInterval
+		if raw := os.Getenv("TTF_NOMINATIM_INTERVAL_MS"); raw != "" {
+			if ms, err := strconv.Atoi(raw); err == nil && ms >= 0 {
+				interval = time.Duration(ms) * time.Millisecond
+			}
+		}
+		geocodingLimiter = ratelimit.New(interval)
+	})
+	return geocodingLimiter
+}
+
+// RateLimiterStats reports the geocoding limiter's queue depth and waiting
+// times per priority, for /stats.
+func RateLimiterStats() []ratelimit.LaneStats {
+	return geocodingRateLimiter().Stats()
+}
+
+// ResetRateLimiterForTest rebuilds the process-wide geocoding limiter so tests
+// can exercise different intervals. It is not safe for concurrent use and is
+// intended for tests only.
+func ResetRateLimiterForTest() {
+	geocodingLimiterOnce = sync.Once{}
+	geocodingLimiter = nil
+}
+
+// NominatimProvider geocodes the request's candidates with Nominatim. It is
+// the last resort: every request costs a second of the shared rate limit.
+type NominatimProvider struct {
+	// MaxRequests defaults to DefaultMaxNominatimRequests when zero.
+	MaxRequests int
+}
+
+func (p NominatimProvider) Name() string { return "nominatim" }
+
+func (p NominatimProvider) Resolve(ctx context.Context, req Request) (Address, error) {
+	plan := planFor(req)
+	if len(plan.candidates) == 0 {
+		return Address{}, ErrNotFound
+	}
+
+	// A result that matches the state but not the queried name exactly is kept
+	// as a fallback while better candidates are tried. Without this, a query
+	// like "Bremer" happily settles for the hamlet "Bremer Sand" instead of
+	// reaching the city of Bremen via the de-adjectived candidate.
+	var fallback *Address
+
+	// Each request is rate limited, so the total number of upstream calls per
+	// tournament is capped regardless of how many candidates or passes exist.
+	budget := p.MaxRequests
+	if budget <= 0 {
+		budget = DefaultMaxNominatimRequests
+	}
+
+	// Two passes. The first restricts results to settlements, which is what a
+	// map pin should point at. Only if nothing resolves at all do we allow
+	// arbitrary features, so a club in a tiny hamlet still gets a location
+	// rather than the federation's default.
+	for _, settlementsOnly := range []bool{true, false} {
+		for _, c := range plan.candidates {
+			if budget <= 0 {
+				return fallbackOrNotFound(fallback)
+			}
+			budget--
+
+			results, err := queryNominatim(ctx, c.Place, req.TournamentID, settlementsOnly)
+			if err != nil {
+				// A transport-level failure affects every candidate equally.
+				// An inexact hit from an earlier request is still an answer.
+				if fallback != nil {
+					return *fallback, nil
+				}
+				return Address{}, err
+			}
+
+			for _, candidate := range results {
+				if !matchesState(candidate, plan.acceptedStates) {
+					continue
+				}
+
+				addr := addressFromGeocoordinates(candidate, c.Source)
+				if placeMatchesQuery(candidate, c.Place) {
+					logger.Debug("Geocoded tournament %s via %s query %q (settlements=%v) -> %s",
+						req.TournamentID, c.Source, c.Place, settlementsOnly, candidate.DisplayName)
+					return addr, nil
+				}
+
+				if fallback == nil {
+					fallback = &addr
+				}
+			}
+		}
+	}
+
+	return fallbackOrNotFound(fallback)
+}
+
+// fallbackOrNotFound uses the best inexact hit rather than falling back to the
+// federation's default coordinates.
+func fallbackOrNotFound(fallback *Address) (Address, error) {
+	if fallback == nil {
+		return Address{}, ErrNotFound
+	}
+	return *fallback, nil
+}
+
+// addressFromGeocoordinates converts a geocoding result into an Address.
+func addressFromGeocoordinates(geo models.Geocoordinates, source string) Address {
+	return Address{
+		Place:       geo.Address.Place(),
+		State:       geo.Address.State,
+		Lat:         geo.Lat,
+		Lon:         geo.Lon,
+		DisplayName: geo.DisplayName,
+		Details:     geo.Address,
+		Source:      source,
+	}
+}
+
+// matchesState reports whether a geocoding result belongs to one of the
+// accepted states.
+//
+// The structured address field is authoritative. DisplayName is only consulted
+// as a fallback for responses without address details.
+func matchesState(geo models.Geocoordinates, acceptedStates []string) bool {
+	if len(acceptedStates) == 0 {
+		return true // no restriction configured
+	}
+
+	for _, state := range acceptedStates {
+		if state == "" {
+			continue
+		}
+		if geo.Address.State != "" {
+			if strings.EqualFold(geo.Address.State, state) {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(geo.DisplayName, state) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeMatchesQuery reports whether a geocoding result actually names the
+// place that was asked for, rather than merely containing it.
+//
+// Nominatim readily returns "Bremer Sand" for "Bremer" or "Ratinger Straße"
+// for "Ratinger". Accepting those produces a pin in the wrong town.
+func placeMatchesQuery(geo models.Geocoordinates, query string) bool {
+	place := geo.Address.Place()
+	if place == "" {
+		return false
+	}
+
+	normPlace := normalizePlaceName(place)
+	normQuery := normalizePlaceName(query)
+	if normPlace == "" || normQuery == "" {
+		return false
+	}
+
+	if normPlace == normQuery {
+		return true
+	}
+
+	// Accept official long forms of the same place: "Bad Homburg" ->
+	// "Bad Homburg vor der Höhe", "Frankfurt" -> "Frankfurt am Main".
+	//
+	// Such suffixes are geographic qualifiers that begin with a lowercase
+	// preposition. Requiring that is what keeps "Bremer" from matching the
+	// hamlet "Bremer Sand", whose suffix is a capitalized noun.
+	rest, ok := strings.CutPrefix(normPlace, normQuery+" ")
+	if !ok {
+		return false
+	}
+
+	first, _, _ := strings.Cut(rest, " ")
+	return placeQualifiers[first]
+}
+
+// placeQualifiers introduce the geographic suffix of an official German place
+// name ("Frankfurt am Main", "Bad Homburg vor der Höhe").
+var placeQualifiers = map[string]bool{
+	"am": true, "an": true, "im": true, "in": true, "vor": true,
+	"bei": true, "auf": true, "ob": true, "unter": true, "a": true,
+	"i": true, "v": true,
+}
+
+// normalizePlaceName lowercases and collapses whitespace for comparison.
+func normalizePlaceName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// queryNominatim performs one geocoding request and returns the parsed results.
+func queryNominatim(ctx context.Context, query, tournamentId string, settlementsOnly bool) ([]models.Geocoordinates, error) {
+	// Honour the Nominatim usage policy: at most one request per second.
+	// Cache hits never reach this point, so cached lookups do not consume
+	// rate-limit capacity.
+	if err := geocodingRateLimiter().Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter aborted: %w", err)
+	}
+
+	reqURL, err := buildNominatimURL(query, settlementsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Nominatim requires an identifying User-Agent.
+	httpclient.ApplyDefaultHeaders(req)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := httpclient.Geocoding().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
+			logger.Warn("Geocoding throttled for tournament %s: status %d, Retry-After: %s",
+				tournamentId, res.StatusCode, retryAfter)
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxGeocodingResponseBytes))
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxGeocodingResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read error: %w", err)
+	}
+
+	var geoCoords []models.Geocoordinates
+	if err := json.Unmarshal(body, &geoCoords); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return geoCoords, nil
+}
+
+// buildNominatimURL assembles the geocoding request URL with proper encoding.
+//
+// When settlementsOnly is set, Nominatim is restricted to cities, towns,
+// villages and hamlets. Without that restriction a query like "Ratinger"
+// happily matches "Ratinger Straße" in a completely different city, which is a
+// major source of wrong map pins.
+func buildNominatimURL(query string, settlementsOnly bool) (string, error) {
+	u, err := url.Parse(nominatimBaseURL())
+	if err != nil {
+		return "", fmt.Errorf("invalid Nominatim base URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("limit", "5")
+	q.Set("accept-language", "de")
+	q.Set("format", "jsonv2")
+	// Structured address details let us verify the state reliably instead of
+	// substring-matching the display name.
+	q.Set("addressdetails", "1")
+	q.Set("countrycodes", "de")
+	if settlementsOnly {
+		q.Set("featureType", "settlement")
+	}
+	q.Set("q", query)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
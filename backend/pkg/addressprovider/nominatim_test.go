@@ -0,0 +1,250 @@
+package addressprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/gazetteer"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// mockNominatim answers queries from a lookup table and counts requests.
+func mockNominatim(t *testing.T, answers map[string][]models.Geocoordinates) *int {
+	t.Helper()
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(answers[r.URL.Query().Get("q")])
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("TTF_NOMINATIM_URL", srv.URL)
+	t.Setenv("TTF_NOMINATIM_INTERVAL_MS", "0")
+	ResetRateLimiterForTest()
+	t.Cleanup(ResetRateLimiterForTest)
+
+	return &calls
+}
+
+func TestNominatimProviderReportsCandidateSource(t *testing.T) {
+	mockNominatim(t, map[string][]models.Geocoordinates{
+		"Heidelberg": {{
+			Lat: "49.41", Lon: "8.69",
+			DisplayName: "Heidelberg, Baden-Württemberg, Deutschland",
+			Address:     models.Address{State: "Baden-Württemberg", City: "Heidelberg"},
+		}},
+	})
+
+	got, err := NominatimProvider{}.Resolve(context.Background(), Request{
+		Organizer:      "Heidelberger Tennis-Club 1890 e.V.",
+		AcceptedStates: []string{"Baden-Württemberg"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got.Lat != "49.41" || got.Details.City != "Heidelberg" {
+		t.Errorf("got %+v, want the Heidelberg result", got)
+	}
+	if got.Source != SourceOrganizerDerived {
+		t.Errorf("Source = %q, want %q", got.Source, SourceOrganizerDerived)
+	}
+}
+
+func TestNominatimProviderStaysWithinBudget(t *testing.T) {
+	calls := mockNominatim(t, nil)
+
+	_, err := NominatimProvider{MaxRequests: 3}.Resolve(context.Background(), Request{
+		Location:  "Irgendwo an der Grenze",
+		Organizer: "TC Rot-Weiß Musterhausen-Kleinkleckersdorf 1920 e.V.",
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+	if *calls != 3 {
+		t.Errorf("made %d requests, want exactly the budget of 3", *calls)
+	}
+}
+
+func TestNominatimProviderSurfacesTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("TTF_NOMINATIM_URL", srv.URL)
+	t.Setenv("TTF_NOMINATIM_INTERVAL_MS", "0")
+	ResetRateLimiterForTest()
+	t.Cleanup(ResetRateLimiterForTest)
+
+	_, err := NominatimProvider{}.Resolve(context.Background(), Request{Location: "Karlsruhe"})
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want the upstream failure", err)
+	}
+}
+
+func TestGazetteerProviderResolvesOffline(t *testing.T) {
+	g, err := gazetteer.Parse([]byte(`{"places":[
+		{"name":"Karlsruhe","state":"Baden-Württemberg","lat":"49.007","lon":"8.404"},
+		{"name":"Neustadt bei Coburg","state":"Bayern","lat":"50.330","lon":"11.120"},
+		{"name":"Neustadt an der Donau","state":"Bayern","lat":"48.800","lon":"11.770"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	p := GazetteerProvider{Gazetteer: g}
+
+	got, err := p.Resolve(context.Background(), Request{
+		Organizer:      "TC Rot-Weiß Karlsruhe",
+		AcceptedStates: []string{"Baden-Württemberg"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got.Lat != "49.007" || got.Source != SourceOrganizerDerived {
+		t.Errorf("got %+v, want Karlsruhe from the organizer name", got)
+	}
+
+	if _, err := p.Resolve(context.Background(), Request{
+		Location: "Karlsruhe", AcceptedStates: []string{"Hessen"},
+	}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other state: error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := p.Resolve(context.Background(), Request{
+		Location: "Neustadt", AcceptedStates: []string{"Bayern"},
+	}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ambiguous name: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMatchesState(t *testing.T) {
+	tests := []struct {
+		name     string
+		geo      models.Geocoordinates
+		accepted []string
+		want     bool
+	}{
+		{
+			name:     "structured address matches",
+			geo:      models.Geocoordinates{Address: models.Address{State: "Hessen"}},
+			accepted: []string{"Hessen"},
+			want:     true,
+		},
+		{
+			name:     "structured address mismatches",
+			geo:      models.Geocoordinates{Address: models.Address{State: "Berlin"}},
+			accepted: []string{"Hessen"},
+			want:     false,
+		},
+		{
+			name:     "second accepted state matches",
+			geo:      models.Geocoordinates{Address: models.Address{State: "Bremen"}},
+			accepted: []string{"Niedersachsen", "Bremen"},
+			want:     true,
+		},
+		{
+			name:     "falls back to display name when address is absent",
+			geo:      models.Geocoordinates{DisplayName: "Kassel, Hessen, Deutschland"},
+			accepted: []string{"Hessen"},
+			want:     true,
+		},
+		{
+			name: "structured address wins over display name",
+			geo: models.Geocoordinates{
+				DisplayName: "Irgendwo, Hessen, Deutschland",
+				Address:     models.Address{State: "Berlin"},
+			},
+			accepted: []string{"Hessen"},
+			want:     false,
+		},
+		{
+			name:     "no restriction accepts anything",
+			geo:      models.Geocoordinates{Address: models.Address{State: "Sachsen"}},
+			accepted: nil,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesState(tt.geo, tt.accepted); got != tt.want {
+				t.Errorf("matchesState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlaceMatchesQuery covers the guard against Nominatim returning a
+// different place that merely contains the queried word.
+func TestPlaceMatchesQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		place string
+		query string
+		want  bool
+	}{
+		{"exact match", "Bremen", "Bremen", true},
+		{"case insensitive", "bremen", "Bremen", true},
+		{"official long form", "Bad Homburg vor der Höhe", "Bad Homburg", true},
+		{"city with suffix", "Frankfurt am Main", "Frankfurt", true},
+		// Regression: "Bremer" must not settle for the hamlet "Bremer Sand".
+		{"different place containing the query", "Bremer Sand", "Bremer", false},
+		{"street-like name", "Ratinger Straße", "Ratinger", false},
+		{"unrelated", "Bösel", "Bremen", false},
+		{"empty place", "", "Bremen", false},
+		{"empty query", "Bremen", "", false},
+		{"query longer than place", "Bad", "Bad Homburg", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geo := models.Geocoordinates{Address: models.Address{City: tt.place}}
+			if got := placeMatchesQuery(geo, tt.query); got != tt.want {
+				t.Errorf("placeMatchesQuery(%q, %q) = %v, want %v",
+					tt.place, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildNominatimURL(t *testing.T) {
+	t.Setenv("TTF_NOMINATIM_URL", "https://nominatim.example.test/search.php")
+
+	raw, err := buildNominatimURL("Bad Homburg vor der Höhe", true)
+	if err != nil {
+		t.Fatalf("buildNominatimURL() error = %v", err)
+	}
+
+	if !strings.HasPrefix(raw, "https://nominatim.example.test/search.php?") {
+		t.Errorf("URL = %q, want the configured base", raw)
+	}
+	// Spaces and umlauts must be percent-encoded, not naively replaced.
+	for _, want := range []string{"format=jsonv2", "limit=5", "addressdetails=1", "accept-language=de", "q=Bad+Homburg+vor+der+H%C3%B6he"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("URL %q does not contain %q", raw, want)
+		}
+	}
+
+	if !strings.Contains(raw, "featureType=settlement") {
+		t.Errorf("URL %q does not restrict results to settlements", raw)
+	}
+
+	permissive, err := buildNominatimURL("Kleinort", false)
+	if err != nil {
+		t.Fatalf("buildNominatimURL(permissive) error = %v", err)
+	}
+	if strings.Contains(permissive, "featureType") {
+		t.Errorf("permissive URL %q should not restrict the feature type", permissive)
+	}
+
+	t.Setenv("TTF_NOMINATIM_URL", "://broken")
+	if _, err := buildNominatimURL("x", true); err == nil {
+		t.Error("expected an error for an invalid base URL")
+	}
+}
@@ -13,9 +13,13 @@ import (
 type OverrideProvider struct {
 	// Table defaults to the process-wide table when nil.
 	Table *clublocations.Table
+	// PinnedOnly limits answers to entries with explicit coordinates. Default
+	// sets it because a city-only entry still has to be geocoded, which the
+	// geocoding providers do through Candidates.
+	PinnedOnly bool
 }
 
-func (p OverrideProvider) Name() string { return "overrides" }
+func (p OverrideProvider) Name() string { return SourceOverride }
 
 func (p OverrideProvider) Resolve(_ context.Context, req Request) (Address, error) {
 	table := p.Table
@@ -32,21 +36,22 @@ func (p OverrideProvider) Resolve(_ context.Context, req Request) (Address, erro
 	}
 
 	o, ok := table.Lookup(req.Organizer)
-	if !ok {
+	if !ok || (p.PinnedOnly && !o.HasCoordinates()) {
 		return Address{}, ErrNotFound
 	}
 
 	return Address{
-		Place:  o.City,
-		State:  o.State,
-		Lat:    o.Lat,
-		Lon:    o.Lon,
-		Source: p.Name(),
+		Place:       o.City,
+		State:       o.State,
+		Lat:         o.Lat,
+		Lon:         o.Lon,
+		DisplayName: o.City,
+		Source:      p.Name(),
 	}, nil
 }
 
 // HeuristicProvider derives a settlement name from the published location or
-// the club name. It is the default source and never performs I/O.
+// the club name. It never performs I/O.
 //
 // It returns the single best candidate; callers that want to try alternatives
 // should use placename.Candidates directly.
@@ -67,17 +72,20 @@ func (p HeuristicProvider) Resolve(_ context.Context, req Request) (Address, err
 	return Address{Place: candidates[0], Source: p.Name()}, nil
 }
 
-// Default returns the provider chain used in production: curated overrides
-// first, then the name heuristic.
+// Default returns the provider chain used in production: pinned overrides,
+// then the offline gazetteer, then Nominatim.
 //
-// The upstream detail lookup (issue #55) is deliberately absent. It depends on
-// an undocumented widget protocol, so it stays opt-in until it can be driven
-// reliably and cached per club.
+// HeuristicProvider is not part of it: the geocoding providers try every
+// Candidate rather than its single best guess. The upstream detail lookup
+// (issue #55) is deliberately absent. It depends on an undocumented widget
+// protocol, so it stays opt-in until it can be driven reliably and cached per
+// club.
 func Default() Chain {
 	return Chain{
 		Providers: []Provider{
-			OverrideProvider{},
-			HeuristicProvider{},
+			OverrideProvider{PinnedOnly: true},
+			GazetteerProvider{},
+			NominatimProvider{},
 		},
 	}
 }
@@ -0,0 +1,130 @@
+// Package atom writes RFC 4287 Atom feeds.
+//
+// Only the subset feed readers need is implemented: a feed with text entries
+// linking elsewhere. encoding/xml does the escaping, so upstream titles cannot
+// break the document.
+package atom
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// namespace is the Atom XML namespace.
+const namespace = "http://www.w3.org/2005/Atom"
+
+// Feed is an Atom feed with its entries, in the order given.
+type Feed struct {
+	// ID must stay the same across fetches.
+	ID    string
+	Title string
+	// Updated is the last time the feed changed; zero means now.
+	Updated time.Time
+	// SelfURL is where the feed is served, if known.
+	SelfURL string
+	// Author is required by Atom, either on the feed or on every entry.
+	Author  string
+	Entries []Entry
+}
+
+// Entry is one feed entry.
+type Entry struct {
+	// ID must stay the same across fetches, so readers only highlight
+	// entries they have not seen.
+	ID        string
+	Title     string
+	Published time.Time
+	Updated   time.Time
+	// URL is the entry's alternate link.
+	URL     string
+	Summary string
+}
+
+type xmlFeed struct {
+	XMLName xml.Name   `xml:"feed"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []xmlLink  `xml:"link"`
+	Author  *xmlAuthor `xml:"author,omitempty"`
+	Entries []xmlEntry `xml:"entry"`
+}
+
+type xmlLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type xmlAuthor struct {
+	Name string `xml:"name"`
+}
+
+type xmlEntry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Published string    `xml:"published,omitempty"`
+	Updated   string    `xml:"updated"`
+	Links     []xmlLink `xml:"link"`
+	Summary   string    `xml:"summary,omitempty"`
+}
+
+// Write renders the feed to w.
+func (f Feed) Write(w io.Writer) error {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	doc := xmlFeed{
+		Xmlns:   namespace,
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: timestamp(updated),
+		Entries: make([]xmlEntry, 0, len(f.Entries)),
+	}
+	if f.SelfURL != "" {
+		doc.Links = append(doc.Links, xmlLink{Rel: "self", Href: f.SelfURL})
+	}
+	if f.Author != "" {
+		doc.Author = &xmlAuthor{Name: f.Author}
+	}
+
+	for _, e := range f.Entries {
+		entry := xmlEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Summary: e.Summary,
+		}
+		// updated is required; an entry without one changed with the feed.
+		entryUpdated := e.Updated
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+		entry.Updated = timestamp(entryUpdated)
+		if !e.Published.IsZero() {
+			entry.Published = timestamp(e.Published)
+		}
+		if e.URL != "" {
+			entry.Links = append(entry.Links, xmlLink{Rel: "alternate", Href: e.URL})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+// timestamp renders an RFC 3339 date-time, as Atom requires.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
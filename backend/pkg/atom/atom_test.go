@@ -0,0 +1,72 @@
+package atom
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func render(t *testing.T, f Feed) string {
+	t.Helper()
+	var b strings.Builder
+	if err := f.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestWriteFeed(t *testing.T) {
+	published := time.Date(2026, 8, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	out := render(t, Feed{
+		ID:      "tag:example.org,2026:feed",
+		Title:   "Jugend <Einzel> & Doppel",
+		Updated: published,
+		SelfURL: "https://example.org/feeds/abc.atom",
+		Author:  "Tennis Tournament Finder",
+		Entries: []Entry{{
+			ID:        "tag:example.org,2026:BAD:700001",
+			Title:     "Sommer Open",
+			Published: published,
+			URL:       "https://www.tennis.de/700001",
+			Summary:   "Veranstalter: TC Karlsruhe",
+		}},
+	})
+
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<title>Jugend &lt;Einzel&gt; &amp; Doppel</title>`,
+		// Times are written in UTC.
+		`<updated>2026-08-01T10:00:00Z</updated>`,
+		`<link rel="self" href="https://example.org/feeds/abc.atom"></link>`,
+		`<id>tag:example.org,2026:BAD:700001</id>`,
+		`<published>2026-08-01T10:00:00Z</published>`,
+		`<link rel="alternate" href="https://www.tennis.de/700001"></link>`,
+		`<summary>Veranstalter: TC Karlsruhe</summary>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// The output must parse back.
+	var doc xmlFeed
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	if len(doc.Entries) != 1 || doc.Entries[0].Updated != "2026-08-01T10:00:00Z" {
+		t.Errorf("entries = %+v, want the feed's updated time on the entry", doc.Entries)
+	}
+}
+
+func TestWriteEmptyFeed(t *testing.T) {
+	out := render(t, Feed{ID: "urn:x", Title: "leer"})
+
+	if strings.Contains(out, "<entry>") || strings.Contains(out, "<link") || strings.Contains(out, "<author>") {
+		t.Errorf("empty feed has entries, links or an author:\n%s", out)
+	}
+	if !strings.Contains(out, "<updated>") {
+		t.Errorf("feed without updated:\n%s", out)
+	}
+}
@@ -9,7 +9,10 @@
 //  1. GET the widget page. It returns a JSESSIONID cookie and bootstraps ZK
 //     with a desktop id (`dt`) and an update URI (`uu`).
 //  2. POST one `onClientInfo` command to the update URI. ZK replies with the
-//     rendered result grid as a JSON-ish payload.
+//     rendered result grid as a JSON-ish payload. When the page exposes the
+//     search form's date boxes, `onChange` commands for both go first, so
+//     the grid should be rendered for the requested window instead of the
+//     widget's default one. The response does not confirm it.
 //
 // The payload is a ZK widget tree rather than an API response, so it is parsed
 // defensively: any field that cannot be read is skipped instead of failing the
@@ -25,10 +28,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
 )
 
@@ -60,14 +66,23 @@ var (
 	// linkPattern matches the tennis.de detail link and the tournament title.
 	linkPattern = regexp.MustCompile(`href=\\?'(https://[^'\\]+)\\?'[^>]*>([^<]+)<`)
 
+	// columnPattern finds the row's columns. The first holds the dates,
+	// title and competitions, the second the MELDESCHLUSS.
+	columnPattern = regexp.MustCompile(`\['zul\.box\.Vlayout'`)
+
+	// deadlinePattern matches the start of the MELDESCHLUSS column, a label
+	// with a bare date such as "03.08.2026".
+	deadlinePattern = regexp.MustCompile(`^\['zul\.box\.Vlayout','[^']*',\{[^}]*\},\{\},\[\s*\['zul\.wgt\.Label','[^']*',\{[^}]*value:'(\d{2}\.\d{2}\.\d{4})'`)
+
 	// labelPattern matches any ZK label value, used for the competition list.
 	labelPattern = regexp.MustCompile(`value:'([^']*)'`)
 
+	// dateboxPattern finds the search form's date boxes in the widget page.
+	// The first is the start of the window, the second its end.
+	dateboxPattern = regexp.MustCompile(`\['zul\.db\.Datebox','([^']+)'`)
+
 	// totalPattern reports how many tournaments the widget found.
 	totalPattern = regexp.MustCompile(`_totalSize:(\d+)`)
-
-	// idPattern extracts the tournament id from a tennis.de detail URL.
-	idPattern = regexp.MustCompile(`detail/(\d+)`)
 )
 
 // Client talks to the BTV tournament widget.
@@ -94,6 +109,9 @@ type session struct {
 	desktopID string
 	updateURL string
 	cookies   []*http.Cookie
+	// dateboxes holds the uuids of the from/to date boxes, or nothing when
+	// the page did not render them.
+	dateboxes []string
 	// sequence backs the ZK-SID header. ZK expects a monotonically
 	// increasing sequence number per desktop; sending a constant value makes
 	// it treat later requests as duplicates and replay the first response,
@@ -138,25 +156,52 @@ func (c *Client) bootstrap(ctx context.Context) (*session, error) {
 		return nil, err
 	}
 
+	var dateboxes []string
+	for _, db := range dateboxPattern.FindAllSubmatch(body, -1) {
+		dateboxes = append(dateboxes, unescapeZK(string(db[1])))
+	}
+
 	return &session{
 		desktopID: unescapeZK(string(m[2])),
 		updateURL: updateURL,
 		cookies:   res.Cookies(),
+		dateboxes: dateboxes,
 	}, nil
 }
 
 // fetchGrid asks ZK to render the result grid.
-func (c *Client) fetchGrid(ctx context.Context, s *session) (string, error) {
+//
+// When the window is known and the page exposed both date boxes, the grid
+// request is preceded by the same onChange events a user editing the dates
+// would send. ZK processes the commands of one request in order, so the grid
+// should be rendered for the requested window.
+func (c *Client) fetchGrid(ctx context.Context, s *session, from, to time.Time) (string, error) {
 	form := url.Values{}
 	form.Set("dtid", s.desktopID)
-	form.Set("cmd_0", "onClientInfo")
-	form.Set("opt_0", "i")
+
+	cmd := 0
+	if !from.IsZero() && !to.IsZero() && len(s.dateboxes) >= 2 {
+		for i, value := range []time.Time{from, to} {
+			form.Set(fmt.Sprintf("cmd_%d", cmd), "onChange")
+			form.Set(fmt.Sprintf("uuid_%d", cmd), s.dateboxes[i])
+			form.Set(fmt.Sprintf("data_%d", cmd), fmt.Sprintf(`{"value":"%s"}`, zkDate(value)))
+			cmd++
+		}
+	}
+
+	form.Set(fmt.Sprintf("cmd_%d", cmd), "onClientInfo")
+	form.Set(fmt.Sprintf("opt_%d", cmd), "i")
 	// Client metrics the widget expects; the values only affect layout.
-	form.Set("data_0", `{"":[0,1500,1200,24,1500,1200,0,0,"1.0","landscape"]}`)
+	form.Set(fmt.Sprintf("data_%d", cmd), `{"":[0,1500,1200,24,1500,1200,0,0,"1.0","landscape"]}`)
 
 	return c.postCommand(ctx, s, form)
 }
 
+// zkDate renders a date the way the ZK client serialises Date values.
+func zkDate(t time.Time) string {
+	return "$z!t#d:" + t.Format("2006.01.02.15.04.05.000")
+}
+
 // fetchPage requests one further page of the result grid.
 func (c *Client) fetchPage(ctx context.Context, s *session, pagingUUID string, page int) (string, error) {
 	form := url.Values{}
@@ -203,18 +248,33 @@ func (c *Client) postCommand(ctx context.Context, s *session, form url.Values) (
 	return unescapeZK(string(body)), nil
 }
 
-// GetTournaments fetches the current tournament list.
+// GetTournaments fetches the tournament list for the window dateFrom..dateTo
+// (format "02.01.2006").
 //
-// The widget applies its own default date window, so dateFrom/dateTo are
-// accepted for interface symmetry but cannot be pushed upstream; results are
-// filtered locally by the caller if needed.
-func (c *Client) GetTournaments(ctx context.Context, fed models.Federation) ([]models.Tournament, error) {
+// The window is pushed into the widget's date filter when the page exposes it;
+// otherwise the widget falls back to its own default window. Nothing in the
+// response tells which window the grid was rendered for, and rows that all lie
+// in the requested window may still come from a shorter default one, so the
+// list may always miss tournaments in the requested window. Callers filter it
+// locally and must not take it as complete.
+func (c *Client) GetTournaments(ctx context.Context, fed models.Federation, dateFrom, dateTo string) ([]models.Tournament, error) {
 	s, err := c.bootstrap(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	payload, err := c.fetchGrid(ctx, s)
+	// An unparseable window is not fatal: the default window plus local
+	// filtering still gives correct, if shorter, results.
+	from, fromErr := daterange.ParseDay(dateFrom)
+	to, toErr := daterange.ParseDay(dateTo)
+	switch {
+	case fromErr != nil || toErr != nil:
+		from, to = time.Time{}, time.Time{}
+	case len(s.dateboxes) < 2:
+		logger.Debug("BTV widget exposed %d date boxes; using its default window", len(s.dateboxes))
+	}
+
+	payload, err := c.fetchGrid(ctx, s, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -222,7 +282,7 @@ func (c *Client) GetTournaments(ctx context.Context, fed models.Federation) ([]m
 	tournaments := ParseGrid(payload, fed)
 	seen := make(map[string]struct{}, len(tournaments))
 	for _, t := range tournaments {
-		seen[dedupKey(t)] = struct{}{}
+		seen[tournamentid.Of(t)] = struct{}{}
 	}
 
 	// The grid renders 10 rows per page, so the remaining pages have to be
@@ -231,10 +291,15 @@ func (c *Client) GetTournaments(ctx context.Context, fed models.Federation) ([]m
 	// ZK re-renders the paging widget with a fresh uuid in every response, so
 	// it must be re-read each time; reusing the first one silently returns
 	// page 0 again.
+	//
+	// A listing cut short is returned with an error, so it is reported as
+	// partial and never mistaken for a complete one.
+	var incomplete error
 	pagingUUID, pageCount := parsePaging(payload)
 	if pagingUUID != "" && pageCount > 1 {
 		if pageCount > maxPages {
 			logger.Warn("BTV reports %d pages, capping at %d", pageCount, maxPages)
+			incomplete = fmt.Errorf("BTV listing capped at %d of %d pages", maxPages, pageCount)
 			pageCount = maxPages
 		}
 
@@ -243,12 +308,13 @@ func (c *Client) GetTournaments(ctx context.Context, fed models.Federation) ([]m
 			if pageErr != nil {
 				// Keep what was fetched so far rather than failing outright.
 				logger.Warn("BTV page %d failed: %v", page, pageErr)
+				incomplete = fmt.Errorf("BTV page %d: %w", page, pageErr)
 				break
 			}
 
 			newOnPage := 0
 			for _, t := range ParseGrid(pagePayload, fed) {
-				key := dedupKey(t)
+				key := tournamentid.Of(t)
 				if _, dup := seen[key]; dup {
 					continue
 				}
@@ -276,7 +342,7 @@ func (c *Client) GetTournaments(ctx context.Context, fed models.Federation) ([]m
 		}
 	}
 
-	return tournaments, nil
+	return tournaments, incomplete
 }
 
 // parsePaging returns the paging widget's uuid and page count.
@@ -292,14 +358,6 @@ func parsePaging(payload string) (string, int) {
 	return m[1], count
 }
 
-// dedupKey identifies a tournament across pages.
-func dedupKey(t models.Tournament) string {
-	if t.Id != "" {
-		return t.Id
-	}
-	return t.Title + "|" + t.Date
-}
-
 // ParseGrid extracts tournaments from a ZK grid payload.
 //
 // It is exported so tests can run against a recorded fixture without any
@@ -338,9 +396,7 @@ func parseRow(chunk string, fed models.Federation) (models.Tournament, bool) {
 		Entries:  []models.CompetitionEntry{},
 	}
 
-	if id := idPattern.FindStringSubmatch(tournament.URL); id != nil {
-		tournament.Id = id[1]
-	}
+	tournament.Id = tournamentid.FromURL(tournament.URL)
 
 	if tournament.Title == "" {
 		return models.Tournament{}, false
@@ -352,9 +408,30 @@ func parseRow(chunk string, fed models.Federation) (models.Tournament, bool) {
 
 	tournament.Entries = parseCompetitions(chunk, dateMatch[0])
 
+	tournament.RegistrationDeadline = parseDeadline(chunk)
+
 	return tournament, true
 }
 
+// parseDeadline reads the MELDESCHLUSS column as an ISO day. A row without
+// that column has no deadline: any other bare date, such as the start date in
+// a changed layout, must not be taken for it.
+func parseDeadline(chunk string) string {
+	columns := columnPattern.FindAllStringIndex(chunk, -1)
+	if len(columns) < 2 {
+		return ""
+	}
+	m := deadlinePattern.FindStringSubmatch(chunk[columns[1][0]:])
+	if m == nil {
+		return ""
+	}
+	deadline, err := daterange.ParseDay(m[1])
+	if err != nil {
+		return ""
+	}
+	return deadline.Format(daterange.ISOLayout)
+}
+
 // parseCompetitions reads the abbreviated competition list, e.g.
 // "W30/E, M40/D". The abbreviations are expanded so they read like the other
 // federations' values.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -63,6 +64,9 @@ func TestParseGridFixture(t *testing.T) {
 	if !strings.HasPrefix(first.URL, "https://www.tennis.de/") {
 		t.Errorf("URL = %q, want the tennis.de detail link", first.URL)
 	}
+	if first.RegistrationDeadline != "2026-08-03" {
+		t.Errorf("RegistrationDeadline = %q, want the MELDESCHLUSS column", first.RegistrationDeadline)
+	}
 
 	if len(first.Entries) == 0 {
 		t.Fatal("no competitions parsed")
@@ -96,6 +100,40 @@ func TestParseGridExtractsEveryRow(t *testing.T) {
 	}
 }
 
+// TestParseGridDeadlineComesFromItsColumn checks that only the MELDESCHLUSS
+// column is read as the deadline, not another bare date in the row.
+func TestParseGridDeadlineComesFromItsColumn(t *testing.T) {
+	// The first column carries a bare start date, as a changed layout might.
+	const first = `['zul.box.Vlayout','a',{vflex:'min'},{},[
+['zul.wgt.Label','b',{sclass:'infotext',value:'10.08.2026'},{},[]],
+['zul.wgt.Div','c',{hflex:'min',sclass:'box-right'},{},[
+['zul.wgt.Label','d',{sclass:'infotext',value:'10.08. - 16.08.2026 in Bad Füssing'},{},[]]]],
+['zul.wgt.Html','e',{content:'<a href=\'https://www.tennis.de/spielen/turniersuche.html#detail/759920\' target=\'_blank\'>Bad Füssing Masters<\/a>'},{},[]],
+['zul.wgt.Label','f',{sclass:'infotext',value:'W30/E'},{},[]]]],`
+	const deadline = `['zul.box.Vlayout','g',{vflex:'min'},{},[
+['zul.wgt.Label','h',{sclass:'infotext',value:'03.08.2026'},{},[]]]]`
+
+	tests := []struct {
+		name string
+		row  string
+		want string
+	}{
+		{"with the column", first + deadline, "2026-08-03"},
+		{"without the column", first, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tournaments := ParseGrid(`['zul.grid.Row','r',{},{},[`+tt.row+`]]`, testFederation)
+			if len(tournaments) != 1 {
+				t.Fatalf("parsed %d tournaments, want 1", len(tournaments))
+			}
+			if got := tournaments[0].RegistrationDeadline; got != tt.want {
+				t.Errorf("RegistrationDeadline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseGridHandlesMalformedInput(t *testing.T) {
 	for _, name := range []string{"empty", "not zk", "truncated row", "no rows"} {
 		t.Run(name, func(t *testing.T) {
@@ -223,7 +261,7 @@ zk.themeName='iceblue';zkmx(
 	})
 
 	client := New(srv.URL + "/")
-	tournaments, err := client.GetTournaments(context.Background(), testFederation)
+	tournaments, err := client.GetTournaments(context.Background(), testFederation, "", "")
 	if err != nil {
 		t.Fatalf("GetTournaments() error = %v", err)
 	}
@@ -262,6 +300,89 @@ zk.themeName='iceblue';zkmx(
 	}
 }
 
+// TestGetTournamentsPushesDateWindow checks that the requested window is sent
+// to the widget's date boxes ahead of the grid request, so the widget does not
+// fall back to its default window.
+func TestGetTournamentsPushesDateWindow(t *testing.T) {
+	fixture := loadFixture(t)
+
+	var forms []url.Values
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/btvtrnsearch/zkau", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		forms = append(forms, r.PostForm)
+		_, _ = w.Write([]byte(fixture))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "S"})
+		_, _ = w.Write([]byte(`<script>zkmx([0,'u',{dt:'z_1',uu:'\x2Fbtvtrnsearch\x2Fzkau'}],
+['zul.db.Datebox','dbFrom',{format:'dd.MM.yyyy'}],
+['zul.db.Datebox','dbTo',{format:'dd.MM.yyyy'}])</script>`))
+	})
+
+	client := New(srv.URL + "/")
+	if _, err := client.GetTournaments(context.Background(), testFederation, "01.08.2026", "30.09.2026"); err != nil {
+		t.Fatalf("GetTournaments() error = %v", err)
+	}
+
+	if len(forms) == 0 {
+		t.Fatal("no update request was recorded")
+	}
+	first := forms[0]
+
+	want := map[string]string{
+		"cmd_0":  "onChange",
+		"uuid_0": "dbFrom",
+		"data_0": `{"value":"$z!t#d:2026.08.01.00.00.00.000"}`,
+		"cmd_1":  "onChange",
+		"uuid_1": "dbTo",
+		"data_1": `{"value":"$z!t#d:2026.09.30.00.00.00.000"}`,
+		"cmd_2":  "onClientInfo",
+	}
+	for key, value := range want {
+		if got := first.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+// TestGetTournamentsWithoutDateboxesKeepsDefaultWindow checks that a page
+// without date boxes still gets the plain grid request.
+func TestGetTournamentsWithoutDateboxesKeepsDefaultWindow(t *testing.T) {
+	fixture := loadFixture(t)
+
+	var first url.Values
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/btvtrnsearch/zkau", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if first == nil {
+			first = r.PostForm
+		}
+		_, _ = w.Write([]byte(fixture))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "S"})
+		_, _ = w.Write([]byte(`<script>zkmx([0,'u',{dt:'z_1',uu:'\x2Fbtvtrnsearch\x2Fzkau'}])</script>`))
+	})
+
+	client := New(srv.URL + "/")
+	if _, err := client.GetTournaments(context.Background(), testFederation, "01.08.2026", "30.09.2026"); err != nil {
+		t.Fatalf("GetTournaments() error = %v", err)
+	}
+
+	if got := first.Get("cmd_0"); got != "onClientInfo" {
+		t.Errorf("cmd_0 = %q, want onClientInfo", got)
+	}
+}
+
 // TestPaginationStopsWhenPagesRepeat guards against an endless loop if the
 // widget ever stops honouring the page offset.
 func TestPaginationStopsWhenPagesRepeat(t *testing.T) {
@@ -282,7 +403,7 @@ func TestPaginationStopsWhenPagesRepeat(t *testing.T) {
 	})
 
 	client := New(srv.URL + "/")
-	if _, err := client.GetTournaments(context.Background(), testFederation); err != nil {
+	if _, err := client.GetTournaments(context.Background(), testFederation, "", ""); err != nil {
 		t.Fatalf("GetTournaments() error = %v", err)
 	}
 
@@ -292,6 +413,40 @@ func TestPaginationStopsWhenPagesRepeat(t *testing.T) {
 	}
 }
 
+// TestFailedPageIsReportedAsPartial keeps the pages read so far, but returns
+// the error with them: a listing cut short must not pass for a complete one.
+func TestFailedPageIsReportedAsPartial(t *testing.T) {
+	fixture := loadFixture(t)
+
+	var updates int
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/btvtrnsearch/zkau", func(w http.ResponseWriter, r *http.Request) {
+		updates++
+		// The grid renders; the first further page fails.
+		if updates > 1 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(fixture))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "S"})
+		_, _ = w.Write([]byte(`<script>zkmx([0,'u',{dt:'z_1',uu:'\x2Fbtvtrnsearch\x2Fzkau'}])</script>`))
+	})
+
+	client := New(srv.URL + "/")
+	tournaments, err := client.GetTournaments(context.Background(), testFederation, "", "")
+	if err == nil {
+		t.Fatal("GetTournaments() returned nil error for a failed page")
+	}
+	if len(tournaments) == 0 {
+		t.Error("GetTournaments() dropped the pages read before the failure")
+	}
+}
+
 func TestParsePaging(t *testing.T) {
 	payload := `['zul.mesh.Paging','abc123',{$onPaging:true,totalSize:180,pageSize:10,pageCount:18,detailed:true}`
 
@@ -333,7 +488,7 @@ func TestGetTournamentsReportsUpstreamFailures(t *testing.T) {
 			defer srv.Close()
 
 			client := New(srv.URL + "/")
-			if _, err := client.GetTournaments(context.Background(), testFederation); err == nil {
+			if _, err := client.GetTournaments(context.Background(), testFederation, "", ""); err == nil {
 				t.Error("GetTournaments() returned nil error for a broken upstream")
 			}
 		})
@@ -350,7 +505,7 @@ func TestGetTournamentsRespectsContextCancellation(t *testing.T) {
 	cancel()
 
 	client := New(srv.URL + "/")
-	if _, err := client.GetTournaments(ctx, testFederation); err == nil {
+	if _, err := client.GetTournaments(ctx, testFederation, "", ""); err == nil {
 		t.Error("GetTournaments() with a cancelled context returned nil error")
 	}
 }
@@ -378,7 +533,7 @@ func TestZKSequenceIncrements(t *testing.T) {
 	})
 
 	client := New(srv.URL + "/")
-	if _, err := client.GetTournaments(context.Background(), testFederation); err != nil {
+	if _, err := client.GetTournaments(context.Background(), testFederation, "", ""); err != nil {
 		t.Fatalf("GetTournaments() error = %v", err)
 	}
 
@@ -0,0 +1,333 @@
+// Package catalog keeps one record per tournament, independent of the query
+// that found it.
+//
+// The result cache stores whole tournament lists per federation and date
+// window, so a tournament appears once in every cached window that covers it
+// and cannot be looked up by itself. The catalog is keyed by canonical id
+// ("BAD:700001", see pkg/tournamentid) instead. Every refresh upserts what it
+// fetched, so the catalog holds the latest published copy of each tournament,
+// when it was first and last seen, and which federation published it.
+package catalog
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+)
+
+// Enabled reports whether the catalog should be used. It is on by default;
+// set TTF_CATALOG=false to turn it off.
+func Enabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("TTF_CATALOG")))
+	return v != "false" && v != "0" && v != "off"
+}
+
+// Record is one tournament in the catalog.
+type Record struct {
+	// ID is the tournament's canonical id.
+	ID string `json:"id"`
+	// Federation is the id of the federation that published the tournament.
+	Federation string            `json:"federation"`
+	Tournament models.Tournament `json:"tournament"`
+	FirstSeen  time.Time         `json:"first_seen"`
+	LastSeen   time.Time         `json:"last_seen"`
+	// ChangedAt is when the tournament was last added, modified or removed.
+	// Records written before it was tracked have it zero; see Changed.
+	ChangedAt time.Time `json:"changed_at"`
+	// Removed marks a tournament a complete refresh of its window no longer
+	// returned. The record is kept, with the last copy seen.
+	Removed bool `json:"removed,omitempty"`
+	// RemovedAt is when it was removed; zero unless Removed.
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+// Changed returns when the record last changed, falling back to when it was
+// first seen.
+func (r Record) Changed() time.Time {
+	if r.ChangedAt.IsZero() {
+		return r.FirstSeen
+	}
+	return r.ChangedAt
+}
+
+// DefaultChangeRetention is how long the change log is kept.
+const DefaultChangeRetention = 90 * 24 * time.Hour
+
+// DefaultCancelledGrace is how long a removed tournament is still shown as
+// possibly cancelled.
+const DefaultCancelledGrace = 14 * 24 * time.Hour
+
+// CancelledGraceFromEnv reads TTF_CANCELLED_GRACE_DAYS, falling back to
+// DefaultCancelledGrace when it is unset or invalid. 0 turns the marker off.
+func CancelledGraceFromEnv() time.Duration {
+	raw := os.Getenv("TTF_CANCELLED_GRACE_DAYS")
+	if raw == "" {
+		return DefaultCancelledGrace
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 0 {
+		return DefaultCancelledGrace
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Catalog records the tournaments each refresh returns, and logs what changed.
+type Catalog struct {
+	store     Store
+	now       func() time.Time
+	retention time.Duration
+	grace     time.Duration
+
+	// mu serialises upserts: two refreshes of overlapping windows must not
+	// both read a record as new and log it as added twice.
+	mu sync.Mutex
+}
+
+// New creates a catalog backed by store.
+func New(store Store) *Catalog {
+	return &Catalog{
+		store:     store,
+		now:       time.Now,
+		retention: DefaultChangeRetention,
+		grace:     CancelledGraceFromEnv(),
+	}
+}
+
+// Upsert records a refresh and logs the differences to what the catalog knew:
+// tournaments seen for the first time (or again, after a removal) are added,
+// known ones whose published data differs are modified. A complete refresh
+// also removes the known tournaments of its window it did not return.
+//
+// New tournaments are first seen now; known ones keep their first-seen time
+// and take the new copy. It returns the logged changes.
+//
+// A refresh without any tournament changes nothing, complete or not: an empty
+// result is far more likely a broken upstream page than a calendar emptied
+// overnight.
+func (c *Catalog) Upsert(refresh Refresh) ([]Change, error) {
+	if c == nil || len(refresh.Tournaments) == 0 {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	records := make([]Record, 0, len(refresh.Tournaments))
+	var changes []Change
+	seen := make(map[string]bool, len(refresh.Tournaments))
+
+	for _, t := range refresh.Tournaments {
+		id := t.CanonicalId
+		if id == "" {
+			id = tournamentid.Canonical(refresh.Federation, t)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		record, found, err := c.store.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		change := Change{ID: id, Tournament: t}
+		switch {
+		case !found:
+			record = Record{ID: id, FirstSeen: now}
+			change.Type = Added
+		case record.Removed:
+			change.Type = Added
+		default:
+			if change.Fields = changedFields(record.Tournament, t); len(change.Fields) > 0 {
+				change.Type = Modified
+			}
+		}
+		if change.Type != "" {
+			changes = append(changes, change)
+			record.ChangedAt = now
+		}
+
+		record.Federation = refresh.Federation
+		record.Tournament = t
+		record.LastSeen = now
+		record.Removed = false
+		record.RemovedAt = time.Time{}
+		records = append(records, record)
+	}
+
+	if refresh.Complete {
+		err := c.store.ForEachPrefix(refresh.Federation+":", func(record Record) error {
+			if seen[record.ID] || record.Removed || !inWindow(record.Tournament, refresh.From, refresh.To) {
+				return nil
+			}
+			record.Removed = true
+			record.RemovedAt = now
+			record.ChangedAt = now
+			records = append(records, record)
+			changes = append(changes, Change{Type: Removed, ID: record.ID, Tournament: record.Tournament})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range changes {
+		changes[i].Federation = refresh.Federation
+		changes[i].At = now
+	}
+
+	changes, err := c.store.Commit(records, changes)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		if err := c.store.PruneChanges(now.Add(-c.retention)); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// Changes returns up to limit changes after the cursor, oldest first, and
+// the cursor to resume from. With federations given, only their changes are
+// returned. truncated reports that changes after the cursor have already been
+// pruned, so a client should reload everything instead of applying the log.
+func (c *Catalog) Changes(after uint64, federations map[string]bool, limit int) (changes []Change, next uint64, truncated bool, err error) {
+	next = after
+	if c == nil {
+		return nil, next, false, nil
+	}
+
+	first, err := c.store.FirstChange()
+	if err != nil {
+		return nil, next, false, err
+	}
+	// The log is numbered without gaps, so a first entry past the one after
+	// the cursor means some were pruned.
+	truncated = first > after+1
+
+	err = c.store.ChangesAfter(after, func(change Change) (bool, error) {
+		next = change.Seq
+		if len(federations) == 0 || federations[change.Federation] {
+			changes = append(changes, change)
+		}
+		return len(changes) < limit, nil
+	})
+	return changes, next, truncated, err
+}
+
+// PossiblyCancelled returns the tournaments of a federation's window that
+// were removed within the grace period and have not started yet, marked with
+// models.StatusPossiblyCancelled.
+//
+// Callers add them to the results of complete refreshes only: after a failed
+// or partial one, a missing tournament says nothing.
+func (c *Catalog) PossiblyCancelled(federation string, from, to time.Time) ([]models.Tournament, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	now := c.now()
+	var tournaments []models.Tournament
+	err := c.store.ForEachPrefix(federation+":", func(record Record) error {
+		if c.possiblyCancelled(record, now) && inWindow(record.Tournament, from, to) {
+			t := record.Tournament
+			t.Status = models.StatusPossiblyCancelled
+			tournaments = append(tournaments, t)
+		}
+		return nil
+	})
+	return tournaments, err
+}
+
+// Status returns the status to publish a record with: "" while its
+// federation lists it, models.StatusPossiblyCancelled during the grace period
+// after a removal. A removed record past it is reported like a listed one, but
+// it is no longer returned by searches or the detail endpoint.
+func (c *Catalog) Status(record Record) string {
+	if c != nil && c.possiblyCancelled(record, c.now()) {
+		return models.StatusPossiblyCancelled
+	}
+	return ""
+}
+
+// possiblyCancelled reports whether a removed record is within the grace
+// period and still ahead. A tournament that has started or is over is not
+// flagged: it is unlisted because it happened, not because it was called off.
+func (c *Catalog) possiblyCancelled(record Record, now time.Time) bool {
+	if !record.Removed || now.Sub(record.RemovedAt) >= c.grace {
+		return false
+	}
+	start, err := daterange.ParseISO(record.Tournament.StartDate)
+	if err != nil {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.After(today)
+}
+
+// Get returns the record of a canonical id.
+func (c *Catalog) Get(id string) (Record, bool, error) {
+	if c == nil {
+		return Record{}, false, nil
+	}
+	return c.store.Get(id)
+}
+
+// Since returns when the catalog began recording. A tournament it first saw
+// then was not necessarily new; only first-seen times after it tell.
+func (c *Catalog) Since() time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	return c.store.Created()
+}
+
+// Persistent reports whether the catalog survives a restart. One that does
+// not begins again with every tournament first seen after the restart.
+func (c *Catalog) Persistent() bool {
+	return c != nil && c.store.Persistent()
+}
+
+// Stats summarises catalog contents for diagnostics.
+type Stats struct {
+	Tournaments  int            `json:"tournaments"`
+	ByFederation map[string]int `json:"by_federation"`
+	// PossiblyCancelled counts the removed tournaments still flagged.
+	PossiblyCancelled int `json:"possibly_cancelled"`
+}
+
+// Stats reports how many tournaments the catalog holds.
+func (c *Catalog) Stats() (Stats, error) {
+	stats := Stats{ByFederation: make(map[string]int)}
+	if c == nil {
+		return stats, nil
+	}
+
+	now := c.now()
+	err := c.store.ForEach(func(record Record) error {
+		stats.Tournaments++
+		stats.ByFederation[record.Federation]++
+		if c.possiblyCancelled(record, now) {
+			stats.PossiblyCancelled++
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Close closes the underlying store.
+func (c *Catalog) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.store.Close()
+}
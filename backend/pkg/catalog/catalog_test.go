@@ -0,0 +1,412 @@
+package catalog
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// clock is a settable time source.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestCatalog(store Store) (*Catalog, *clock) {
+	clk := &clock{t: time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)}
+	c := New(store)
+	c.now = clk.now
+	return c, clk
+}
+
+func TestUpsertTracksFirstAndLastSeen(t *testing.T) {
+	c, clk := newTestCatalog(NewMemoryStore())
+	first := clk.t
+
+	if _, err := c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{
+		{Id: "700001", CanonicalId: "BAD:700001", Title: "Sommer Open"},
+	}}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	clk.t = clk.t.Add(time.Hour)
+	if _, err := c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{
+		{Id: "700001", CanonicalId: "BAD:700001", Title: "Sommer Open 2026"},
+	}}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	got, found, err := c.Get("BAD:700001")
+	if err != nil || !found {
+		t.Fatalf("Get() = %v, %v", found, err)
+	}
+	if !got.FirstSeen.Equal(first) || !got.LastSeen.Equal(clk.t) {
+		t.Errorf("seen %s..%s, want %s..%s", got.FirstSeen, got.LastSeen, first, clk.t)
+	}
+	if got.Tournament.Title != "Sommer Open 2026" || got.Federation != "BAD" {
+		t.Errorf("record = %+v, want the latest copy", got)
+	}
+}
+
+// TestUpsertDerivesTheCanonicalId covers tournaments that reach the catalog
+// without one, e.g. from sources registered by tests.
+func TestUpsertDerivesTheCanonicalId(t *testing.T) {
+	c, _ := newTestCatalog(NewMemoryStore())
+
+	// The same upstream id in two federations are two tournaments.
+	c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{{Id: "1", Title: "Baden"}}})
+	c.Upsert(Refresh{Federation: "WTB", Tournaments: []models.Tournament{{Id: "1", Title: "Württemberg"}}})
+
+	for id, want := range map[string]string{"BAD:1": "Baden", "WTB:1": "Württemberg"} {
+		got, found, _ := c.Get(id)
+		if !found || got.Tournament.Title != want {
+			t.Errorf("Get(%q) = %+v, %v; want %s", id, got, found, want)
+		}
+	}
+
+	stats, err := c.Stats()
+	if err != nil || stats.Tournaments != 2 || stats.ByFederation["BAD"] != 1 {
+		t.Errorf("Stats() = %+v, %v", stats, err)
+	}
+}
+
+func TestNilCatalogIsANoOp(t *testing.T) {
+	var c *Catalog
+	if _, err := c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{{Id: "1"}}}); err != nil {
+		t.Errorf("Upsert() error = %v", err)
+	}
+	if changes, _, _, err := c.Changes(0, nil, 10); len(changes) != 0 || err != nil {
+		t.Errorf("Changes() = %v, %v", changes, err)
+	}
+	if _, found, err := c.Get("BAD:1"); found || err != nil {
+		t.Errorf("Get() = %v, %v", found, err)
+	}
+}
+
+func TestBoltStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tournaments.bolt")
+
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	c, clk := newTestCatalog(store)
+	if _, err := c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{{Id: "700001", Title: "Sommer Open"}}}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	created := store.Created()
+	store.Close()
+
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+
+	if !store.Created().Equal(created) || created.IsZero() || !store.Persistent() {
+		t.Errorf("Created() after reopen = %v, want %v", store.Created(), created)
+	}
+
+	got, found, err := New(store).Get("BAD:700001")
+	if err != nil || !found {
+		t.Fatalf("Get() after reopen = %v, %v", found, err)
+	}
+	if got.Tournament.Title != "Sommer Open" || !got.FirstSeen.Equal(clk.t) {
+		t.Errorf("record = %+v", got)
+	}
+
+	// The log continues where it stopped.
+	c, _ = newTestCatalog(store)
+	changes, err := c.Upsert(Refresh{Federation: "BAD", Tournaments: []models.Tournament{{Id: "700002", Title: "Herbst Cup"}}})
+	if err != nil || len(changes) != 1 || changes[0].Seq != 2 {
+		t.Errorf("Upsert() after reopen = %+v, %v; want seq 2", changes, err)
+	}
+}
+
+// summer returns a tournament of August 2026 in Baden.
+func summer(id, title string) models.Tournament {
+	return models.Tournament{
+		Id: id, CanonicalId: "BAD:" + id, Title: title,
+		Date: "10.08.2026", StartDate: "2026-08-10", EndDate: "2026-08-10",
+		Location: "Karlsruhe",
+	}
+}
+
+// august is a complete refresh of August 2026.
+func august(tournaments ...models.Tournament) Refresh {
+	return Refresh{
+		Federation:  "BAD",
+		Tournaments: tournaments,
+		From:        time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC),
+		Complete:    true,
+	}
+}
+
+// changeTypes lists changes as "type id [fields]" for comparison.
+func changeTypes(changes []Change) []string {
+	out := make([]string, 0, len(changes))
+	for _, c := range changes {
+		s := c.Type + " " + c.ID
+		if len(c.Fields) > 0 {
+			s += " " + strings.Join(c.Fields, ",")
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestUpsertLogsChanges(t *testing.T) {
+	c, clk := newTestCatalog(NewMemoryStore())
+
+	moved := summer("2", "Herbst Cup")
+	moved.Date, moved.StartDate, moved.EndDate = "17.08.2026", "2026-08-17", "2026-08-17"
+	relocated := summer("3", "Stadtmeisterschaft")
+	relocated.Location = "Mannheim"
+	pinned := summer("4", "Jugend Cup")
+	pinned.Lat, pinned.Lon = "49.0", "8.4"
+	september := summer("5", "September Open")
+	september.Date, september.StartDate, september.EndDate = "05.09.2026", "2026-09-05", "2026-09-05"
+
+	steps := []struct {
+		name    string
+		refresh Refresh
+		want    []string
+	}{
+		{
+			name:    "first refresh adds everything",
+			refresh: august(summer("1", "Sommer Open"), summer("2", "Herbst Cup"), summer("3", "Stadtmeisterschaft"), summer("4", "Jugend Cup"), september),
+			want:    []string{"added BAD:1", "added BAD:2", "added BAD:3", "added BAD:4", "added BAD:5"},
+		},
+		{
+			name:    "same data changes nothing, coordinates are not a change",
+			refresh: august(summer("1", "Sommer Open"), summer("2", "Herbst Cup"), summer("3", "Stadtmeisterschaft"), pinned),
+			want:    []string{},
+		},
+		{
+			name:    "date and location changes are modifications",
+			refresh: august(summer("1", "Sommer Open"), moved, relocated, pinned),
+			want:    []string{"modified BAD:2 date", "modified BAD:3 location"},
+		},
+		{
+			name:    "missing from a complete refresh is removed, outside the window is not",
+			refresh: august(moved, relocated, pinned),
+			want:    []string{"removed BAD:1"},
+		},
+		{
+			name: "a partial refresh removes nothing",
+			refresh: Refresh{
+				Federation:  "BAD",
+				Tournaments: []models.Tournament{moved},
+				From:        august().From,
+				To:          august().To,
+			},
+			want: []string{},
+		},
+		{
+			name:    "an empty complete refresh removes nothing",
+			refresh: august(),
+			want:    []string{},
+		},
+		{
+			name:    "a removed tournament that returns is added again",
+			refresh: august(summer("1", "Sommer Open"), moved, relocated, pinned),
+			want:    []string{"added BAD:1"},
+		},
+	}
+
+	for _, step := range steps {
+		clk.t = clk.t.Add(time.Hour)
+		changes, err := c.Upsert(step.refresh)
+		if err != nil {
+			t.Fatalf("%s: Upsert() error = %v", step.name, err)
+		}
+		if got := changeTypes(changes); !slices.Equal(got, step.want) {
+			t.Errorf("%s: changes = %v, want %v", step.name, got, step.want)
+		}
+		for _, change := range changes {
+			if change.Seq == 0 || change.Federation != "BAD" || !change.At.Equal(clk.t) {
+				t.Errorf("%s: change = %+v, want seq, federation and time set", step.name, change)
+			}
+		}
+	}
+
+	if record, _, _ := c.Get("BAD:1"); record.Removed {
+		t.Errorf("BAD:1 still marked removed after it returned")
+	}
+}
+
+func TestRemovedTournamentsArePossiblyCancelled(t *testing.T) {
+	t.Setenv("TTF_CANCELLED_GRACE_DAYS", "")
+	c, clk := newTestCatalog(NewMemoryStore())
+
+	// Today is 2026-08-01; one tournament is over, one is still ahead.
+	past := summer("1", "Juli Open")
+	past.Date, past.StartDate, past.EndDate = "30.07.2026", "2026-07-30", "2026-07-30"
+	ahead := summer("2", "Sommer Open")
+	window := august()
+	window.From = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	window.Tournaments = []models.Tournament{past, ahead, summer("3", "Herbst Cup")}
+	c.Upsert(window)
+
+	cancelled := func() []string {
+		t.Helper()
+		got, err := c.PossiblyCancelled("BAD", window.From, window.To)
+		if err != nil {
+			t.Fatalf("PossiblyCancelled() error = %v", err)
+		}
+		var ids []string
+		for _, tm := range got {
+			if tm.Status != models.StatusPossiblyCancelled {
+				t.Errorf("%s has status %q", tm.CanonicalId, tm.Status)
+			}
+			ids = append(ids, tm.CanonicalId)
+		}
+		return ids
+	}
+
+	// A partial refresh without them flags nothing.
+	partial := window
+	partial.Tournaments = []models.Tournament{summer("3", "Herbst Cup")}
+	partial.Complete = false
+	c.Upsert(partial)
+	if got := cancelled(); len(got) != 0 {
+		t.Errorf("after a partial refresh = %v, want none", got)
+	}
+
+	// A complete one flags only the tournament still ahead.
+	clk.t = clk.t.Add(time.Hour)
+	complete := partial
+	complete.Complete = true
+	c.Upsert(complete)
+	if got := cancelled(); !slices.Equal(got, []string{"BAD:2"}) {
+		t.Errorf("after a complete refresh = %v, want [BAD:2]", got)
+	}
+	record, _, _ := c.Get("BAD:2")
+	if c.Status(record) != models.StatusPossiblyCancelled {
+		t.Errorf("Status(BAD:2) = %q", c.Status(record))
+	}
+	if stats, _ := c.Stats(); stats.PossiblyCancelled != 1 {
+		t.Errorf("Stats().PossiblyCancelled = %d, want 1", stats.PossiblyCancelled)
+	}
+
+	// The flag expires after the grace period...
+	clk.t = clk.t.Add(DefaultCancelledGrace)
+	if got := cancelled(); len(got) != 0 {
+		t.Errorf("after the grace period = %v, want none", got)
+	}
+
+	// ...and is cleared when the tournament is listed again.
+	clk.t = clk.t.Add(-DefaultCancelledGrace)
+	c.Upsert(august(ahead, summer("3", "Herbst Cup")))
+	if got := cancelled(); len(got) != 0 {
+		t.Errorf("after it returned = %v, want none", got)
+	}
+}
+
+func TestChangesResumeFromCursor(t *testing.T) {
+	c, _ := newTestCatalog(NewMemoryStore())
+	c.Upsert(august(summer("1", "Sommer Open"), summer("2", "Herbst Cup")))
+	c.Upsert(Refresh{Federation: "WTB", Tournaments: []models.Tournament{{Id: "9", Title: "Stuttgart Open"}}})
+	c.Upsert(august(summer("1", "Sommer Open"), summer("2", "Herbst Cup"), summer("3", "Jugend Cup")))
+
+	page, next, truncated, err := c.Changes(0, nil, 2)
+	if err != nil || truncated {
+		t.Fatalf("Changes() = %v, %v", truncated, err)
+	}
+	if got := changeTypes(page); !slices.Equal(got, []string{"added BAD:1", "added BAD:2"}) {
+		t.Errorf("first page = %v", got)
+	}
+
+	page, next, _, _ = c.Changes(next, nil, 2)
+	if got := changeTypes(page); !slices.Equal(got, []string{"added WTB:9", "added BAD:3"}) {
+		t.Errorf("second page = %v", got)
+	}
+
+	page, last, _, _ := c.Changes(next, nil, 2)
+	if len(page) != 0 || last != next {
+		t.Errorf("after the end = %v, cursor %d; want nothing, cursor %d", page, last, next)
+	}
+
+	// Filtering by federation still advances the cursor past the others.
+	page, next, _, _ = c.Changes(0, map[string]bool{"WTB": true}, 10)
+	if got := changeTypes(page); !slices.Equal(got, []string{"added WTB:9"}) || next != 4 {
+		t.Errorf("WTB changes = %v, cursor %d", got, next)
+	}
+}
+
+func TestChangesReportPrunedCursors(t *testing.T) {
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "tournaments.bolt"))
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	defer bolt.Close()
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "bolt": bolt} {
+		c, clk := newTestCatalog(store)
+		c.retention = 24 * time.Hour
+
+		c.Upsert(august(summer("1", "Sommer Open")))
+		clk.t = clk.t.Add(48 * time.Hour)
+		c.Upsert(august(summer("1", "Sommer Open"), summer("2", "Herbst Cup")))
+
+		page, _, truncated, err := c.Changes(0, nil, 10)
+		if err != nil || !truncated {
+			t.Errorf("%s: Changes(0) truncated = %v, %v; want true", name, truncated, err)
+		}
+		if got := changeTypes(page); !slices.Equal(got, []string{"added BAD:2"}) {
+			t.Errorf("%s: Changes(0) = %v, want only the kept change", name, got)
+		}
+
+		if _, _, truncated, _ := c.Changes(1, nil, 10); truncated {
+			t.Errorf("%s: Changes(1) truncated, but nothing after it was pruned", name)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint64
+		ok   bool
+	}{
+		{"", 0, true},
+		{"42", 42, true},
+		{FormatCursor(7), 7, true},
+		{"-1", 0, false},
+		{"abc", 0, false},
+	} {
+		got, ok := ParseCursor(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseCursor(%q) = %d, %v; want %d, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false, "0": false, "off": false} {
+		t.Setenv("TTF_CATALOG", value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestCancelledGraceFromEnv(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":    DefaultCancelledGrace,
+		"7":   7 * 24 * time.Hour,
+		"0":   0,
+		"-1":  DefaultCancelledGrace,
+		"two": DefaultCancelledGrace,
+	} {
+		t.Setenv("TTF_CANCELLED_GRACE_DAYS", value)
+		if got := CancelledGraceFromEnv(); got != want {
+			t.Errorf("CancelledGraceFromEnv() with %q = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// Change types.
+const (
+	Added    = "added"
+	Modified = "modified"
+	Removed  = "removed"
+)
+
+// Change is one entry of the change log.
+type Change struct {
+	// Seq orders the log. It is assigned by the store and doubles as the
+	// cursor clients resume from.
+	Seq uint64 `json:"seq"`
+	// Type is "added", "modified" or "removed".
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	Federation string    `json:"federation"`
+	At         time.Time `json:"at"`
+	// Fields names what a modification changed: "title", "date",
+	// "location", "competitions" or "registration_deadline".
+	Fields []string `json:"fields,omitempty"`
+	// Tournament is the new copy, or the last one seen for a removal.
+	Tournament models.Tournament `json:"tournament"`
+}
+
+// FormatCursor renders a log position as the opaque cursor clients pass back.
+func FormatCursor(seq uint64) string {
+	return strconv.FormatUint(seq, 10)
+}
+
+// ParseCursor reads a cursor. An empty cursor is the start of the log.
+func ParseCursor(s string) (uint64, bool) {
+	if s == "" {
+		return 0, true
+	}
+	seq, err := strconv.ParseUint(s, 10, 64)
+	return seq, err == nil
+}
+
+// Refresh is the outcome of one upstream fetch of a federation.
+type Refresh struct {
+	Federation  string
+	Tournaments []models.Tournament
+	// From and To are the days the fetch covered.
+	From, To time.Time
+	// Complete reports that the fetch succeeded and returned every
+	// tournament in the window, so a known tournament it did not return has
+	// disappeared upstream. A failed or partial fetch only adds and updates.
+	Complete bool
+}
+
+// changedFields compares the published data players care about. Coordinates
+// are left out: a pin resolving in the background is not a change upstream.
+func changedFields(old, new models.Tournament) []string {
+	var fields []string
+	if old.Title != new.Title {
+		fields = append(fields, "title")
+	}
+	if old.Date != new.Date || old.StartDate != new.StartDate || old.EndDate != new.EndDate {
+		fields = append(fields, "date")
+	}
+	if old.Location != new.Location || old.Organizer != new.Organizer {
+		fields = append(fields, "location")
+	}
+	if !slices.EqualFunc(old.Entries, new.Entries, func(a, b models.CompetitionEntry) bool {
+		return a.Competition == b.Competition && a.SkillLevel == b.SkillLevel
+	}) {
+		fields = append(fields, "competitions")
+	}
+	if old.RegistrationDeadline != new.RegistrationDeadline {
+		fields = append(fields, "registration_deadline")
+	}
+	return fields
+}
+
+// inWindow reports whether a tournament's parsed days touch the refreshed
+// window. Without parsed days it cannot be told, so it is not.
+func inWindow(t models.Tournament, from, to time.Time) bool {
+	start, err := daterange.ParseISO(t.StartDate)
+	if err != nil {
+		return false
+	}
+	end, err := daterange.ParseISO(t.EndDate)
+	if err != nil {
+		end = start
+	}
+	return daterange.Range{Start: start, End: end}.Overlaps(from, to)
+}
@@ -0,0 +1,364 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// tournamentsBucket holds one record per tournament, keyed by canonical id.
+const tournamentsBucket = "tournaments"
+
+// changesBucket holds the change log, keyed by big-endian sequence number so
+// a cursor walks it in order.
+const changesBucket = "tournament_changes"
+
+// metaBucket holds facts about the catalog itself, such as when it was
+// created.
+const metaBucket = "catalog_meta"
+
+var createdKey = []byte("created")
+
+// Store persists tournament records and the change log.
+type Store interface {
+	Get(id string) (Record, bool, error)
+	// Commit stores the records and appends the changes in one write, so a
+	// refresh is recorded completely or not at all. It returns the changes
+	// with their sequence numbers assigned.
+	Commit(records []Record, changes []Change) ([]Change, error)
+	ForEach(fn func(record Record) error) error
+	// ForEachPrefix visits the records whose id starts with prefix, such as
+	// one federation's "BAD:".
+	ForEachPrefix(prefix string, fn func(record Record) error) error
+	// ChangesAfter visits the changes after seq in order, until fn returns
+	// false.
+	ChangesAfter(seq uint64, fn func(change Change) (bool, error)) error
+	// FirstChange returns the sequence number of the oldest change kept, or
+	// the next one to be assigned when the log is empty.
+	FirstChange() (uint64, error)
+	// PruneChanges drops the changes logged before the given time.
+	PruneChanges(before time.Time) error
+	// Created returns when the store began recording. Tournaments it first
+	// saw then may have been published long before.
+	Created() time.Time
+	// Persistent reports whether the records outlive the process.
+	Persistent() bool
+	Close() error
+}
+
+// BoltStore persists records in BoltDB.
+type BoltStore struct {
+	db      *bbolt.DB
+	created time.Time
+}
+
+// NewBoltStore opens (or creates) the catalog database at dbPath.
+func NewBoltStore(dbPath string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create catalog directory: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tournament catalog at %s: %w", dbPath, err)
+	}
+
+	var created time.Time
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{tournamentsBucket, changesBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		meta, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return err
+		}
+		// A catalog from before the creation time was recorded counts as
+		// created now.
+		if created.UnmarshalText(meta.Get(createdKey)) == nil {
+			return nil
+		}
+		created = time.Now()
+		data, err := created.MarshalText()
+		if err != nil {
+			return err
+		}
+		return meta.Put(createdKey, data)
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create catalog buckets: %w", err)
+	}
+
+	return &BoltStore{db: db, created: created}, nil
+}
+
+func (s *BoltStore) Get(id string) (Record, bool, error) {
+	var record Record
+	var found bool
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(tournamentsBucket))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		// An unreadable record is a miss; the next refresh overwrites it.
+		if err := json.Unmarshal(data, &record); err == nil {
+			found = true
+		}
+		return nil
+	})
+
+	return record, found, err
+}
+
+func (s *BoltStore) Commit(records []Record, changes []Change) ([]Change, error) {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(tournamentsBucket))
+		log := tx.Bucket([]byte(changesBucket))
+		if bucket == nil || log == nil {
+			return fmt.Errorf("catalog buckets do not exist")
+		}
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(record.ID), data); err != nil {
+				return err
+			}
+		}
+		for i := range changes {
+			seq, err := log.NextSequence()
+			if err != nil {
+				return err
+			}
+			changes[i].Seq = seq
+			data, err := json.Marshal(changes[i])
+			if err != nil {
+				return err
+			}
+			if err := log.Put(seqKey(seq), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (s *BoltStore) ForEach(fn func(record Record) error) error {
+	return s.ForEachPrefix("", fn)
+}
+
+func (s *BoltStore) ForEachPrefix(prefix string, fn func(record Record) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(tournamentsBucket))
+		if bucket == nil {
+			return nil
+		}
+		p := []byte(prefix)
+		c := bucket.Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				continue // skip corrupt records
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) ChangesAfter(seq uint64, fn func(change Change) (bool, error)) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		log := tx.Bucket([]byte(changesBucket))
+		if log == nil {
+			return nil
+		}
+		c := log.Cursor()
+		for k, v := c.Seek(seqKey(seq + 1)); k != nil; k, v = c.Next() {
+			var change Change
+			if err := json.Unmarshal(v, &change); err != nil {
+				continue // skip corrupt entries
+			}
+			more, err := fn(change)
+			if err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) FirstChange() (uint64, error) {
+	var first uint64
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		log := tx.Bucket([]byte(changesBucket))
+		if log == nil {
+			return nil
+		}
+		if k, _ := log.Cursor().First(); k != nil {
+			first = binary.BigEndian.Uint64(k)
+		} else {
+			first = log.Sequence() + 1
+		}
+		return nil
+	})
+	return first, err
+}
+
+func (s *BoltStore) PruneChanges(before time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		log := tx.Bucket([]byte(changesBucket))
+		if log == nil {
+			return nil
+		}
+		c := log.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var change Change
+			if err := json.Unmarshal(v, &change); err == nil && !change.At.Before(before) {
+				return nil
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// seqKey encodes a sequence number so keys sort in log order.
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+func (s *BoltStore) Created() time.Time { return s.created }
+
+func (s *BoltStore) Persistent() bool { return true }
+
+func (s *BoltStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// MemoryStore keeps records in memory. It is used by tests and as a fallback
+// when the persistent store cannot be opened.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+	changes []Change
+	seq     uint64
+	created time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record), created: time.Now()}
+}
+
+func (s *MemoryStore) Get(id string) (Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	return record, ok, nil
+}
+
+func (s *MemoryStore) Commit(records []Record, changes []Change) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		s.records[record.ID] = record
+	}
+	for i := range changes {
+		s.seq++
+		changes[i].Seq = s.seq
+		s.changes = append(s.changes, changes[i])
+	}
+	return changes, nil
+}
+
+func (s *MemoryStore) ForEach(fn func(record Record) error) error {
+	return s.ForEachPrefix("", fn)
+}
+
+func (s *MemoryStore) ForEachPrefix(prefix string, fn func(record Record) error) error {
+	s.mu.RLock()
+	snapshot := make([]Record, 0, len(s.records))
+	for id, record := range s.records {
+		if strings.HasPrefix(id, prefix) {
+			snapshot = append(snapshot, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, record := range snapshot {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) ChangesAfter(seq uint64, fn func(change Change) (bool, error)) error {
+	s.mu.RLock()
+	snapshot := append([]Change(nil), s.changes...)
+	s.mu.RUnlock()
+
+	for _, change := range snapshot {
+		if change.Seq <= seq {
+			continue
+		}
+		more, err := fn(change)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FirstChange() (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.changes) == 0 {
+		return s.seq + 1, nil
+	}
+	return s.changes[0].Seq, nil
+}
+
+func (s *MemoryStore) PruneChanges(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := 0
+	for i < len(s.changes) && s.changes[i].At.Before(before) {
+		i++
+	}
+	s.changes = s.changes[i:]
+	return nil
+}
+
+func (s *MemoryStore) Created() time.Time { return s.created }
+
+func (s *MemoryStore) Persistent() bool { return false }
+
+func (s *MemoryStore) Close() error { return nil }
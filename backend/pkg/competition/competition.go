@@ -0,0 +1,382 @@
+// Package competition classifies the free-text competition names federations
+// publish ("Herren 40 Einzel", "U14 männlich", "Mixed") into gender, age
+// class, discipline and draw type, and matches them against the frontend's
+// competition filter ("Herren+Einzel").
+//
+// Upstream filters cannot be relied on for this: the new nuLiga API only knows
+// coarse age categories ("general" covers both Herren and Damen) and the BTV
+// widget has no competition filter at all. Parsing the names lets one
+// unfiltered scrape serve every filter, with the same rules for every source.
+//
+// Names observed in live data:
+//
+//	"Herren Einzel"         nuLiga, open class with discipline
+//	"Damen 40"              nuLiga, senior class without discipline
+//	"Junioren U14"          nuLiga, junior class
+//	"U14 männlich"          nuLiga, junior class with the gender last
+//	"Knaben 16 Doppel"      nuLiga, older junior naming
+//	"Damen 30 Einzel"       BTV, expanded from "W30/E"
+//	"Herren 14 Doppel"      BTV, expanded from "M14/D"; ages below 30 are juniors
+//	"Herren Einzel Quali"   any source, with a draw type
+package competition
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Gender is who may enter a competition.
+type Gender int
+
+const (
+	// GenderUnknown means the name states no gender, so none is assumed
+	// restricted; it is reported as "open".
+	GenderUnknown Gender = iota
+	GenderMen
+	GenderWomen
+	GenderMixed
+)
+
+// String renders the gender as exposed in the API.
+func (g Gender) String() string {
+	switch g {
+	case GenderMen:
+		return "Herren"
+	case GenderWomen:
+		return "Damen"
+	case GenderMixed:
+		return "Mixed"
+	default:
+		return "open"
+	}
+}
+
+// AgeGroup is the broad age bracket of a competition.
+type AgeGroup int
+
+const (
+	AgeUnknown AgeGroup = iota
+	AgeOpen
+	AgeJunior
+	AgeSenior
+)
+
+// AgeClass is a competition's age bracket and, where stated, its limit.
+//
+// German age classes are defined by the age a player turns in the calendar
+// year: a senior class "40" is open from the year of the 40th birthday, and a
+// junior class "J14" ("Junioren 14", "Knaben 14") or "U14" up to the year of
+// the 14th.
+type AgeClass struct {
+	Group AgeGroup
+	// Age is the stated limit, or zero when the name gives none.
+	Age int
+	// Under marks a U-xx junior class.
+	Under bool
+}
+
+// Senior classes run from 30 to 85 in steps of five.
+const (
+	seniorFrom = 30
+	seniorTo   = 85
+)
+
+// String renders the class as exposed in the API: "open", "J14", "U14",
+// "S40", or "junior"/"senior" without a stated limit. It is empty when the
+// name gives no age information.
+func (a AgeClass) String() string {
+	switch a.Group {
+	case AgeOpen:
+		return "open"
+	case AgeJunior:
+		switch {
+		case a.Age == 0:
+			return "junior"
+		case a.Under:
+			return "U" + strconv.Itoa(a.Age)
+		default:
+			return "J" + strconv.Itoa(a.Age)
+		}
+	case AgeSenior:
+		if a.Age == 0 {
+			return "senior"
+		}
+		return "S" + strconv.Itoa(a.Age)
+	default:
+		return ""
+	}
+}
+
+// Discipline is singles or doubles.
+type Discipline int
+
+const (
+	DisciplineUnknown Discipline = iota
+	DisciplineSingles
+	DisciplineDoubles
+)
+
+// String renders the discipline as exposed in the API.
+func (d Discipline) String() string {
+	switch d {
+	case DisciplineSingles:
+		return "Einzel"
+	case DisciplineDoubles:
+		return "Doppel"
+	default:
+		return ""
+	}
+}
+
+// DrawType is the part of a tournament a competition belongs to. Most names
+// do not say, which means the main draw.
+type DrawType int
+
+const (
+	DrawUnknown DrawType = iota
+	DrawMain
+	DrawQualifying
+	DrawConsolation
+	DrawRoundRobin
+)
+
+// String renders the draw type as exposed in the API.
+func (d DrawType) String() string {
+	switch d {
+	case DrawMain:
+		return "main"
+	case DrawQualifying:
+		return "qualifying"
+	case DrawConsolation:
+		return "consolation"
+	case DrawRoundRobin:
+		return "round_robin"
+	default:
+		return ""
+	}
+}
+
+// Class is what could be read from a competition name. Each field is Unknown
+// when the name does not say.
+type Class struct {
+	Gender     Gender
+	AgeClass   AgeClass
+	Discipline Discipline
+	DrawType   DrawType
+}
+
+var (
+	wordSplit = regexp.MustCompile(`[\s/,+()\-–.:]+`)
+	// codePattern matches gender-and-age codes such as "M40", "W30" or "U14".
+	codePattern = regexp.MustCompile(`^([mwuj])(\d{1,2})$`)
+	number      = regexp.MustCompile(`^\d{1,2}$`)
+)
+
+var genderWords = map[string]Gender{
+	"herren":      GenderMen,
+	"männer":      GenderMen,
+	"männlich":    GenderMen,
+	"maennlich":   GenderMen,
+	"junioren":    GenderMen,
+	"knaben":      GenderMen,
+	"jungen":      GenderMen,
+	"damen":       GenderWomen,
+	"frauen":      GenderWomen,
+	"weiblich":    GenderWomen,
+	"juniorinnen": GenderWomen,
+	"mädchen":     GenderWomen,
+	"maedchen":    GenderWomen,
+	"mixed":       GenderMixed,
+}
+
+// juniorWords name a junior class; a following number is its J limit.
+var juniorWords = map[string]bool{
+	"junioren": true, "juniorinnen": true, "knaben": true, "jungen": true,
+	"mädchen": true, "maedchen": true, "jugend": true, "junior": true,
+	"bambini": true, "kids": true,
+}
+
+var drawWords = map[string]DrawType{
+	"hauptfeld":     DrawMain,
+	"hauptrunde":    DrawMain,
+	"quali":         DrawQualifying,
+	"qualifikation": DrawQualifying,
+	"nebenrunde":    DrawConsolation,
+	"nebenfeld":     DrawConsolation,
+	"trostrunde":    DrawConsolation,
+	"gruppe":        DrawRoundRobin,
+	"gruppen":       DrawRoundRobin,
+	"gruppenphase":  DrawRoundRobin,
+	"robin":         DrawRoundRobin,
+}
+
+// Parse classifies a competition name.
+func Parse(name string) Class {
+	var c Class
+	words := wordSplit.Split(strings.ToLower(strings.TrimSpace(name)), -1)
+
+	for i, word := range words {
+		next := ""
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+
+		if g, ok := genderWords[word]; ok {
+			c.Gender = g
+			if g == GenderMixed {
+				c.Discipline = DisciplineDoubles
+			}
+		}
+		if d, ok := drawWords[word]; ok {
+			c.DrawType = d
+		}
+
+		switch {
+		case strings.HasPrefix(word, "einzel"):
+			c.Discipline = DisciplineSingles
+		case strings.HasPrefix(word, "doppel"):
+			c.Discipline = DisciplineDoubles
+
+		case strings.HasPrefix(word, "senior"):
+			c.AgeClass = AgeClass{Group: AgeSenior}
+			if age, ok := ageOf(next); ok {
+				c.AgeClass = seniorClass(age)
+			}
+
+		case juniorWords[word] || strings.HasPrefix(word, "jugend"):
+			if c.AgeClass.Group != AgeJunior {
+				c.AgeClass = AgeClass{Group: AgeJunior}
+			}
+			if age, ok := ageOf(next); ok {
+				c.AgeClass.Age = age
+			}
+
+		case word == "u":
+			// "U 14", "U-14"
+			if age, ok := ageOf(next); ok {
+				c.AgeClass = AgeClass{Group: AgeJunior, Age: age, Under: true}
+			}
+
+		case codePattern.MatchString(word):
+			m := codePattern.FindStringSubmatch(word)
+			age, _ := strconv.Atoi(m[2])
+			switch m[1] {
+			case "u":
+				c.AgeClass = AgeClass{Group: AgeJunior, Age: age, Under: true}
+			case "j":
+				c.AgeClass = AgeClass{Group: AgeJunior, Age: age}
+			case "m":
+				c.Gender = GenderMen
+				c.AgeClass = classForAge(age)
+			case "w":
+				c.Gender = GenderWomen
+				c.AgeClass = classForAge(age)
+			}
+
+		case number.MatchString(word) && i > 0:
+			// "Herren 40", "Damen 14": the number after a gender word. Junior
+			// and senior words consume their own number above.
+			if _, ok := genderWords[words[i-1]]; ok && c.AgeClass.Group == AgeUnknown {
+				age, _ := strconv.Atoi(word)
+				c.AgeClass = classForAge(age)
+			}
+		}
+	}
+
+	if c.AgeClass.Group == AgeUnknown && (c.Gender == GenderMen || c.Gender == GenderWomen) {
+		// "Herren" or "Damen" without an age is the open class.
+		c.AgeClass = AgeClass{Group: AgeOpen}
+	}
+
+	return c
+}
+
+// ageOf parses the age following a class word.
+func ageOf(word string) (int, bool) {
+	if !number.MatchString(word) {
+		return 0, false
+	}
+	age, err := strconv.Atoi(word)
+	return age, err == nil && age > 0
+}
+
+// classForAge reads the number after a gender: 30 and above is a senior
+// class, below it a junior one; "00" is BTV's open class.
+func classForAge(age int) AgeClass {
+	switch {
+	case age == 0:
+		return AgeClass{Group: AgeOpen}
+	case age >= seniorFrom:
+		return seniorClass(age)
+	default:
+		return AgeClass{Group: AgeJunior, Age: age}
+	}
+}
+
+// seniorClass validates a senior class limit. An age outside the 30–85 scale
+// still marks a senior class, just without a usable limit.
+func seniorClass(age int) AgeClass {
+	if age < seniorFrom || age > seniorTo || age%5 != 0 {
+		return AgeClass{Group: AgeSenior}
+	}
+	return AgeClass{Group: AgeSenior, Age: age}
+}
+
+// Filter is a parsed competition filter. The zero Filter matches everything.
+type Filter struct {
+	Gender     Gender
+	Age        AgeGroup
+	Discipline Discipline
+}
+
+// ParseFilter reads the frontend's compType parameter, e.g. "Herren+Einzel"
+// or "Senioren Doppel". ok is false for an empty or unrecognised value, which
+// callers treat as no filter.
+func ParseFilter(compType string) (Filter, bool) {
+	words := wordSplit.Split(strings.ToLower(strings.TrimSpace(compType)), -1)
+	if len(words) != 2 {
+		return Filter{}, false
+	}
+
+	var f Filter
+	switch words[0] {
+	case "herren":
+		f.Gender, f.Age = GenderMen, AgeOpen
+	case "damen":
+		f.Gender, f.Age = GenderWomen, AgeOpen
+	case "senioren":
+		f.Age = AgeSenior
+	case "jugend":
+		f.Age = AgeJunior
+	default:
+		return Filter{}, false
+	}
+
+	switch words[1] {
+	case "einzel":
+		f.Discipline = DisciplineSingles
+	case "doppel":
+		f.Discipline = DisciplineDoubles
+	default:
+		return Filter{}, false
+	}
+
+	return f, true
+}
+
+// Matches reports whether a competition may satisfy the filter. A property
+// the name does not state is not held against it: hiding a competition
+// because its name is terse would lose real tournaments.
+func (f Filter) Matches(c Class) bool {
+	if f.Gender != GenderUnknown && c.Gender != GenderUnknown && c.Gender != f.Gender {
+		return false
+	}
+	if f.Age != AgeUnknown && c.AgeClass.Group != AgeUnknown && c.AgeClass.Group != f.Age {
+		return false
+	}
+	if f.Discipline != DisciplineUnknown && c.Discipline != DisciplineUnknown && c.Discipline != f.Discipline {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package competition
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParse pins competition names seen in live data, plus BTV's expansions.
+func TestParse(t *testing.T) {
+	open := AgeClass{Group: AgeOpen}
+	tests := []struct {
+		name string
+		want Class
+	}{
+		{"Herren Einzel", Class{Gender: GenderMen, AgeClass: open, Discipline: DisciplineSingles}},
+		{"Damen Doppel", Class{Gender: GenderWomen, AgeClass: open, Discipline: DisciplineDoubles}},
+		{"Damen 40", Class{Gender: GenderWomen, AgeClass: AgeClass{Group: AgeSenior, Age: 40}}},
+		{"Herren 50 Doppel", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeSenior, Age: 50}, Discipline: DisciplineDoubles}},
+		{"Herren 85 Einzel", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeSenior, Age: 85}, Discipline: DisciplineSingles}},
+		{"Senioren 65 Einzel", Class{AgeClass: AgeClass{Group: AgeSenior, Age: 65}, Discipline: DisciplineSingles}},
+		{"Senioren Einzel", Class{AgeClass: AgeClass{Group: AgeSenior}, Discipline: DisciplineSingles}},
+		{"Junioren U14", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeJunior, Age: 14, Under: true}}},
+		{"Juniorinnen U16 Einzel", Class{Gender: GenderWomen, AgeClass: AgeClass{Group: AgeJunior, Age: 16, Under: true}, Discipline: DisciplineSingles}},
+		{"U14 männlich", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeJunior, Age: 14, Under: true}}},
+		{"U 12 weiblich", Class{Gender: GenderWomen, AgeClass: AgeClass{Group: AgeJunior, Age: 12, Under: true}}},
+		{"U10", Class{AgeClass: AgeClass{Group: AgeJunior, Age: 10, Under: true}}},
+		{"Knaben 16 Doppel", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeJunior, Age: 16}, Discipline: DisciplineDoubles}},
+		{"Mädchen 14", Class{Gender: GenderWomen, AgeClass: AgeClass{Group: AgeJunior, Age: 14}}},
+		{"Jugend Einzel", Class{AgeClass: AgeClass{Group: AgeJunior}, Discipline: DisciplineSingles}},
+		// BTV, expanded from W30/E, M14/D and X00/D.
+		{"Damen 30 Einzel", Class{Gender: GenderWomen, AgeClass: AgeClass{Group: AgeSenior, Age: 30}, Discipline: DisciplineSingles}},
+		{"Herren 14 Doppel", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeJunior, Age: 14}, Discipline: DisciplineDoubles}},
+		{"Mixed Doppel", Class{Gender: GenderMixed, Discipline: DisciplineDoubles}},
+		{"Mixed 40", Class{Gender: GenderMixed, AgeClass: AgeClass{Group: AgeSenior, Age: 40}, Discipline: DisciplineDoubles}},
+		// Codes as some organisers write them.
+		{"M40 Einzel", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeSenior, Age: 40}, Discipline: DisciplineSingles}},
+		{"W00", Class{Gender: GenderWomen, AgeClass: open}},
+		// Draw types.
+		{"Herren Einzel Quali", Class{Gender: GenderMen, AgeClass: open, Discipline: DisciplineSingles, DrawType: DrawQualifying}},
+		{"Damen Einzel - Nebenrunde", Class{Gender: GenderWomen, AgeClass: open, Discipline: DisciplineSingles, DrawType: DrawConsolation}},
+		{"Herren 40 Hauptfeld", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeSenior, Age: 40}, DrawType: DrawMain}},
+		{"U12 Round Robin", Class{AgeClass: AgeClass{Group: AgeJunior, Age: 12, Under: true}, DrawType: DrawRoundRobin}},
+		// A senior number off the 30–85 scale is still a senior class.
+		{"Herren 42", Class{Gender: GenderMen, AgeClass: AgeClass{Group: AgeSenior}}},
+		{"Offene Konkurrenz", Class{}},
+		{"", Class{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.name); got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name                                  string
+		gender, ageClass, discipline, drawTyp string
+	}{
+		{"Herren Einzel", "Herren", "open", "Einzel", ""},
+		{"Damen 45 Doppel", "Damen", "S45", "Doppel", ""},
+		{"Junioren U14", "Herren", "U14", "", ""},
+		{"Knaben 16", "Herren", "J16", "", ""},
+		{"Jugend Einzel Quali", "open", "junior", "Einzel", "qualifying"},
+		{"Mixed", "Mixed", "", "Doppel", ""},
+	}
+
+	for _, tt := range tests {
+		c := Parse(tt.name)
+		got := [4]string{c.Gender.String(), c.AgeClass.String(), c.Discipline.String(), c.DrawType.String()}
+		want := [4]string{tt.gender, tt.ageClass, tt.discipline, tt.drawTyp}
+		if got != want {
+			t.Errorf("Parse(%q) renders %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   Filter
+		wantOK bool
+	}{
+		{"Herren+Einzel", Filter{GenderMen, AgeOpen, DisciplineSingles}, true},
+		{"Damen+Doppel", Filter{GenderWomen, AgeOpen, DisciplineDoubles}, true},
+		{"Senioren Einzel", Filter{Age: AgeSenior, Discipline: DisciplineSingles}, true},
+		{"Jugend+Doppel", Filter{Age: AgeJunior, Discipline: DisciplineDoubles}, true},
+		{"", Filter{}, false},
+		{"Herren", Filter{}, false},
+		{"Bambini+Einzel", Filter{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseFilter(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseFilter(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		filter      string
+		competition string
+		want        bool
+	}{
+		{"Herren+Einzel", "Herren Einzel", true},
+		{"Herren+Einzel", "Damen Einzel", false},
+		{"Herren+Einzel", "Herren Doppel", false},
+		{"Herren+Einzel", "Herren 40 Einzel", false},
+		{"Herren+Einzel", "Junioren U14", false},
+		{"Herren+Einzel", "Mixed", false},
+		// A name that does not state the discipline may still be singles.
+		{"Herren+Einzel", "Herren", true},
+		{"Senioren+Einzel", "Damen 40", true},
+		{"Senioren+Einzel", "Herren 55 Einzel", true},
+		{"Senioren+Doppel", "Herren 55 Einzel", false},
+		{"Jugend+Einzel", "Juniorinnen U12 Einzel", true},
+		{"Jugend+Einzel", "U14 männlich", true},
+		{"Jugend+Einzel", "Herren Einzel", false},
+		// Nothing known, nothing held against it.
+		{"Damen+Doppel", "Offene Konkurrenz", true},
+	}
+
+	for _, tt := range tests {
+		f, ok := ParseFilter(tt.filter)
+		if !ok {
+			t.Fatalf("ParseFilter(%q) failed", tt.filter)
+		}
+		if got := f.Matches(Parse(tt.competition)); got != tt.want {
+			t.Errorf("%s matches %q = %v, want %v", tt.filter, tt.competition, got, tt.want)
+		}
+	}
+}
+
+func TestAdmits(t *testing.T) {
+	woman45 := Player{BirthYear: 1981, Gender: GenderWomen}
+	boy12 := Player{BirthYear: 2014, Gender: GenderMen}
+
+	tests := []struct {
+		competition string
+		player      Player
+		want        bool
+		unknown     int
+	}{
+		// The case from the issue: a 45-year-old woman.
+		{"Damen Einzel", woman45, true, 0},
+		{"Damen 40 Einzel", woman45, true, 0},
+		{"Damen 45 Einzel", woman45, true, 0},
+		{"Damen 50 Einzel", woman45, false, 0},
+		{"Herren Einzel", woman45, false, 0},
+		{"Juniorinnen U16", woman45, false, 0},
+		{"Mixed", woman45, true, 1},
+
+		// Junior limits: U14 and J14 both end with the year of the 14th
+		// birthday.
+		{"Junioren U14", boy12, true, 0},
+		{"Junioren U14", Player{BirthYear: 2012, Gender: GenderMen}, true, 0},
+		{"Junioren U14", Player{BirthYear: 2011, Gender: GenderMen}, false, 0},
+		{"Knaben 14", Player{BirthYear: 2012, Gender: GenderMen}, true, 0},
+		{"Knaben 14", Player{BirthYear: 2011, Gender: GenderMen}, false, 0},
+		{"Jugend Einzel", Player{BirthYear: 2008}, true, 1},
+		{"Jugend Einzel", Player{BirthYear: 2007}, false, 1},
+		{"Herren Einzel", boy12, true, 0},
+
+		// Unknown means open, and is reported.
+		{"Offene Konkurrenz", woman45, true, 2},
+		{"Herren 40", Player{}, true, 2},
+		{"Senioren Einzel", Player{BirthYear: 1996}, true, 1},
+		{"Senioren Einzel", Player{BirthYear: 1997}, false, 1},
+	}
+
+	for _, tt := range tests {
+		got := Parse(tt.competition).Admits(tt.player, 2026)
+		if got.Eligible != tt.want || len(got.Unknown) != tt.unknown {
+			t.Errorf("%q admits %+v = %+v, want eligible %v with %d unknown",
+				tt.competition, tt.player, got, tt.want, tt.unknown)
+		}
+		if len(got.Reasons) != 2 {
+			t.Errorf("%q: reasons %v, want one per constraint", tt.competition, got.Reasons)
+		}
+	}
+}
+
+func TestAdmitsExplainsTheAge(t *testing.T) {
+	got := Parse("Damen 40 Einzel").Admits(Player{BirthYear: 1981, Gender: GenderWomen}, 2026)
+	if want := "Damen, S40: wird 2026 45"; strings.Join(got.Reasons, ", ") != want {
+		t.Errorf("reasons = %q, want %q", strings.Join(got.Reasons, ", "), want)
+	}
+}
+
+func TestParseGender(t *testing.T) {
+	for in, want := range map[string]Gender{
+		"w": GenderWomen, "Damen": GenderWomen, "female": GenderWomen,
+		"m": GenderMen, "Herren": GenderMen, " männlich ": GenderMen,
+	} {
+		if got, ok := ParseGender(in); !ok || got != want {
+			t.Errorf("ParseGender(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseGender("x"); ok {
+		t.Error("ParseGender accepted an unknown value")
+	}
+}
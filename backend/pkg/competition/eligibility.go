@@ -0,0 +1,112 @@
+package competition
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Player is who may want to enter a competition. Zero fields are unknown.
+type Player struct {
+	BirthYear int
+	Gender    Gender
+}
+
+// Check is the outcome of matching a player against one competition.
+type Check struct {
+	Eligible bool
+	// Reasons are short German phrases for the player, one per constraint.
+	Reasons []string
+	// Unknown names the constraints that could not be checked, because
+	// either the competition or the player does not state them: "gender" or
+	// "age".
+	Unknown []string
+}
+
+// Defaults for junior and senior classes that do not state their limit.
+const (
+	juniorUpTo    = 18
+	seniorDefault = seniorFrom
+)
+
+// ParseGender reads a player's gender as given in a query: "w", "f",
+// "female", "damen", "weiblich", or "m", "male", "herren", "männlich".
+func ParseGender(s string) (Gender, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "w", "f", "female", "damen", "frau", "weiblich":
+		return GenderWomen, true
+	case "m", "male", "herren", "mann", "männlich", "maennlich":
+		return GenderMen, true
+	default:
+		return GenderUnknown, false
+	}
+}
+
+// Admits checks a player against the competition under German age-class
+// rules, where a class is defined by the age a player turns in the season's
+// calendar year (see AgeClass).
+//
+// A constraint that is unknown on either side does not exclude the player,
+// matching the LK filter: hiding a competition on missing information would
+// lose real tournaments. It is reported in Unknown instead.
+func (c Class) Admits(p Player, season int) Check {
+	check := Check{Eligible: true}
+
+	switch {
+	case c.Gender == GenderUnknown:
+		check.unknown("gender", "Geschlecht nicht ausgeschrieben")
+	case c.Gender == GenderMixed:
+		check.Reasons = append(check.Reasons, "Mixed")
+	case p.Gender == GenderUnknown:
+		check.unknown("gender", "Geschlecht nicht angegeben")
+	case p.Gender != c.Gender:
+		check.Eligible = false
+		check.Reasons = append(check.Reasons, c.Gender.String()+"-Konkurrenz")
+	default:
+		check.Reasons = append(check.Reasons, c.Gender.String())
+	}
+
+	switch {
+	case c.AgeClass.Group == AgeUnknown:
+		check.unknown("age", "Altersklasse nicht ausgeschrieben")
+	case c.AgeClass.Group == AgeOpen:
+		check.Reasons = append(check.Reasons, "offene Altersklasse")
+	case p.BirthYear == 0:
+		check.unknown("age", "Jahrgang nicht angegeben")
+	default:
+		age := season - p.BirthYear
+		ok := c.AgeClass.admitsAge(age)
+		if !ok {
+			check.Eligible = false
+		}
+		check.Reasons = append(check.Reasons,
+			c.AgeClass.String()+": wird "+strconv.Itoa(season)+" "+strconv.Itoa(age))
+	}
+
+	return check
+}
+
+func (check *Check) unknown(constraint, reason string) {
+	check.Unknown = append(check.Unknown, constraint)
+	check.Reasons = append(check.Reasons, reason)
+}
+
+// admitsAge reports whether a player turning age in the season may enter.
+func (a AgeClass) admitsAge(age int) bool {
+	switch a.Group {
+	case AgeJunior:
+		// U14 and J14 alike are open up to the year of the 14th birthday.
+		limit := a.Age
+		if limit == 0 {
+			limit = juniorUpTo
+		}
+		return age <= limit
+	case AgeSenior:
+		from := a.Age
+		if from == 0 {
+			from = seniorDefault
+		}
+		return age >= from
+	default:
+		return true
+	}
+}
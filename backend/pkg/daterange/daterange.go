@@ -0,0 +1,181 @@
+// Package daterange turns the free-text tournament dates federations publish
+// into real date ranges.
+//
+// Every source renders dates differently, and several omit the year on the
+// start date:
+//
+//	"01.08.2026 - 03.08.2026"     nuLiga old table, new API daterange cell
+//	"12.09.2026"                  single-day tournament
+//	"10.08. - 16.08.2026"         BTV widget
+//	"So, 16.8. – Fr, 21.8.2026"   weekday prefixes, en dash
+//
+// Rather than one layout per source, the parser takes the first and last
+// day.month[.year] group in the text and fills in missing years from the
+// other end of the range.
+package daterange
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Layout is the date format used in query parameters and by the upstreams.
+const Layout = "02.01.2006"
+
+// ISOLayout is the format of the parsed days exposed in the API.
+const ISOLayout = "2006-01-02"
+
+// datePattern matches "16.8.", "16.08.2026" and "16. 08. 2026". The year is
+// optional because range starts often omit it.
+var datePattern = regexp.MustCompile(`(\d{1,2})\.\s*(\d{1,2})\.(?:\s*(\d{4}))?`)
+
+// Range is an inclusive span of calendar days. Both ends are midnight UTC, so
+// ranges compare by day without time zone surprises.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps reports whether the range shares at least one day with [from, to].
+func (r Range) Overlaps(from, to time.Time) bool {
+	return !r.Start.After(to) && !r.End.Before(from)
+}
+
+// day is one parsed date group; year is 0 when the text omitted it.
+type day struct {
+	day, month, year int
+}
+
+// Parse reads a tournament date text.
+//
+// ref supplies the year when the text has none at all: the date is placed in
+// ref's year, or the next one when that would put it more than a month in the
+// past. That matches how the upstream calendars are browsed, forward from
+// today.
+func Parse(text string, ref time.Time) (Range, bool) {
+	matches := datePattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return Range{}, false
+	}
+
+	first, ok := toDay(matches[0])
+	if !ok {
+		return Range{}, false
+	}
+	last := first
+	if len(matches) > 1 {
+		if last, ok = toDay(matches[len(matches)-1]); !ok {
+			return Range{}, false
+		}
+	}
+
+	switch {
+	case first.year == 0 && last.year != 0:
+		// "10.08. - 16.08.2026": the start borrows the end's year, minus
+		// one when the range crosses new year ("28.12. - 03.01.2027").
+		first.year = last.year
+		if first.month > last.month {
+			first.year--
+		}
+	case first.year != 0 && last.year == 0:
+		last.year = first.year
+		if last.month < first.month {
+			last.year++
+		}
+	case first.year == 0 && last.year == 0:
+		first.year = inferYear(first, ref)
+		last.year = first.year
+		if last.month < first.month {
+			last.year++
+		}
+	}
+
+	start, ok := toTime(first)
+	if !ok {
+		return Range{}, false
+	}
+	end, ok := toTime(last)
+	if !ok || end.Before(start) {
+		return Range{}, false
+	}
+
+	return Range{Start: start, End: end}, true
+}
+
+// ParseDeadline reads a registration deadline, e.g. "20.08.2026" or
+// "20.08. 12:00 Uhr"; a time of day is ignored.
+//
+// A deadline without a year falls in the year of event, the tournament's
+// start, or the year before when it would otherwise come after it. Without an
+// event date such a deadline cannot be placed and ok is false.
+func ParseDeadline(text string, event time.Time) (time.Time, bool) {
+	m := datePattern.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, false
+	}
+	d, ok := toDay(m)
+	if !ok {
+		return time.Time{}, false
+	}
+	if d.year == 0 {
+		if event.IsZero() {
+			return time.Time{}, false
+		}
+		d.year = event.Year()
+		if t, ok := toTime(d); ok && t.After(event) {
+			d.year--
+		}
+	}
+	return toTime(d)
+}
+
+// ParseDay reads a single date in Layout, as used by the query parameters.
+func ParseDay(s string) (time.Time, error) {
+	return time.ParseInLocation(Layout, s, time.UTC)
+}
+
+// ParseISO reads a day in ISOLayout, as stored in StartDate/EndDate.
+func ParseISO(s string) (time.Time, error) {
+	return time.ParseInLocation(ISOLayout, s, time.UTC)
+}
+
+func toDay(m []string) (day, bool) {
+	d, err := strconv.Atoi(m[1])
+	if err != nil {
+		return day{}, false
+	}
+	mo, err := strconv.Atoi(m[2])
+	if err != nil {
+		return day{}, false
+	}
+	var y int
+	if m[3] != "" {
+		if y, err = strconv.Atoi(m[3]); err != nil {
+			return day{}, false
+		}
+	}
+	return day{day: d, month: mo, year: y}, true
+}
+
+// toTime builds the date, rejecting impossible ones such as 31.02. that
+// time.Date would silently normalise into March.
+func toTime(d day) (time.Time, bool) {
+	if d.month < 1 || d.month > 12 || d.day < 1 || d.day > 31 {
+		return time.Time{}, false
+	}
+	t := time.Date(d.year, time.Month(d.month), d.day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != d.day || int(t.Month()) != d.month {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func inferYear(d day, ref time.Time) int {
+	year := ref.Year()
+	candidate, ok := toTime(day{day: d.day, month: d.month, year: year})
+	if ok && candidate.Before(ref.AddDate(0, -1, 0)) {
+		return year + 1
+	}
+	return year
+}
@@ -0,0 +1,127 @@
+package daterange
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+var ref = date(2026, 8, 15)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end time.Time
+	}{
+		// nuLiga old table and new API daterange cell.
+		{"01.08.2026 - 03.08.2026", date(2026, 8, 1), date(2026, 8, 3)},
+		{"12.09.2026", date(2026, 9, 12), date(2026, 9, 12)},
+		// BTV: the start omits the year.
+		{"10.08. - 16.08.2026", date(2026, 8, 10), date(2026, 8, 16)},
+		{"28.12. - 03.01.2027", date(2026, 12, 28), date(2027, 1, 3)},
+		// Weekday prefixes, single-digit months and an en dash.
+		{"So, 16.8. – Fr, 21.8.2026", date(2026, 8, 16), date(2026, 8, 21)},
+		{"Sa, 15.8.2026 abgesagt", date(2026, 8, 15), date(2026, 8, 15)},
+		// No year anywhere: taken from the reference date.
+		{"22.08. bis 23.08.", date(2026, 8, 22), date(2026, 8, 23)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := Parse(tt.in, ref)
+			if !ok {
+				t.Fatalf("Parse(%q) failed", tt.in)
+			}
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+				t.Errorf("Parse(%q) = %s..%s, want %s..%s", tt.in,
+					got.Start.Format(Layout), got.End.Format(Layout),
+					tt.start.Format(Layout), tt.end.Format(Layout))
+			}
+		})
+	}
+}
+
+// TestParseRollsYearlessDatesForward mirrors the frontend: a date without a
+// year that already lies more than a month back belongs to next year.
+func TestParseRollsYearlessDatesForward(t *testing.T) {
+	got, ok := Parse("10.01. - 12.01.", date(2026, 11, 20))
+	if !ok {
+		t.Fatal("Parse() failed")
+	}
+	if got.Start.Year() != 2027 {
+		t.Errorf("Start = %s, want next year", got.Start.Format(Layout))
+	}
+}
+
+func TestParseRejectsUnusableText(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"demnächst",
+		"31.02.2026",              // impossible day
+		"01.13.2026",              // impossible month
+		"10.08.2026 - 01.08.2026", // end before start
+	} {
+		if got, ok := Parse(in, ref); ok {
+			t.Errorf("Parse(%q) = %+v, want failure", in, got)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	r := Range{Start: date(2026, 8, 10), End: date(2026, 8, 16)}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     bool
+	}{
+		{"window contains range", date(2026, 8, 1), date(2026, 8, 31), true},
+		{"window starts mid-range", date(2026, 8, 14), date(2026, 8, 31), true},
+		{"window ends on first day", date(2026, 8, 1), date(2026, 8, 10), true},
+		{"window starts on last day", date(2026, 8, 16), date(2026, 8, 20), true},
+		{"window before", date(2026, 8, 1), date(2026, 8, 9), false},
+		{"window after", date(2026, 8, 17), date(2026, 8, 31), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Overlaps(tt.from, tt.to); got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDeadline(t *testing.T) {
+	event := date(2026, 8, 22)
+	tests := []struct {
+		in    string
+		event time.Time
+		want  time.Time
+	}{
+		{"20.08.2026", event, date(2026, 8, 20)},
+		{"20.08.2026 23:59 Uhr", time.Time{}, date(2026, 8, 20)},
+		{"So, 16.8. 12:00", event, date(2026, 8, 16)},
+		// A deadline after the event's day belongs to the year before.
+		{"28.12.", date(2027, 1, 3), date(2026, 12, 28)},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseDeadline(tt.in, tt.event)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("ParseDeadline(%q) = %s, %v; want %s", tt.in, got.Format(Layout), ok, tt.want.Format(Layout))
+		}
+	}
+
+	for _, in := range []string{"", "siehe Ausschreibung", "31.02.2026"} {
+		if got, ok := ParseDeadline(in, event); ok {
+			t.Errorf("ParseDeadline(%q) = %s, want failure", in, got.Format(Layout))
+		}
+	}
+	if _, ok := ParseDeadline("20.08.", time.Time{}); ok {
+		t.Error("placed a deadline without a year or event date")
+	}
+}
@@ -1,172 +1,248 @@
+// Package federation loads the configured tennis federations.
+//
+// The list lives in a JSON file rather than in code because parts of it expire
+// on their own schedule: the new-API federations (RLP, WTB) need a TYPO3
+// `trusted_properties` HMAC that changes whenever their site is redeployed.
+// Keeping it in a file means a stale token is fixed by editing the file and
+// reloading, not by rebuilding and redeploying the service.
 package federation
 
-import "github.com/timoknapp/tennis-tournament-finder/pkg/models"
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/source"
+)
+
+// defaultFederations is compiled into the binary so the service works without
+// any external file. TTF_FEDERATIONS can point at a different file.
+//
+//go:embed data/federations.json
+var defaultFederations []byte
+
+// entry is one federation as written in the file. Note documents quirks
+// (multi-state coverage, renamed hosts) and is not used at runtime.
+type entry struct {
+	models.Federation
+	Note string `json:"note,omitempty"`
+}
+
+type file struct {
+	Federations []entry `json:"federations"`
+}
+
+var (
+	mu        sync.RWMutex
+	once      sync.Once
+	current   []models.Federation
+	currentOK bool
+)
+
+// GetFederations returns the configured federations, loading them on first
+// use.
+//
+// It never returns an error: a broken TTF_FEDERATIONS file is logged and the
+// embedded list is served instead, because an empty list would silently take
+// every tournament off the map.
 func GetFederations() []models.Federation {
-	federations := []models.Federation{
-		{
-			Id:                "BAD",
-			Url:               "https://baden.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Badischer Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "49.34003", Lon: "8.68514"},
-			State:             "Baden-Württemberg",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "HTV",
-			Url:               "https://htv.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Hessischer Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "50.0770372", Lon: "8.7553832"},
-			State:             "Hessen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "RLP",
-			Url:               "https://www.rlp-tennis.de/spielbetrieb/turniere/appTournament.html",
-			Name:              "Rheinland-Pfälzischer Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "49.8335079", Lon: "8.0138431"},
-			State:             "Rheinland-Pfalz",
-			ApiVersion:        "new",
-			TrustedProperties: "{\"tournamentsFilter\":{\"ageCategory\":1,\"ageGroupJuniors\":1,\"ageGroupSeniors\":1,\"circuit\":1,\"region\":1,\"organizerRegion\":1,\"fedRankValuation\":1,\"nationalValuation\":1,\"fedRank\":1,\"name\":1,\"city\":1,\"startDate\":1,\"endDate\":1,\"firstResult\":1,\"maxResults\":1}}147ad25c14aa9b88f132c65e3c4de2e6992acf37",
-		},
-		{
-			Id:                "STV",
-			Url:               "https://stv.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Sächsischer Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "51.3633218", Lon: "12.4132917"},
-			State:             "Sachsen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "TMV",
-			Url:               "https://tmv.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Tennisverband Mecklenburg-Vorpommern",
-			Geocoordinates:    models.Geocoordinates{Lat: "54.0829601", Lon: "12.0889703"},
-			State:             "Mecklenburg-Vorpommern",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "TSA",
-			Url:               "https://tsa.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Tennisverband Sachsen-Anhalt",
-			Geocoordinates:    models.Geocoordinates{Lat: "52.1063933", Lon: "11.6015097"},
-			State:             "Sachsen-Anhalt",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "TTV",
-			Url:               "https://ttv.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Thüringer Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "51.0012441", Lon: "11.3327579"},
-			State:             "Thüringen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "TVN",
-			Url:               "https://tvn.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Tennisverband Niederrhein",
-			Geocoordinates:    models.Geocoordinates{Lat: "51.4784721", Lon: "6.9804422"},
-			State:             "Nordrhein-Westfalen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "WTB",
-			Url:               "https://www.wtb-tennis.de/wettkampfsport/turniere/turnierkalender.html",
-			Name:              "Württembergischer Tennisbund",
-			Geocoordinates:    models.Geocoordinates{Lat: "48.853488", Lon: "9.1373019"},
-			State:             "Baden-Württemberg",
-			ApiVersion:        "new",
-			TrustedProperties: "{\"tournamentsFilter\":{\"ageCategory\":1,\"ageGroupJuniors\":1,\"ageGroupSeniors\":1,\"circuit\":1,\"fedRankValuation\":1,\"nationalValuation\":1,\"type\":1,\"fedRank\":1,\"region\":1,\"name\":1,\"city\":1,\"startDate\":1,\"endDate\":1,\"firstResult\":1,\"maxResults\":1}}159ecd19ddd43b30fbc8e35aea82f7bf7373a592",
-		},
-		{
-			Id:             "TVBB",
-			Url:            "https://tvbb.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:           "Tennis-Verband Berlin-Brandenburg",
-			Geocoordinates: models.Geocoordinates{Lat: "52.5170365", Lon: "13.3888599"},
-			// The federation covers both Berlin and Brandenburg; accepting
-			// only one would push every tournament in the other state onto
-			// the default marker.
-			State:             "Berlin",
-			States:            []string{"Berlin", "Brandenburg"},
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "HAM",
-			Url:               "https://hamburg.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Hamburger Tennisverband",
-			Geocoordinates:    models.Geocoordinates{Lat: "53.550341", Lon: "10.000654"},
-			State:             "Hamburg",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "TVM",
-			Url:               "https://tvm.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Tennisverband Mittelrhein",
-			Geocoordinates:    models.Geocoordinates{Lat: "50.9412538", Lon: "6.9582814"},
-			State:             "Nordrhein-Westfalen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:             "TNB",
-			Url:            "https://tnb.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:           "Tennisverband Niedersachsen-Bremen",
-			Geocoordinates: models.Geocoordinates{Lat: "52.3758916", Lon: "9.7320104"},
-			// Covers Niedersachsen and the city state of Bremen.
-			State:             "Niedersachsen",
-			States:            []string{"Niedersachsen", "Bremen"},
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "STB",
-			Url:               "https://stb.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Saarländischer Tennisbund",
-			Geocoordinates:    models.Geocoordinates{Lat: "49.2401572", Lon: "6.9969327"},
-			State:             "Saarland",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			Id:                "WTV",
-			Url:               "https://wtv.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Westfälischer Tennis-Verband",
-			Geocoordinates:    models.Geocoordinates{Lat: "51.5142273", Lon: "7.4652789"},
-			State:             "Nordrhein-Westfalen",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			// The federation is commonly abbreviated TSH, but nuLiga serves it
-			// under the host and federation code SLH.
-			Id:                "SLH",
-			Url:               "https://slh.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
-			Name:              "Tennisverband Schleswig-Holstein",
-			Geocoordinates:    models.Geocoordinates{Lat: "54.3232927", Lon: "10.1227652"},
-			State:             "Schleswig-Holstein",
-			ApiVersion:        "old",
-			TrustedProperties: "",
-		},
-		{
-			// Bavaria left nuLiga: btv.de embeds its own ZK widget, so this
-			// federation uses a dedicated client rather than a shared parser.
-			Id:                "BTV",
-			Url:               "https://btv-prod.burdadigitalsystems.de/btvtrnsearch/",
-			Name:              "Bayerischer Tennis-Verband",
-			Geocoordinates:    models.Geocoordinates{Lat: "48.1371079", Lon: "11.5753822"},
-			State:             "Bayern",
-			ApiVersion:        "btv",
-			TrustedProperties: "",
-		},
-	}
-	return federations
+	once.Do(func() {
+		if err := Reload(); err != nil {
+			logger.Error("Failed to load federations, using the embedded list: %v", err)
+			installEmbedded()
+		}
+	})
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	// Hand out a copy so a caller filtering or sorting the slice cannot
+	// corrupt the shared list.
+	out := make([]models.Federation, len(current))
+	copy(out, current)
+	return out
+}
+
+// Reload reads the federation file again and swaps in the new list.
+//
+// On failure the previously loaded list stays active, so a typo in an edited
+// file degrades to "nothing changed" rather than to an empty map.
+func Reload() error {
+	raw := defaultFederations
+	origin := "embedded federations.json"
+
+	if path := os.Getenv("TTF_FEDERATIONS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		raw, origin = data, path
+	}
+
+	federations, err := Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", origin, err)
+	}
+
+	mu.Lock()
+	current, currentOK = federations, true
+	mu.Unlock()
+
+	logger.Info("Loaded %d federations from %s", len(federations), origin)
+	return nil
+}
+
+// installEmbedded falls back to the compiled-in list unless a good list is
+// already active.
+func installEmbedded() {
+	federations, err := Parse(defaultFederations)
+	if err != nil {
+		// Only reachable with a broken build; the tests parse this file.
+		logger.Error("Embedded federations.json is invalid: %v", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !currentOK {
+		current, currentOK = federations, true
+	}
+}
+
+// ResetForTest clears the loaded list so tests can load a different file.
+// It is not safe for concurrent use and is intended for tests only.
+func ResetForTest() {
+	mu.Lock()
+	defer mu.Unlock()
+	once = sync.Once{}
+	current, currentOK = nil, false
+}
+
+// Parse decodes and validates a federation file.
+//
+// Validation rejects the whole file on the first problem. Serving a partly
+// valid list would hide the broken federation until someone noticed its
+// tournaments were missing.
+func Parse(raw []byte) ([]models.Federation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// A misspelled key ("api_verison") would otherwise be silently ignored
+	// and surface as a federation that fetches nothing.
+	decoder.DisallowUnknownFields()
+
+	var f file
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse federations: %w", err)
+	}
+	if len(f.Federations) == 0 {
+		return nil, fmt.Errorf("no federations configured")
+	}
+
+	federations := make([]models.Federation, 0, len(f.Federations))
+	seen := make(map[string]bool, len(f.Federations))
+
+	for i, e := range f.Federations {
+		fed := e.Federation
+		if err := validate(fed); err != nil {
+			return nil, fmt.Errorf("federation %d (%q): %w", i, fed.Id, err)
+		}
+		if seen[fed.Id] {
+			return nil, fmt.Errorf("federation %d: duplicate id %q", i, fed.Id)
+		}
+		seen[fed.Id] = true
+
+		federations = append(federations, fed)
+	}
+
+	return federations, nil
+}
+
+// validate checks a single federation.
+func validate(fed models.Federation) error {
+	if fed.Id == "" {
+		return fmt.Errorf("empty id")
+	}
+	if fed.Name == "" {
+		return fmt.Errorf("empty name")
+	}
+
+	u, err := url.Parse(fed.Url)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("url %q is not an absolute URL", fed.Url)
+	}
+
+	// The ApiVersion selects the source; one nobody registered would fail
+	// every request for this federation.
+	if _, ok := source.Lookup(fed.ApiVersion); !ok {
+		return fmt.Errorf("unknown api_version %q (known: %v)", fed.ApiVersion, source.Versions())
+	}
+
+	// The default coordinates are the fallback pin for every tournament that
+	// cannot be geocoded.
+	if err := validateCoordinate(fed.Geocoordinates.Lat, -90, 90); err != nil {
+		return fmt.Errorf("lat: %w", err)
+	}
+	if err := validateCoordinate(fed.Geocoordinates.Lon, -180, 180); err != nil {
+		return fmt.Errorf("lon: %w", err)
+	}
+
+	if fed.Bounds != nil {
+		if err := validateBounds(*fed.Bounds, fed.Geocoordinates); err != nil {
+			return fmt.Errorf("bounds: %w", err)
+		}
+	}
+
+	if fed.State == "" {
+		return fmt.Errorf("empty state")
+	}
+	if len(fed.States) > 0 && !containsString(fed.States, fed.State) {
+		return fmt.Errorf("states %v must include the primary state %q", fed.States, fed.State)
+	}
+
+	return nil
+}
+
+func validateCoordinate(raw string, min, max float64) error {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", raw)
+	}
+	if v < min || v > max {
+		return fmt.Errorf("%q is out of range", raw)
+	}
+	return nil
+}
+
+// validateBounds rejects swapped or out-of-range corners. The default pin must
+// lie inside: a box that misses it is almost certainly a typo, and would make a
+// radius search skip the federation for users standing next to its office.
+func validateBounds(b models.Bounds, pin models.Geocoordinates) error {
+	if b.South < -90 || b.North > 90 || b.West < -180 || b.East > 180 {
+		return fmt.Errorf("%+v is out of range", b)
+	}
+	if b.South >= b.North || b.West >= b.East {
+		return fmt.Errorf("%+v has swapped corners", b)
+	}
+
+	lat, _ := strconv.ParseFloat(pin.Lat, 64)
+	lon, _ := strconv.ParseFloat(pin.Lon, 64)
+	if lat < b.South || lat > b.North || lon < b.West || lon > b.East {
+		return fmt.Errorf("%+v does not contain the default coordinates %s,%s", b, pin.Lat, pin.Lon)
+	}
+	return nil
+}
+
+func containsString(list []string, want string) bool {
+	for _, s := range list {
+		if s == want {
+			return true
+		}
+	}
+	return false
 }
@@ -1,12 +1,35 @@
 package federation
 
 import (
+	"context"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/source"
 )
 
+// nopSource stands in for the real platforms, which the tournament package
+// registers in production. Validation only needs the ApiVersion to be known.
+type nopSource struct{}
+
+func (nopSource) Fetch(context.Context, models.Federation, source.Query) ([]models.Tournament, error) {
+	return nil, nil
+}
+
+func (nopSource) Capabilities() source.Capabilities { return source.Capabilities{} }
+
+func TestMain(m *testing.M) {
+	for _, version := range []string{"old", "new", "btv"} {
+		source.Register(version, nopSource{})
+	}
+	os.Exit(m.Run())
+}
+
 // TestFederationsAreWellFormed guards the configuration itself: a typo here
 // silently removes a whole federation's tournaments from the map.
 func TestFederationsAreWellFormed(t *testing.T) {
@@ -66,6 +89,12 @@ func TestFederationsAreWellFormed(t *testing.T) {
 			// and inside Germany's bounding box.
 			assertGermanCoordinates(t, fed.Geocoordinates.Lat, fed.Geocoordinates.Lon)
 
+			// Without bounds a radius search has to fetch the federation for
+			// every position in Germany.
+			if fed.Bounds == nil {
+				t.Error("federation has no Bounds")
+			}
+
 			if fed.State == "" {
 				t.Error("federation has an empty State")
 			}
@@ -226,3 +255,144 @@ func TestDefaultsCollidingWithCitiesAreDocumented(t *testing.T) {
 		}
 	}
 }
+
+// validFederation is the smallest entry that passes validation.
+const validFederation = `{
+	"id": "BAD",
+	"name": "Badischer Tennisverband",
+	"url": "https://baden.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar",
+	"api_version": "old",
+	"state": "Baden-Württemberg",
+	"geocoordinates": {"lat": "49.34003", "lon": "8.68514"}
+}`
+
+func TestParseAcceptsValidFile(t *testing.T) {
+	federations, err := Parse([]byte(`{"federations":[` + validFederation + `]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(federations) != 1 || federations[0].Id != "BAD" {
+		t.Fatalf("Parse() = %+v, want the one federation", federations)
+	}
+	if federations[0].Geocoordinates.Lat != "49.34003" {
+		t.Errorf("Lat = %q, want the configured default", federations[0].Geocoordinates.Lat)
+	}
+}
+
+// TestParseRejectsInvalidFiles covers the mistakes an operator editing the
+// file is likely to make. Each would otherwise surface only as a federation
+// quietly returning nothing.
+func TestParseRejectsInvalidFiles(t *testing.T) {
+	with := func(old, new string) string {
+		return `{"federations":[` + strings.Replace(validFederation, old, new, 1) + `]}`
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"not json", `{"federations":`, "parse"},
+		{"empty list", `{"federations":[]}`, "no federations"},
+		{"duplicate id", `{"federations":[` + validFederation + `,` + validFederation + `]}`, "duplicate id"},
+		{"unknown api version", with(`"old"`, `"nuliga-v3"`), "unknown api_version"},
+		{"misspelled key", with(`"api_version"`, `"api_verison"`), "unknown field"},
+		{"unparseable lat", with(`"49.34003"`, `"49,34003"`), "lat"},
+		{"out of range lon", with(`"8.68514"`, `"208.6"`), "lon"},
+		{"missing coordinates", with(`"geocoordinates": {"lat": "49.34003", "lon": "8.68514"}`, `"geocoordinates": {}`), "lat"},
+		{"relative url", with(`"https://baden.liga.nu/cgi-bin/WebObjects/nuLigaTENDE.woa/wa/tournamentCalendar"`, `"/tournamentCalendar"`), "url"},
+		{"empty id", with(`"id": "BAD"`, `"id": ""`), "empty id"},
+		{"swapped bounds", with(`"state": "Baden-Württemberg"`, `"state": "Baden-Württemberg", "bounds": {"south": 49.8, "west": 7.51, "north": 47.53, "east": 9.95}`), "swapped"},
+		{"bounds missing the pin", with(`"state": "Baden-Württemberg"`, `"state": "Baden-Württemberg", "bounds": {"south": 47.53, "west": 7.51, "north": 48.5, "east": 9.95}`), "default coordinates"},
+		{"states without primary", with(`"state": "Baden-Württemberg"`, `"state": "Baden-Württemberg", "states": ["Hessen"]`), "primary state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.raw))
+			if err == nil {
+				t.Fatal("Parse() returned nil error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func writeFederations(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "federations.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write federations file: %v", err)
+	}
+	return path
+}
+
+func TestEnvironmentOverridesEmbeddedList(t *testing.T) {
+	t.Setenv("TTF_FEDERATIONS", writeFederations(t, `{"federations":[`+validFederation+`]}`))
+	ResetForTest()
+	t.Cleanup(ResetForTest)
+
+	federations := GetFederations()
+	if len(federations) != 1 || federations[0].Id != "BAD" {
+		t.Errorf("GetFederations() = %d federations, want only the one from the file", len(federations))
+	}
+}
+
+// TestBrokenFileFallsBackToEmbeddedList protects the map: an unreadable file
+// must not leave the service without federations.
+func TestBrokenFileFallsBackToEmbeddedList(t *testing.T) {
+	t.Setenv("TTF_FEDERATIONS", writeFederations(t, `{"federations":[{"id":"BAD"}]}`))
+	ResetForTest()
+	t.Cleanup(ResetForTest)
+
+	if got := len(GetFederations()); got < 17 {
+		t.Errorf("GetFederations() = %d federations, want the embedded list", got)
+	}
+}
+
+// TestReloadPicksUpChangesAndKeepsLastGoodList covers the hot-reload path
+// used when an expired TrustedProperties token is replaced.
+func TestReloadPicksUpChangesAndKeepsLastGoodList(t *testing.T) {
+	path := writeFederations(t, `{"federations":[`+validFederation+`]}`)
+	t.Setenv("TTF_FEDERATIONS", path)
+	ResetForTest()
+	t.Cleanup(ResetForTest)
+
+	if got := GetFederations()[0].Name; got != "Badischer Tennisverband" {
+		t.Fatalf("Name = %q before reload", got)
+	}
+
+	renamed := strings.Replace(validFederation, "Badischer Tennisverband", "Badischer Tennisverband e.V.", 1)
+	if err := os.WriteFile(path, []byte(`{"federations":[`+renamed+`]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := GetFederations()[0].Name; got != "Badischer Tennisverband e.V." {
+		t.Errorf("Name = %q after reload, want the edited value", got)
+	}
+
+	// A broken edit must be reported and must not replace the working list.
+	if err := os.WriteFile(path, []byte(`{"federations":[{"id":"BAD"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(); err == nil {
+		t.Error("Reload() of a broken file returned nil error")
+	}
+	if got := GetFederations(); len(got) != 1 || got[0].Name != "Badischer Tennisverband e.V." {
+		t.Errorf("GetFederations() = %+v after a failed reload, want the last good list", got)
+	}
+}
+
+func TestGetFederationsReturnsACopy(t *testing.T) {
+	first := GetFederations()
+	first[0].Id = "MUTATED"
+
+	if GetFederations()[0].Id == "MUTATED" {
+		t.Error("mutating the returned slice changed the shared list")
+	}
+}
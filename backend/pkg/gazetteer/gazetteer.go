@@ -0,0 +1,219 @@
+// Package gazetteer resolves German place names offline, from a list of
+// municipalities compiled into the binary.
+//
+// Every geocoding miss used to cost a rate-limited Nominatim request, and with
+// Nominatim unreachable every uncached tournament collapsed onto its
+// federation's default pin. Most place names derived from club names are
+// plain municipalities ("TC Rot-Weiß Karlsruhe" -> "Karlsruhe"), which a
+// static list answers instantly and deterministically, so the network is only
+// needed for the rest.
+//
+// The list is generated from open data by cmd/gazetteer; see
+// data/README.md. TTF_GAZETTEER points at a different file, or disables the
+// gazetteer with "off".
+package gazetteer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// defaultPlaces is compiled into the binary so the service works without any
+// external file.
+//
+//go:embed data/gazetteer.json
+var defaultPlaces []byte
+
+// Place is one municipality.
+type Place struct {
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	Lat         string   `json:"lat"`
+	Lon         string   `json:"lon"`
+	PostalCodes []string `json:"postal_codes,omitempty"`
+}
+
+// Geocoordinates renders the place the way a Nominatim result looks, so the
+// geocoder can apply the same state check to both.
+func (p Place) Geocoordinates() models.Geocoordinates {
+	return models.Geocoordinates{
+		Lat:         p.Lat,
+		Lon:         p.Lon,
+		DisplayName: p.Name + ", " + p.State + ", Deutschland",
+		Address: models.Address{
+			State:       p.State,
+			City:        p.Name,
+			Country:     "Deutschland",
+			CountryCode: "de",
+		},
+	}
+}
+
+type file struct {
+	// Source records where the list was derived from, for attribution.
+	Source string  `json:"source"`
+	Places []Place `json:"places"`
+}
+
+// Gazetteer is a loaded list, indexed for lookup.
+type Gazetteer struct {
+	byName   map[string][]Place
+	byPostal map[string][]Place
+	size     int
+}
+
+var (
+	once      sync.Once
+	loaded    *Gazetteer
+	loadedErr error
+)
+
+// Default returns the process-wide gazetteer, loading it on first use. It
+// returns nil when the gazetteer is disabled. A broken file yields an empty
+// gazetteer and an error for the caller to log, so geocoding falls back to the
+// network instead of failing.
+func Default() (*Gazetteer, error) {
+	once.Do(load)
+	return loaded, loadedErr
+}
+
+// ResetForTest clears the memoized gazetteer so tests can load a different
+// file. It is not safe for concurrent use and is intended for tests only.
+func ResetForTest() {
+	once = sync.Once{}
+	loaded = nil
+	loadedErr = nil
+}
+
+func load() {
+	raw := defaultPlaces
+
+	switch path := os.Getenv("TTF_GAZETTEER"); path {
+	case "":
+	case "off":
+		return
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			loadedErr = fmt.Errorf("failed to read %s: %w", path, err)
+			loaded = &Gazetteer{}
+			return
+		}
+		raw = data
+	}
+
+	g, err := Parse(raw)
+	if err != nil {
+		loadedErr = err
+		loaded = &Gazetteer{}
+		return
+	}
+	loaded = g
+}
+
+// postalCodePattern matches a German postal code.
+var postalCodePattern = regexp.MustCompile(`^\d{5}$`)
+
+// Parse builds a gazetteer from JSON.
+func Parse(raw []byte) (*Gazetteer, error) {
+	var f file
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{
+		byName:   make(map[string][]Place),
+		byPostal: make(map[string][]Place),
+		size:     len(f.Places),
+	}
+
+	for i, p := range f.Places {
+		if p.Name == "" || p.State == "" {
+			return nil, fmt.Errorf("place %d has no name or state", i)
+		}
+		if err := validateCoordinate(p.Lat, -90, 90); err != nil {
+			return nil, fmt.Errorf("place %d (%s): lat: %w", i, p.Name, err)
+		}
+		if err := validateCoordinate(p.Lon, -180, 180); err != nil {
+			return nil, fmt.Errorf("place %d (%s): lon: %w", i, p.Name, err)
+		}
+
+		keys := nameKeys(p.Name)
+		for j, key := range keys {
+			// "Freiburg im Breisgau" is also found as "Freiburg".
+			if j > 0 && key == keys[0] {
+				continue
+			}
+			g.byName[key] = append(g.byName[key], p)
+		}
+		for _, code := range p.PostalCodes {
+			if !postalCodePattern.MatchString(code) {
+				return nil, fmt.Errorf("place %d (%s): invalid postal code %q", i, p.Name, code)
+			}
+			g.byPostal[code] = append(g.byPostal[code], p)
+		}
+	}
+
+	return g, nil
+}
+
+// Lookup returns every place a name or postal code may refer to. Several
+// results are normal: "Neustadt" exists dozens of times, and it is up to the
+// caller to narrow them down by state.
+func (g *Gazetteer) Lookup(query string) []Place {
+	if g == nil {
+		return nil
+	}
+
+	query = strings.TrimSpace(query)
+	if postalCodePattern.MatchString(query) {
+		return g.byPostal[query]
+	}
+	return g.byName[normalize(query)]
+}
+
+// Len reports how many places are loaded.
+func (g *Gazetteer) Len() int {
+	if g == nil {
+		return 0
+	}
+	return g.size
+}
+
+// qualifierPattern finds the start of the geographic suffix of an official
+// name: "Frankfurt am Main", "Halle (Saale)", "Bad Homburg vor der Höhe".
+var qualifierPattern = regexp.MustCompile(`\s+(?:\(|(?:am|an|im|in|vor|bei|auf|ob|unter|a\.|i\.|v\.)\s)`)
+
+// nameKeys returns the normalized full name followed by its short form.
+func nameKeys(name string) []string {
+	keys := []string{normalize(name)}
+	if loc := qualifierPattern.FindStringIndex(name); loc != nil {
+		keys = append(keys, normalize(name[:loc[0]]))
+	}
+	return keys
+}
+
+// normalize lowercases and collapses whitespace. Umlauts are kept: "Mühlheim"
+// and "Mülheim" are different towns.
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func validateCoordinate(raw string, min, max float64) error {
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", raw)
+	}
+	if v < min || v > max {
+		return fmt.Errorf("%q is out of range", raw)
+	}
+	return nil
+}
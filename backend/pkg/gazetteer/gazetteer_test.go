@@ -0,0 +1,182 @@
+package gazetteer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEmbeddedGazetteerResolves guards the shipped data file: a broken entry
+// would otherwise only surface as a wrong pin in production.
+func TestEmbeddedGazetteerResolves(t *testing.T) {
+	g, err := Parse(defaultPlaces)
+	if err != nil {
+		t.Fatalf("Parse(embedded) error = %v", err)
+	}
+
+	tests := []struct {
+		query     string
+		wantName  string
+		wantState string
+	}{
+		{"Karlsruhe", "Karlsruhe", "Baden-Württemberg"},
+		{"münchen", "München", "Bayern"},
+		{"Freiburg", "Freiburg im Breisgau", "Baden-Württemberg"},
+		{"Halle", "Halle (Saale)", "Sachsen-Anhalt"},
+		{"76131", "Karlsruhe", "Baden-Württemberg"},
+	}
+
+	// The generated file lists every municipality, so a short form such as
+	// "Halle" may match several; the geocoder's state check picks one.
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := g.Lookup(tt.query)
+			for _, p := range got {
+				if p.Name == tt.wantName && p.State == tt.wantState {
+					return
+				}
+			}
+			t.Errorf("Lookup(%q) = %v, want %s (%s) among them", tt.query, got, tt.wantName, tt.wantState)
+		})
+	}
+}
+
+// TestEmbeddedGazetteerCoversEveryState makes sure no state is left entirely
+// to the network.
+func TestEmbeddedGazetteerCoversEveryState(t *testing.T) {
+	g, err := Parse(defaultPlaces)
+	if err != nil {
+		t.Fatalf("Parse(embedded) error = %v", err)
+	}
+
+	states := make(map[string]bool)
+	for _, places := range g.byName {
+		for _, p := range places {
+			states[p.State] = true
+		}
+	}
+	if len(states) != 16 {
+		t.Errorf("embedded gazetteer covers %d states, want 16: %v", len(states), states)
+	}
+}
+
+func TestLookupReturnsEveryCandidate(t *testing.T) {
+	g, err := Parse([]byte(`{"places":[
+		{"name":"Frankfurt am Main","state":"Hessen","lat":"50.111","lon":"8.682"},
+		{"name":"Frankfurt (Oder)","state":"Brandenburg","lat":"52.343","lon":"14.550"}
+	]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got := g.Lookup("Frankfurt"); len(got) != 2 {
+		t.Errorf("Lookup(Frankfurt) returned %d places, want both", len(got))
+	}
+	if got := g.Lookup("Frankfurt am Main"); len(got) != 1 || got[0].State != "Hessen" {
+		t.Errorf("Lookup(Frankfurt am Main) = %+v, want only the Hessian one", got)
+	}
+}
+
+func TestLookupMisses(t *testing.T) {
+	g, err := Parse([]byte(`{"places":[
+		{"name":"Karlsruhe","state":"Baden-Württemberg","lat":"49.007","lon":"8.404","postal_codes":["76131"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for _, query := range []string{"", "Karlsruh", "Karlsruhe-Durlach", "76133", "7613"} {
+		if got := g.Lookup(query); len(got) != 0 {
+			t.Errorf("Lookup(%q) = %+v, want no match", query, got)
+		}
+	}
+}
+
+func TestLookupOnNilGazetteer(t *testing.T) {
+	var g *Gazetteer
+	if got := g.Lookup("Karlsruhe"); got != nil {
+		t.Errorf("Lookup on nil = %+v, want nil", got)
+	}
+	if g.Len() != 0 {
+		t.Errorf("Len on nil = %d, want 0", g.Len())
+	}
+}
+
+func TestParseRejectsInvalidPlaces(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"no name", `{"places":[{"state":"Bayern","lat":"48.1","lon":"11.5"}]}`},
+		{"no state", `{"places":[{"name":"München","lat":"48.1","lon":"11.5"}]}`},
+		{"bad lat", `{"places":[{"name":"München","state":"Bayern","lat":"north","lon":"11.5"}]}`},
+		{"lon out of range", `{"places":[{"name":"München","state":"Bayern","lat":"48.1","lon":"190"}]}`},
+		{"bad postal code", `{"places":[{"name":"München","state":"Bayern","lat":"48.1","lon":"11.5","postal_codes":["8033"]}]}`},
+		{"malformed json", `{"places":[`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.json)); err == nil {
+				t.Error("Parse() returned nil error for invalid input")
+			}
+		})
+	}
+}
+
+func TestGeocoordinatesLookLikeNominatim(t *testing.T) {
+	p := Place{Name: "Bremen", State: "Bremen", Lat: "53.079", Lon: "8.802"}
+	got := p.Geocoordinates()
+
+	if got.Lat != "53.079" || got.Lon != "8.802" {
+		t.Errorf("coordinates = %s,%s, want 53.079,8.802", got.Lat, got.Lon)
+	}
+	if got.Address.State != "Bremen" || got.Address.CountryCode != "de" {
+		t.Errorf("address = %+v, want state and country set", got.Address)
+	}
+	if got.DisplayName != "Bremen, Bremen, Deutschland" {
+		t.Errorf("DisplayName = %q", got.DisplayName)
+	}
+}
+
+func TestDefaultHonoursEnvironment(t *testing.T) {
+	t.Cleanup(ResetForTest)
+
+	t.Run("off", func(t *testing.T) {
+		t.Setenv("TTF_GAZETTEER", "off")
+		ResetForTest()
+		g, err := Default()
+		if err != nil || g != nil {
+			t.Errorf("Default() = %v, %v; want a disabled gazetteer", g, err)
+		}
+	})
+
+	t.Run("custom file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "custom.json")
+		content := `{"places":[{"name":"Teststadt","state":"Bayern","lat":"48.0","lon":"11.0"}]}`
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+		t.Setenv("TTF_GAZETTEER", path)
+		ResetForTest()
+		g, err := Default()
+		if err != nil {
+			t.Fatalf("Default() error = %v", err)
+		}
+		if g.Len() != 1 || len(g.Lookup("Teststadt")) != 1 {
+			t.Errorf("Default() did not load the custom file")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("TTF_GAZETTEER", filepath.Join(t.TempDir(), "missing.json"))
+		ResetForTest()
+		g, err := Default()
+		if err == nil {
+			t.Error("Default() returned nil error for a missing file")
+		}
+		if g == nil || g.Len() != 0 {
+			t.Errorf("Default() = %v, want an empty gazetteer", g)
+		}
+	})
+}
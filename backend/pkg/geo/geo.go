@@ -0,0 +1,46 @@
+// Package geo holds the little spherical geometry the radius search needs.
+//
+// Distances use the haversine formula on a spherical earth. The error against
+// the ellipsoid is well under a percent at the tens of kilometres a radius
+// search covers, and tournament coordinates are club-level anyway. The
+// frontend uses the same formula, so both agree on what "within 50 km" means.
+package geo
+
+import (
+	"math"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// earthRadiusKm is the mean earth radius.
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// DistanceToBoundsKm returns the distance from a point to the nearest point of
+// a bounding box, or 0 when the point lies inside it.
+//
+// Clamping the point into the box is exact for the nearest latitude and close
+// enough for longitude at German latitudes and box sizes.
+func DistanceToBoundsKm(lat, lon float64, b models.Bounds) float64 {
+	nearestLat := math.Max(b.South, math.Min(lat, b.North))
+	nearestLon := math.Max(b.West, math.Min(lon, b.East))
+	return DistanceKm(lat, lon, nearestLat, nearestLon)
+}
+
+// CircleIntersectsBounds reports whether a circle overlaps a bounding box.
+func CircleIntersectsBounds(lat, lon, radiusKm float64, b models.Bounds) bool {
+	return DistanceToBoundsKm(lat, lon, b) <= radiusKm
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 49.0, 8.4, 49.0, 8.4, 0},
+		// Karlsruhe to Stuttgart is about 62 km as the crow flies.
+		{"Karlsruhe-Stuttgart", 49.0069, 8.4037, 48.7758, 9.1829, 62},
+		// Hamburg to München is about 612 km.
+		{"Hamburg-München", 53.5503, 10.0007, 48.1371, 11.5754, 612},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > 2 {
+				t.Errorf("DistanceKm() = %.1f, want about %.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircleIntersectsBounds(t *testing.T) {
+	// Roughly Saarland.
+	saar := models.Bounds{South: 49.11, West: 6.36, North: 49.64, East: 7.41}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		radius   float64
+		want     bool
+	}{
+		{"centre inside", 49.24, 7.0, 1, true},
+		// Kaiserslautern is about 20 km east of the border.
+		{"nearby circle reaches in", 49.44, 7.77, 30, true},
+		{"nearby circle stops short", 49.44, 7.77, 10, false},
+		{"far away", 53.55, 10.0, 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CircleIntersectsBounds(tt.lat, tt.lon, tt.radius, saar); got != tt.want {
+				t.Errorf("CircleIntersectsBounds() = %v, want %v (distance %.1f km)",
+					got, tt.want, DistanceToBoundsKm(tt.lat, tt.lon, saar))
+			}
+		})
+	}
+}
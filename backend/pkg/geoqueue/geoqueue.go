@@ -0,0 +1,234 @@
+// Package geoqueue moves geocoding off the request path.
+//
+// Nominatim allows one request per second, so a federation with 200 clubs
+// nobody has looked up yet used to hold a user request for minutes while the
+// parser geocoded row by row. With the queue, the parser answers from the
+// geocoding cache only. Misses are pinned at the federation's default, marked
+// pending and queued here; a single worker resolves them in the background and
+// the next request picks the coordinates up from the cache.
+//
+// The queue is persistent, so lookups survive a restart, and deduplicated: a
+// club that hosts ten tournaments is looked up once.
+package geoqueue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
+)
+
+// Enabled reports whether geocoding runs in the background. It defaults to
+// true; set TTF_GEOCODE_ASYNC=false to geocode while parsing, as before.
+func Enabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("TTF_GEOCODE_ASYNC")))
+	return v != "false" && v != "0" && v != "off"
+}
+
+// Job is one queued lookup.
+type Job struct {
+	Federation models.Federation `json:"federation"`
+	// Tournament carries only what geocoding reads: Id, Organizer, Location.
+	Tournament models.Tournament `json:"tournament"`
+	EnqueuedAt time.Time         `json:"enqueued_at"`
+	// Priority is the most urgent priority the lookup was requested with. It
+	// orders the queue and is passed on to the Nominatim limiter.
+	Priority ratelimit.Priority `json:"priority"`
+}
+
+// Key identifies a lookup. Tournaments at the same club in the same
+// federation share a key, which is what deduplicates the queue.
+func Key(fed models.Federation, tournament models.Tournament) string {
+	return fmt.Sprintf("%s|%s|%s",
+		fed.Id,
+		strings.ToLower(strings.TrimSpace(tournament.Location)),
+		strings.ToLower(strings.TrimSpace(tournament.Organizer)))
+}
+
+// Resolver performs one lookup. It is expected to cache its outcome, success
+// or failure, where the request path will find it.
+type Resolver func(ctx context.Context, fed models.Federation, tournament models.Tournament)
+
+// Stats describes the queue for /stats.
+type Stats struct {
+	Pending   int   `json:"pending"`
+	Processed int64 `json:"processed"`
+	// OldestSeconds is how long the oldest pending job has waited.
+	OldestSeconds int `json:"oldest_seconds,omitempty"`
+	// ByPriority splits Pending by priority name.
+	ByPriority map[string]int `json:"by_priority,omitempty"`
+}
+
+// Queue holds pending lookups and runs the worker that resolves them.
+type Queue struct {
+	store   Store
+	resolve Resolver
+	now     func() time.Time
+
+	// wake is signalled when a job is added, so an idle worker does not poll.
+	wake chan struct{}
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	processed atomic.Int64
+}
+
+// New creates a queue. Call Start to begin resolving.
+func New(store Store, resolve Resolver) *Queue {
+	return &Queue{
+		store:   store,
+		resolve: resolve,
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds a lookup unless the same one is already pending, in which case
+// the pending job is promoted to priority if that is more urgent. It reports
+// whether a job was added.
+func (q *Queue) Enqueue(fed models.Federation, tournament models.Tournament, priority ratelimit.Priority) (bool, error) {
+	job := Job{
+		Federation: fed,
+		Tournament: models.Tournament{
+			Id:        tournament.Id,
+			Organizer: tournament.Organizer,
+			Location:  tournament.Location,
+		},
+		EnqueuedAt: q.now(),
+		Priority:   priority,
+	}
+
+	added, err := q.store.Add(Key(fed, tournament), job)
+	if err != nil {
+		return false, err
+	}
+	if added {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return added, nil
+}
+
+// Start launches the worker. Jobs left over from a previous run are resolved
+// first. Calling Start twice has no effect.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	q.done = make(chan struct{})
+
+	go q.run(ctx, q.done)
+}
+
+// Stop halts the worker and waits for the current lookup to finish. Pending
+// jobs stay in the store for the next Start.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	cancel, done := q.cancel, q.done
+	q.cancel, q.done = nil, nil
+	q.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Close stops the worker and closes the store.
+func (q *Queue) Close() error {
+	q.Stop()
+	return q.store.Close()
+}
+
+// Stats reports the queue's size and progress.
+func (q *Queue) Stats() (Stats, error) {
+	stats := Stats{Processed: q.processed.Load()}
+
+	now := q.now()
+	err := q.store.ForEach(func(_ string, job Job) error {
+		stats.Pending++
+		if stats.ByPriority == nil {
+			stats.ByPriority = make(map[string]int)
+		}
+		stats.ByPriority[job.Priority.String()]++
+		if age := int(now.Sub(job.EnqueuedAt).Seconds()); age > stats.OldestSeconds {
+			stats.OldestSeconds = age
+		}
+		return nil
+	})
+	return stats, err
+}
+
+func (q *Queue) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		key, job, ok, err := q.next()
+		if err != nil {
+			logger.Error("Geocode queue: failed to read pending jobs: %v", err)
+		}
+
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+				continue
+			}
+		}
+
+		q.resolve(ratelimit.WithPriority(ctx, job.Priority), job.Federation, job.Tournament)
+		if ctx.Err() != nil {
+			// Interrupted mid-lookup: keep the job for the next start.
+			return
+		}
+
+		if err := q.store.Delete(key); err != nil {
+			logger.Error("Geocode queue: failed to remove job %s: %v", key, err)
+			// Avoid spinning on a job that cannot be removed.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		q.processed.Add(1)
+	}
+}
+
+// next returns the most urgent job, the one waiting longest among equals. The
+// queue holds at most a few hundred clubs, so a scan is cheap next to the
+// second each lookup costs.
+func (q *Queue) next() (string, Job, bool, error) {
+	var (
+		nextKey string
+		nextJob Job
+		found   bool
+	)
+	err := q.store.ForEach(func(key string, job Job) error {
+		if !found || job.Priority < nextJob.Priority ||
+			(job.Priority == nextJob.Priority && job.EnqueuedAt.Before(nextJob.EnqueuedAt)) {
+			nextKey, nextJob, found = key, job, true
+		}
+		return nil
+	})
+	return nextKey, nextJob, found, err
+}
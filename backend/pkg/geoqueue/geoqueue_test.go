@@ -0,0 +1,245 @@
+package geoqueue
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
+)
+
+var testFederation = models.Federation{Id: "WTV", Name: "Württemberg", State: "Baden-Württemberg"}
+
+// recorder is a Resolver that remembers what it was asked.
+type recorder struct {
+	mu       sync.Mutex
+	resolved []string
+	done     chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{done: make(chan struct{}, 16)}
+}
+
+func (r *recorder) resolve(_ context.Context, _ models.Federation, t models.Tournament) {
+	r.mu.Lock()
+	r.resolved = append(r.resolved, t.Id)
+	r.mu.Unlock()
+	r.done <- struct{}{}
+}
+
+func (r *recorder) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("resolved %d of %d jobs before timeout", i, n)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.resolved...)
+}
+
+func TestEnqueueDeduplicatesByClub(t *testing.T) {
+	q := New(NewMemoryStore(), newRecorder().resolve)
+
+	first := models.Tournament{Id: "1", Organizer: "TC Musterstadt", Location: "Musterstadt"}
+	second := models.Tournament{Id: "2", Organizer: "tc musterstadt ", Location: "Musterstadt"}
+	other := models.Tournament{Id: "3", Organizer: "TC Anderswo", Location: "Anderswo"}
+
+	for _, tc := range []struct {
+		tournament models.Tournament
+		want       bool
+	}{
+		{first, true},
+		{second, false},
+		{other, true},
+	} {
+		added, err := q.Enqueue(testFederation, tc.tournament, ratelimit.PriorityInteractive)
+		if err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", tc.tournament.Id, err)
+		}
+		if added != tc.want {
+			t.Errorf("Enqueue(%s) = %v, want %v", tc.tournament.Id, added, tc.want)
+		}
+	}
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Pending != 2 {
+		t.Errorf("Pending = %d, want 2", stats.Pending)
+	}
+}
+
+func TestWorkerResolvesOldestFirst(t *testing.T) {
+	rec := newRecorder()
+	q := New(NewMemoryStore(), rec.resolve)
+
+	base := time.Date(2026, 8, 14, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"a", "b", "c"} {
+		at := base.Add(time.Duration(i) * time.Minute)
+		q.now = func() time.Time { return at }
+		if _, err := q.Enqueue(testFederation, models.Tournament{Id: id, Organizer: "TC " + id}, ratelimit.PriorityInteractive); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q.Start()
+	defer q.Stop()
+
+	got := rec.wait(t, 3)
+	want := []string{"a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("resolved %v, want %v", got, want)
+		}
+	}
+
+	// A job added while the worker is idle wakes it up.
+	if _, err := q.Enqueue(testFederation, models.Tournament{Id: "d", Organizer: "TC d"}, ratelimit.PriorityInteractive); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.wait(t, 1); got[len(got)-1] != "d" {
+		t.Errorf("resolved %v, want d last", got)
+	}
+}
+
+func TestStopKeepsInterruptedJob(t *testing.T) {
+	started := make(chan struct{})
+	q := New(NewMemoryStore(), func(ctx context.Context, _ models.Federation, _ models.Tournament) {
+		close(started)
+		<-ctx.Done()
+	})
+
+	if _, err := q.Enqueue(testFederation, models.Tournament{Id: "1", Organizer: "TC Eins"}, ratelimit.PriorityInteractive); err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	<-started
+	q.Stop()
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pending != 1 || stats.Processed != 0 {
+		t.Errorf("stats = %+v, want the interrupted job still pending", stats)
+	}
+}
+
+func TestBoltStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.bolt")
+
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	q := New(store, newRecorder().resolve)
+	tournament := models.Tournament{Id: "1", Title: "Sommercup", Organizer: "TC Eins", Location: "Eins"}
+	if _, err := q.Enqueue(testFederation, tournament, ratelimit.PriorityBackground); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer reopened.Close()
+
+	var jobs []Job
+	if err := reopened.ForEach(func(_ string, job Job) error {
+		jobs = append(jobs, job)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("got %d jobs after reopen, want 1", len(jobs))
+	}
+	if jobs[0].Tournament.Organizer != "TC Eins" || jobs[0].Federation.Id != "WTV" {
+		t.Errorf("job = %+v, want the enqueued lookup", jobs[0])
+	}
+	// Only the fields geocoding reads are persisted.
+	if jobs[0].Tournament.Title != "" {
+		t.Errorf("Title = %q, want it trimmed", jobs[0].Tournament.Title)
+	}
+
+	added, err := reopened.Add(Key(testFederation, tournament), Job{Priority: ratelimit.PriorityInteractive})
+	if err != nil || added {
+		t.Errorf("Add() after reopen = %v, %v; want a duplicate", added, err)
+	}
+	if err := reopened.ForEach(func(_ string, job Job) error {
+		if job.Priority != ratelimit.PriorityInteractive || job.Tournament.Organizer != "TC Eins" {
+			t.Errorf("job = %+v, want the original job promoted", job)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInteractiveJobsAreResolvedFirst(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		priorities []ratelimit.Priority
+	)
+	rec := newRecorder()
+	q := New(NewMemoryStore(), func(ctx context.Context, fed models.Federation, tm models.Tournament) {
+		mu.Lock()
+		priorities = append(priorities, ratelimit.PriorityFrom(ctx))
+		mu.Unlock()
+		rec.resolve(ctx, fed, tm)
+	})
+
+	base := time.Date(2026, 8, 14, 12, 0, 0, 0, time.UTC)
+	enqueue := func(minute int, id string, p ratelimit.Priority) {
+		t.Helper()
+		at := base.Add(time.Duration(minute) * time.Minute)
+		q.now = func() time.Time { return at }
+		if _, err := q.Enqueue(testFederation, models.Tournament{Id: id, Organizer: "TC " + id}, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A warmup queued two clubs before a user asked for a third one and,
+	// later, for the second warmup club too.
+	enqueue(0, "warmup-1", ratelimit.PriorityBackground)
+	enqueue(1, "warmup-2", ratelimit.PriorityBackground)
+	enqueue(2, "user", ratelimit.PriorityInteractive)
+	enqueue(3, "warmup-2", ratelimit.PriorityInteractive)
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pending != 3 || stats.ByPriority["interactive"] != 2 || stats.ByPriority["background"] != 1 {
+		t.Errorf("stats = %+v, want the second warmup club promoted", stats)
+	}
+
+	q.Start()
+	defer q.Stop()
+
+	got := rec.wait(t, 3)
+	want := []string{"warmup-2", "user", "warmup-1"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("resolved %v, want %v", got, want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if priorities[0] != ratelimit.PriorityInteractive || priorities[2] != ratelimit.PriorityBackground {
+		t.Errorf("lookups ran with priorities %v, want each job's own", priorities)
+	}
+}
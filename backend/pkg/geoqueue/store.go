@@ -0,0 +1,167 @@
+package geoqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// queueBucket holds pending jobs.
+const queueBucket = "geocode_queue"
+
+// Store persists pending jobs.
+type Store interface {
+	// Add stores job under key unless key is already present, and reports
+	// whether it did. A present job is promoted to job's priority when that
+	// is more urgent.
+	Add(key string, job Job) (bool, error)
+	Delete(key string) error
+	ForEach(fn func(key string, job Job) error) error
+	Close() error
+}
+
+// BoltStore persists jobs in BoltDB.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (or creates) the queue database at dbPath.
+func NewBoltStore(dbPath string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geocode queue at %s: %w", dbPath, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(queueBucket))
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create queue bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Add(key string, job Job) (bool, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return false, err
+	}
+
+	added := false
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(queueBucket))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s does not exist", queueBucket)
+		}
+		if existing := bucket.Get([]byte(key)); existing != nil {
+			var pending Job
+			if err := json.Unmarshal(existing, &pending); err == nil {
+				if job.Priority >= pending.Priority {
+					return nil
+				}
+				pending.Priority = job.Priority
+				if data, err = json.Marshal(pending); err != nil {
+					return err
+				}
+			}
+			// An unreadable entry is replaced by the new job.
+		} else {
+			added = true
+		}
+		return bucket.Put([]byte(key), data)
+	})
+	return added, err
+}
+
+func (s *BoltStore) Delete(key string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(queueBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) ForEach(fn func(key string, job Job) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(queueBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return nil // skip corrupt entries
+			}
+			return fn(string(k), job)
+		})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// MemoryStore keeps jobs in memory. It is used by tests and as a fallback
+// when the persistent store cannot be opened.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryStore) Add(key string, job Job) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pending, ok := s.jobs[key]; ok {
+		if job.Priority < pending.Priority {
+			pending.Priority = job.Priority
+			s.jobs[key] = pending
+		}
+		return false, nil
+	}
+	s.jobs[key] = job
+	return true, nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, key)
+	return nil
+}
+
+func (s *MemoryStore) ForEach(fn func(key string, job Job) error) error {
+	s.mu.RLock()
+	snapshot := make(map[string]Job, len(s.jobs))
+	for k, v := range s.jobs {
+		snapshot[k] = v
+	}
+	s.mu.RUnlock()
+
+	for k, v := range snapshot {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
@@ -1,11 +1,15 @@
 package httpclient
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -24,22 +28,57 @@ const (
 	DefaultUserAgent = "TennisTournamentFinder/1.0 (+https://github.com/timoknapp/tennis-tournament-finder)"
 )
 
+// ErrNonPublicAddress is returned when a client made by NewPublic is asked to
+// connect to a loopback, link-local or private address.
+var ErrNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
 var (
 	federationOnce   sync.Once
 	federationClient *http.Client
 
 	geocodingOnce   sync.Once
 	geocodingClient *http.Client
+
+	pushOnce   sync.Once
+	pushClient *http.Client
 )
 
 // New builds an HTTP client with bounded timeouts at every stage of the
 // request lifecycle.
 func New(timeout time.Duration) *http.Client {
+	return newClient(timeout, nil)
+}
+
+// NewPublic is New for URLs that users supply: it only connects to public
+// addresses. The check runs on the address a host name resolved to, right
+// before connecting, so a name cannot be pointed elsewhere after validation.
+func NewPublic(timeout time.Duration) *http.Client {
+	return newClient(timeout, func(network, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		if !IsPublic(addrPort.Addr()) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+		}
+		return nil
+	})
+}
+
+// IsPublic reports whether addr is a unicast address outside the loopback,
+// link-local and private ranges.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+func newClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
 	transport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   DefaultDialTimeout,
 			KeepAlive: 30 * time.Second,
+			Control:   control,
 		}).DialContext,
 		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
 		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
@@ -71,6 +110,15 @@ func Geocoding() *http.Client {
 	return geocodingClient
 }
 
+// Push returns the shared client used to deliver Web Push messages. Push
+// endpoints come from browsers, so it only connects to public addresses.
+func Push() *http.Client {
+	pushOnce.Do(func() {
+		pushClient = NewPublic(durationFromEnv("TTF_PUSH_TIMEOUT_SECONDS", DefaultTimeout))
+	})
+	return pushClient
+}
+
 // UserAgent returns the User-Agent sent with outbound requests. It can be
 // overridden with TTF_USER_AGENT so operators of a fork can supply their own
 // contact details.
@@ -0,0 +1,163 @@
+// Package ical writes RFC 5545 calendars.
+//
+// Only the subset needed for all-day tournament events is implemented. The
+// two rules calendar clients are strict about are handled here rather than by
+// callers: text values are escaped, and content lines are folded at 75 octets
+// without splitting a UTF-8 sequence (club names are full of umlauts).
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line RFC 5545 allows, excluding CRLF.
+const maxLineOctets = 75
+
+// Event is one all-day VEVENT.
+type Event struct {
+	// UID must stay the same across exports, so subscribed calendars update
+	// the event in place instead of adding a duplicate.
+	UID     string
+	Summary string
+	// Start and End are the first and last day, inclusive. End is converted
+	// to the exclusive DTEND the format requires.
+	Start       time.Time
+	End         time.Time
+	Location    string
+	Description string
+	URL         string
+	// Organizer is a display name; the format wants an address, which no
+	// upstream publishes.
+	Organizer string
+	// Lat and Lon are written as GEO when both are set.
+	Lat string
+	Lon string
+	// Status is written as STATUS when set: "TENTATIVE", "CONFIRMED" or
+	// "CANCELLED".
+	Status string
+}
+
+// Calendar is a VCALENDAR with its events.
+type Calendar struct {
+	// ProdID identifies the producing application.
+	ProdID string
+	// Name is shown by clients that support X-WR-CALNAME.
+	Name   string
+	Events []Event
+	// Stamp is written as every event's DTSTAMP; zero means now.
+	Stamp time.Time
+}
+
+// Write renders the calendar to w.
+func (c Calendar) Write(w io.Writer) error {
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	stampValue := stamp.UTC().Format("20060102T150405Z")
+
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", escapeText(c.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(e.UID))
+		line("DTSTAMP", stampValue)
+		line("DTSTART;VALUE=DATE", e.Start.Format("20060102"))
+		line("DTEND;VALUE=DATE", e.End.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		if e.Location != "" {
+			line("LOCATION", escapeText(e.Location))
+		}
+		if e.Lat != "" && e.Lon != "" {
+			line("GEO", e.Lat+";"+e.Lon)
+		}
+		if e.Organizer != "" {
+			// "invalid:nomail" is the conventional placeholder address for
+			// organizers that cannot be contacted through the calendar.
+			line("ORGANIZER;CN="+quoteParam(e.Organizer), "invalid:nomail")
+		}
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case ';':
+			b.WriteString(`\;`)
+		case ',':
+			b.WriteString(`\,`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			// Dropped: "\r\n" in upstream text must not become two breaks.
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// quoteParam renders a parameter value. DQUOTE cannot be escaped inside a
+// quoted value, so it is removed.
+func quoteParam(s string) string {
+	s = strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(s)
+	return `"` + s + `"`
+}
+
+// writeFolded writes one content line, folding it into continuation lines
+// that start with a space.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// Back up to the start of a UTF-8 sequence.
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose one octet to the leading space.
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
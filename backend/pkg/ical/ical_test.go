@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func day(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+func render(t *testing.T, c Calendar) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestWriteEvent(t *testing.T) {
+	out := render(t, Calendar{
+		ProdID: "-//test//EN",
+		Stamp:  time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC),
+		Events: []Event{{
+			UID:         "tournament-700001@example",
+			Summary:     "Sommer Open",
+			Start:       day(2026, 8, 1),
+			End:         day(2026, 8, 3),
+			Location:    "Karlsruhe",
+			Organizer:   "TC Karlsruhe",
+			Description: "Herren Einzel, LK 1-12",
+			URL:         "https://www.tennis.de/700001",
+			Lat:         "49.0069",
+			Lon:         "8.4037",
+			Status:      "TENTATIVE",
+		}},
+	})
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"VERSION:2.0\r\n",
+		"UID:tournament-700001@example\r\n",
+		"DTSTAMP:20260801T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20260801\r\n",
+		// DTEND is exclusive, so a tournament ending on the 3rd ends on the 4th.
+		"DTEND;VALUE=DATE:20260804\r\n",
+		"SUMMARY:Sommer Open\r\n",
+		"STATUS:TENTATIVE\r\n",
+		"LOCATION:Karlsruhe\r\n",
+		"GEO:49.0069;8.4037\r\n",
+		"ORGANIZER;CN=\"TC Karlsruhe\":invalid:nomail\r\n",
+		"DESCRIPTION:Herren Einzel\\, LK 1-12\r\n",
+		"URL:https://www.tennis.de/700001\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteOmitsEmptyProperties(t *testing.T) {
+	out := render(t, Calendar{Events: []Event{{UID: "u", Summary: "s", Start: day(2026, 8, 1), End: day(2026, 8, 1)}}})
+
+	for _, absent := range []string{"LOCATION", "GEO", "ORGANIZER", "DESCRIPTION", "URL:", "STATUS"} {
+		if strings.Contains(out, absent) {
+			t.Errorf("output contains %s for an empty value:\n%s", absent, out)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a,b;c", `a\,b\;c`},
+		{`back\slash`, `back\\slash`},
+		{"zwei\r\nZeilen", `zwei\nZeilen`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestLongLinesAreFoldedOnRuneBoundaries guards the two folding rules: no line
+// over 75 octets, and no multi-byte character split across lines.
+func TestLongLinesAreFoldedOnRuneBoundaries(t *testing.T) {
+	summary := strings.Repeat("Größte Tennismeisterschaft ", 10)
+	out := render(t, Calendar{Events: []Event{{UID: "u", Summary: summary, Start: day(2026, 8, 1), End: day(2026, 8, 1)}}})
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line has %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a UTF-8 sequence: %q", line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+summary+"\r\n") {
+		t.Error("unfolding does not restore the original summary")
+	}
+}
@@ -177,6 +177,9 @@ func StatsHandler(w http.ResponseWriter, r *http.Request) {
 		ActiveUsers5m:             int64(len(st.active)),
 		RequestsByMethodAndStatus: methodStatus,
 		ResultCache:               resultCacheSnapshot(),
+		GeocodeQueue:              geocodeQueueSnapshot(),
+		Catalog:                   catalogSnapshot(),
+		GeocodingLimiter:          geocodingLimiterSnapshot(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -197,6 +200,9 @@ type stats struct {
 	ActiveUsers5m             int64                       `json:"active_users_5m"`
 	RequestsByMethodAndStatus map[string]map[string]int64 `json:"requests_by_method_status"`
 	ResultCache               any                         `json:"result_cache,omitempty"`
+	GeocodeQueue              any                         `json:"geocode_queue,omitempty"`
+	Catalog                   any                         `json:"catalog,omitempty"`
+	GeocodingLimiter          any                         `json:"geocoding_limiter,omitempty"`
 }
 
 // resultCacheProvider supplies cache statistics for the diagnostics endpoint.
@@ -225,6 +231,81 @@ func resultCacheSnapshot() any {
 	return fn()
 }
 
+// geocodeQueueProvider supplies background geocoding statistics, wired the
+// same way as resultCacheProvider.
+var (
+	geocodeQueueProvider   func() any
+	geocodeQueueProviderMu sync.RWMutex
+)
+
+// SetGeocodeQueueProvider registers a source of geocode-queue statistics.
+func SetGeocodeQueueProvider(fn func() any) {
+	geocodeQueueProviderMu.Lock()
+	defer geocodeQueueProviderMu.Unlock()
+	geocodeQueueProvider = fn
+}
+
+func geocodeQueueSnapshot() any {
+	geocodeQueueProviderMu.RLock()
+	fn := geocodeQueueProvider
+	geocodeQueueProviderMu.RUnlock()
+
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+// catalogProvider supplies tournament catalog statistics, wired the same way
+// as resultCacheProvider.
+var (
+	catalogProvider   func() any
+	catalogProviderMu sync.RWMutex
+)
+
+// SetCatalogProvider registers a source of tournament catalog statistics.
+func SetCatalogProvider(fn func() any) {
+	catalogProviderMu.Lock()
+	defer catalogProviderMu.Unlock()
+	catalogProvider = fn
+}
+
+func catalogSnapshot() any {
+	catalogProviderMu.RLock()
+	fn := catalogProvider
+	catalogProviderMu.RUnlock()
+
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+// geocodingLimiterProvider supplies the Nominatim limiter's per-priority
+// queue depth and waiting times.
+var (
+	geocodingLimiterProvider   func() any
+	geocodingLimiterProviderMu sync.RWMutex
+)
+
+// SetGeocodingLimiterProvider registers a source of rate-limiter statistics.
+func SetGeocodingLimiterProvider(fn func() any) {
+	geocodingLimiterProviderMu.Lock()
+	defer geocodingLimiterProviderMu.Unlock()
+	geocodingLimiterProvider = fn
+}
+
+func geocodingLimiterSnapshot() any {
+	geocodingLimiterProviderMu.RLock()
+	fn := geocodingLimiterProvider
+	geocodingLimiterProviderMu.RUnlock()
+
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
 type metricsState struct {
 	mu sync.Mutex
 
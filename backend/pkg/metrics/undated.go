@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/undated"
+)
+
+// UnparsedDatesPath serves the tournament dates that could not be parsed.
+const UnparsedDatesPath = "/stats/unparsed-dates"
+
+// undatedResponse is the shape served at UnparsedDatesPath.
+type undatedResponse struct {
+	Count int `json:"count"`
+	// Dropped is non-zero when the registry filled up and the list is
+	// incomplete.
+	Dropped int             `json:"dropped,omitempty"`
+	Dates   []undated.Entry `json:"dates"`
+}
+
+// UnparsedDatesHandler reports date texts that left tournaments without a
+// start_date/end_date, most frequent first. Each one is a format the parser in
+// pkg/daterange does not know yet.
+func UnparsedDatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dates := undated.Snapshot()
+	response := undatedResponse{
+		Count:   len(dates),
+		Dropped: undated.Dropped(),
+		Dates:   dates,
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(response); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
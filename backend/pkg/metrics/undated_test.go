@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/undated"
+)
+
+func TestUnparsedDatesHandlerReportsRecordedDates(t *testing.T) {
+	undated.Reset()
+	undated.Record("demnächst", "BAD", "1", "Sommer-Cup")
+	undated.Record("demnächst", "BAD", "2", "Herbst-Cup")
+	undated.Record("verschoben", "WTB", "3", "Stadtmeisterschaft")
+
+	rec := httptest.NewRecorder()
+	UnparsedDatesHandler(rec, httptest.NewRequest(http.MethodGet, UnparsedDatesPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var got struct {
+		Count int `json:"count"`
+		Dates []struct {
+			Text       string `json:"text"`
+			Federation string `json:"federation"`
+			Count      int    `json:"count"`
+		} `json:"dates"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if got.Count != 2 {
+		t.Errorf("expected 2 date texts, got %d", got.Count)
+	}
+	if got.Dates[0].Text != "demnächst" || got.Dates[0].Count != 2 {
+		t.Errorf("expected the most frequent text first, got %+v", got.Dates[0])
+	}
+}
+
+func TestUnparsedDatesHandlerRejectsNonGet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	UnparsedDatesHandler(rec, httptest.NewRequest(http.MethodPost, UnparsedDatesPath, nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
@@ -3,18 +3,61 @@ package models
 type CompetitionEntry struct {
 	Competition string `json:"competition"` // Konkurrenz
 	SkillLevel  string `json:"skill_level"` // LK
+	// The fields below are Competition parsed by pkg/competition, so clients
+	// can filter without string matching. Competition stays the source of
+	// truth for display. Each is empty when the name does not say.
+	//
+	// Gender is "Damen", "Herren", "Mixed" or "open".
+	Gender string `json:"gender,omitempty"`
+	// AgeClass is "open", a junior class ("J14", "U14"), a senior class
+	// ("S30" to "S85"), or "junior"/"senior" without a stated limit.
+	AgeClass string `json:"age_class,omitempty"`
+	// Discipline is "Einzel" or "Doppel".
+	Discipline string `json:"discipline,omitempty"`
+	// DrawType is "main", "qualifying", "consolation" or "round_robin".
+	DrawType string `json:"draw_type,omitempty"`
+	// Eligibility is set by a player-profile search only: it depends on the
+	// player, so it is never cached.
+	Eligibility *Eligibility `json:"eligibility,omitempty"`
+}
+
+// Eligibility explains why a player-profile search kept a competition.
+type Eligibility struct {
+	// Reason is a short German explanation, e.g. "Damen, S40: wird 2026 45,
+	// LK 14,0 in LK 1,0–25,0".
+	Reason string `json:"reason"`
+	// Unknown lists the constraints that could not be checked and were
+	// treated as open: "gender", "age" or "lk".
+	Unknown []string `json:"unknown,omitempty"`
 }
 
 type Tournament struct {
-	Id        string             `json:"id"`
-	Title     string             `json:"title"`
-	URL       string             `json:"url"`
-	Date      string             `json:"date"`
+	// Id is the upstream id, or a content hash when the source publishes
+	// none; see pkg/tournamentid. It is unique within its federation only.
+	Id string `json:"id"`
+	// CanonicalId is Id namespaced by federation ("BAD:700001"), unique
+	// across all sources.
+	CanonicalId string `json:"canonical_id,omitempty"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Date        string `json:"date"`
+	// StartDate and EndDate are Date parsed into ISO days (2006-01-02). They
+	// are empty when Date could not be parsed; Date stays the source of truth
+	// for display.
+	StartDate string             `json:"start_date,omitempty"`
+	EndDate   string             `json:"end_date,omitempty"`
 	Location  string             `json:"location"`
 	Organizer string             `json:"organizer"`
 	Lat       string             `json:"lat"`
 	Lon       string             `json:"lon"`
 	Entries   []CompetitionEntry `json:"entries"` // Competition-SkillLevel pairs
+	// RegistrationDeadline is the published "Meldeschluss" as an ISO day
+	// (2006-01-02). It is empty when the source publishes none or it could
+	// not be parsed, which a registrationOpen search treats as still open.
+	RegistrationDeadline string `json:"registration_deadline,omitempty"`
+	// OpenFor is who may enter, as published ("Offen für"), e.g. "Alle" or
+	// "Vereinsmitglieder".
+	OpenFor string `json:"open_for,omitempty"`
 	// ApproximateLocation marks a tournament pinned at its federation's default
 	// rather than at a place derived from its name.
 	//
@@ -24,8 +67,31 @@ type Tournament struct {
 	// BTV default. Recording the outcome where it is known is the only reliable
 	// way to tell them apart.
 	ApproximateLocation bool `json:"approximate_location,omitempty"`
+	// LocationSource names what the pin was derived from: "override",
+	// "location", "location-derived", "organizer-derived" or
+	// "federation-default", roughly from most to least trustworthy. It is
+	// empty for pins geocoded before sources were recorded.
+	LocationSource string `json:"location_source,omitempty"`
+	// LocationDisplayName is the geocoder's name for the matched place, so a
+	// wrong guess ("Neckarau" resolving to a street) can be spotted.
+	LocationDisplayName string `json:"location_display_name,omitempty"`
+	// LocationPending marks a pin at the federation's default while the
+	// tournament's place waits in the background geocoding queue. A later
+	// request returns the resolved coordinates.
+	LocationPending bool `json:"location_pending,omitempty"`
+	// DistanceKm is set by a radius search only: it depends on the caller's
+	// position, so it is never cached.
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	// Status is empty for a tournament the federation lists, or
+	// StatusPossiblyCancelled for one it stopped listing before its date.
+	Status string `json:"status,omitempty"`
 }
 
+// StatusPossiblyCancelled marks a tournament that disappeared from a complete
+// listing of its federation while still ahead. Federations rarely announce a
+// cancellation; they just unlist the tournament.
+const StatusPossiblyCancelled = "possibly_cancelled"
+
 type Geocoordinates struct {
 	Lat         string `json:"lat"`
 	Lon         string `json:"lon"`
@@ -38,6 +104,9 @@ type Geocoordinates struct {
 	LastAttempt int64   `json:"last_attempt,omitempty"` // Unix timestamp of last geocoding attempt
 	FailCount   int     `json:"fail_count,omitempty"`   // Number of consecutive failures
 	IsFailed    bool    `json:"is_failed,omitempty"`    // Marks this as a failed geocoding attempt
+	// Pending is set by a geocoder that queued the lookup instead of waiting
+	// for it. It is never cached.
+	Pending bool `json:"-"`
 }
 
 // Address is the subset of Nominatim's structured address we rely on.
@@ -49,6 +118,9 @@ type Address struct {
 	Municipality string `json:"municipality,omitempty"`
 	Country      string `json:"country,omitempty"`
 	CountryCode  string `json:"country_code,omitempty"`
+	// Source names the place name a pin was geocoded from ("override",
+	// "location", ...). It is set by this service, never by Nominatim.
+	Source string `json:"source,omitempty"`
 }
 
 // Place returns the most specific settlement name available.
@@ -76,6 +148,18 @@ type Federation struct {
 	States            []string `json:"states,omitempty"`
 	ApiVersion        string   `json:"api_version"`
 	TrustedProperties string   `json:"trusted_properties"`
+	// Bounds is a bounding box around the federation's territory. A radius
+	// search uses it to skip federations the circle cannot reach; without it
+	// the federation is always searched.
+	Bounds *Bounds `json:"bounds,omitempty"`
+}
+
+// Bounds is a latitude/longitude bounding box in degrees.
+type Bounds struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
 }
 
 // AcceptedStates returns every state a geocoding result may belong to.
@@ -2,16 +2,16 @@ package openstreetmap
 
 import (
 	"os"
-	"sync"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/addressprovider"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/clublocations"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/gazetteer"
 )
 
 // resetRateLimiterForTest rebuilds the process-wide geocoding limiter so tests
 // can exercise different intervals. It is only compiled into test binaries.
 func resetRateLimiterForTest() {
-	geocodingLimiterOnce = sync.Once{}
-	geocodingLimiter = nil
+	addressprovider.ResetRateLimiterForTest()
 }
 
 // resetClubLocationsForTest clears the memoized override table so a test can
@@ -24,3 +24,9 @@ func resetClubLocationsForTest() {
 func osWriteFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0o644)
 }
+
+// resetGazetteerForTest clears the memoized gazetteer so a test can point
+// TTF_GAZETTEER at its own fixture.
+func resetGazetteerForTest() {
+	gazetteer.ResetForTest()
+}
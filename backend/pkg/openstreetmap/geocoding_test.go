@@ -1,6 +1,7 @@
 package openstreetmap
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"sync/atomic"
 	"testing"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/addressprovider"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
 )
 
@@ -71,7 +73,7 @@ func TestMultiStateFederationAcceptsEitherState(t *testing.T) {
 		States: []string{"Berlin", "Brandenburg"},
 	}
 
-	got := GetGeocoordinatesForFederation(tvbb, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), tvbb, models.Tournament{
 		Id: "1", Location: "Bad Saarow", Organizer: "TC Bad Saarow",
 	})
 
@@ -93,7 +95,7 @@ func TestSingleStateFederationStillRejectsOtherStates(t *testing.T) {
 
 	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
 
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Location: "Musterstadt",
 	})
 
@@ -118,7 +120,7 @@ func TestStateVerifiedViaStructuredAddress(t *testing.T) {
 
 	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
 
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Location: "Neckarau",
 	})
 
@@ -144,7 +146,7 @@ func TestCandidatesAreTriedUntilOneResolves(t *testing.T) {
 
 	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
 
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Organizer: "Heidelberger Tennis-Club 1890 e.V.",
 	})
 
@@ -166,7 +168,7 @@ func TestCandidateCountIsBounded(t *testing.T) {
 
 	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
 
-	GetGeocoordinatesForFederation(fed, models.Tournament{
+	GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id:        "1",
 		Location:  "Irgendwo an der Grenze",
 		Organizer: "TC Rot-Weiß Musterhausen-Kleinkleckersdorf 1920 e.V.",
@@ -175,8 +177,8 @@ func TestCandidateCountIsBounded(t *testing.T) {
 	// Each candidate costs one rate-limited request and both lookup passes
 	// share one budget, so the total must stay predictable regardless of how
 	// long the club name is.
-	if got := len(rec.recorded()); got > maxGeocodeRequests {
-		t.Errorf("made %d requests, want at most %d", got, maxGeocodeRequests)
+	if got := len(rec.recorded()); got > addressprovider.DefaultMaxNominatimRequests {
+		t.Errorf("made %d requests, want at most %d", got, addressprovider.DefaultMaxNominatimRequests)
 	}
 }
 
@@ -197,7 +199,7 @@ func TestOverrideTakesPrecedence(t *testing.T) {
 
 	// "Lohausener Sport-Verein" cannot be resolved from its name; the
 	// override maps it to Düsseldorf.
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Organizer: "Lohausener Sport-Verein",
 	})
 
@@ -221,7 +223,7 @@ func TestOverrideWithPinnedCoordinatesSkipsNetwork(t *testing.T) {
 	rec := newRecordingServer(t, nil)
 
 	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Organizer: "TC Sonderfall",
 	})
 
@@ -233,97 +235,6 @@ func TestOverrideWithPinnedCoordinatesSkipsNetwork(t *testing.T) {
 	}
 }
 
-func TestMatchesState(t *testing.T) {
-	tests := []struct {
-		name     string
-		geo      models.Geocoordinates
-		accepted []string
-		want     bool
-	}{
-		{
-			name:     "structured address matches",
-			geo:      models.Geocoordinates{Address: models.Address{State: "Hessen"}},
-			accepted: []string{"Hessen"},
-			want:     true,
-		},
-		{
-			name:     "structured address mismatches",
-			geo:      models.Geocoordinates{Address: models.Address{State: "Berlin"}},
-			accepted: []string{"Hessen"},
-			want:     false,
-		},
-		{
-			name:     "second accepted state matches",
-			geo:      models.Geocoordinates{Address: models.Address{State: "Bremen"}},
-			accepted: []string{"Niedersachsen", "Bremen"},
-			want:     true,
-		},
-		{
-			name:     "falls back to display name when address is absent",
-			geo:      models.Geocoordinates{DisplayName: "Kassel, Hessen, Deutschland"},
-			accepted: []string{"Hessen"},
-			want:     true,
-		},
-		{
-			name: "structured address wins over display name",
-			geo: models.Geocoordinates{
-				DisplayName: "Irgendwo, Hessen, Deutschland",
-				Address:     models.Address{State: "Berlin"},
-			},
-			accepted: []string{"Hessen"},
-			want:     false,
-		},
-		{
-			name:     "no restriction accepts anything",
-			geo:      models.Geocoordinates{Address: models.Address{State: "Sachsen"}},
-			accepted: nil,
-			want:     true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := matchesState(tt.geo, tt.accepted); got != tt.want {
-				t.Errorf("matchesState() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestBuildGeocodeQueriesOrder(t *testing.T) {
-	queries, _ := buildGeocodeQueries(models.Tournament{
-		Location:  "Karlsruhe",
-		Organizer: "TC Rot-Weiß Karlsruhe e.V.",
-	})
-
-	if len(queries) == 0 {
-		t.Fatal("no queries built")
-	}
-	// The published location is the most trustworthy signal.
-	if queries[0].value != "Karlsruhe" {
-		t.Errorf("first query = %q, want the published location", queries[0].value)
-	}
-
-	// No duplicates, even though the organizer derives the same city.
-	seen := make(map[string]bool)
-	for _, q := range queries {
-		if seen[q.value] {
-			t.Errorf("duplicate query %q in %+v", q.value, queries)
-		}
-		seen[q.value] = true
-	}
-}
-
-func TestBuildGeocodeQueriesWithoutUsableInput(t *testing.T) {
-	queries, override := buildGeocodeQueries(models.Tournament{Id: "1"})
-	if len(queries) != 0 {
-		t.Errorf("queries = %+v, want none", queries)
-	}
-	if override != nil {
-		t.Errorf("override = %+v, want nil", override)
-	}
-}
-
 // writeOverrides writes a club-locations file and returns its path.
 func writeOverrides(t *testing.T, content string) string {
 	t.Helper()
@@ -335,39 +246,6 @@ func writeOverrides(t *testing.T, content string) string {
 	return path
 }
 
-// TestPlaceMatchesQuery covers the guard against Nominatim returning a
-// different place that merely contains the queried word.
-func TestPlaceMatchesQuery(t *testing.T) {
-	tests := []struct {
-		name  string
-		place string
-		query string
-		want  bool
-	}{
-		{"exact match", "Bremen", "Bremen", true},
-		{"case insensitive", "bremen", "Bremen", true},
-		{"official long form", "Bad Homburg vor der Höhe", "Bad Homburg", true},
-		{"city with suffix", "Frankfurt am Main", "Frankfurt", true},
-		// Regression: "Bremer" must not settle for the hamlet "Bremer Sand".
-		{"different place containing the query", "Bremer Sand", "Bremer", false},
-		{"street-like name", "Ratinger Straße", "Ratinger", false},
-		{"unrelated", "Bösel", "Bremen", false},
-		{"empty place", "", "Bremen", false},
-		{"empty query", "Bremen", "", false},
-		{"query longer than place", "Bad", "Bad Homburg", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			geo := models.Geocoordinates{Address: models.Address{City: tt.place}}
-			if got := placeMatchesQuery(geo, tt.query); got != tt.want {
-				t.Errorf("placeMatchesQuery(%q, %q) = %v, want %v",
-					tt.place, tt.query, got, tt.want)
-			}
-		})
-	}
-}
-
 // TestExactPlaceMatchWinsOverInexactHit is the regression test for the
 // "Bremer Tennisclub" case: an inexact hit from an early candidate must not
 // beat an exact match from a later one.
@@ -392,7 +270,7 @@ func TestExactPlaceMatchWinsOverInexactHit(t *testing.T) {
 	fed := models.Federation{Id: "TNB", State: "Niedersachsen",
 		States: []string{"Niedersachsen", "Bremen"}}
 
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Organizer: "Bremer Tennisclub",
 	})
 
@@ -417,7 +295,7 @@ func TestInexactHitUsedWhenNothingBetterExists(t *testing.T) {
 
 	fed := models.Federation{Id: "HTV", State: "Hessen"}
 
-	got := GetGeocoordinatesForFederation(fed, models.Tournament{
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
 		Id: "1", Location: "Kleinkleckersdorf",
 	})
 
@@ -425,3 +303,119 @@ func TestInexactHitUsedWhenNothingBetterExists(t *testing.T) {
 		t.Errorf("got %+v, want the inexact hit rather than no result at all", got)
 	}
 }
+
+// useGazetteer points the geocoder at a gazetteer fixture for one test.
+func useGazetteer(t *testing.T, content string) {
+	t.Helper()
+
+	path := t.TempDir() + "/gazetteer.json"
+	if err := osWriteFile(path, content); err != nil {
+		t.Fatalf("failed to write gazetteer: %v", err)
+	}
+	t.Setenv("TTF_GAZETTEER", path)
+	resetGazetteerForTest()
+	t.Cleanup(resetGazetteerForTest)
+}
+
+func TestGazetteerResolvesWithoutNetwork(t *testing.T) {
+	initTestCache(t)
+	useGazetteer(t, `{"places":[
+		{"name":"Karlsruhe","state":"Baden-Württemberg","lat":"49.007","lon":"8.404"}
+	]}`)
+
+	rec := newRecordingServer(t, nil)
+
+	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
+		Id: "1", Organizer: "TC Rot-Weiß Karlsruhe",
+	})
+
+	if got.Lat != "49.007" || got.Lon != "8.404" {
+		t.Errorf("got %+v, want the gazetteer coordinates", got)
+	}
+	if got.Address.State != "Baden-Württemberg" {
+		t.Errorf("Address.State = %q, want the gazetteer's state", got.Address.State)
+	}
+	if calls := len(rec.recorded()); calls != 0 {
+		t.Errorf("made %d geocoding requests, want 0 for a gazetteer hit", calls)
+	}
+}
+
+func TestGazetteerAppliesStateCheck(t *testing.T) {
+	initTestCache(t)
+	useGazetteer(t, `{"places":[
+		{"name":"Frankfurt am Main","state":"Hessen","lat":"50.111","lon":"8.682"},
+		{"name":"Frankfurt (Oder)","state":"Brandenburg","lat":"52.343","lon":"14.550"}
+	]}`)
+
+	rec := newRecordingServer(t, nil)
+
+	tvbb := models.Federation{Id: "TVBB", State: "Berlin", States: []string{"Berlin", "Brandenburg"}}
+	got := GetGeocoordinatesForFederation(context.Background(), tvbb, models.Tournament{
+		Id: "1", Location: "Frankfurt",
+	})
+
+	if got.Lat != "52.343" {
+		t.Errorf("got %+v, want Frankfurt (Oder) for a Brandenburg federation", got)
+	}
+	if calls := len(rec.recorded()); calls != 0 {
+		t.Errorf("made %d geocoding requests, want 0", calls)
+	}
+}
+
+// TestAmbiguousGazetteerHitFallsThroughToNetwork covers a name that exists
+// twice in the same state: guessing would pin half of them wrongly.
+func TestAmbiguousGazetteerHitFallsThroughToNetwork(t *testing.T) {
+	initTestCache(t)
+	useGazetteer(t, `{"places":[
+		{"name":"Neustadt bei Coburg","state":"Bayern","lat":"50.330","lon":"11.120"},
+		{"name":"Neustadt an der Donau","state":"Bayern","lat":"48.800","lon":"11.770"}
+	]}`)
+
+	rec := newRecordingServer(t, map[string][]models.Geocoordinates{
+		"Neustadt": {{
+			Lat: "48.80", Lon: "11.77",
+			DisplayName: "Neustadt an der Donau, Kelheim, Bayern, Deutschland",
+			Address:     models.Address{State: "Bayern", Town: "Neustadt an der Donau"},
+		}},
+	})
+
+	fed := models.Federation{Id: "BTV", State: "Bayern"}
+	got := GetGeocoordinatesForFederation(context.Background(), fed, models.Tournament{
+		Id: "1", Location: "Neustadt",
+	})
+
+	if got.Lat != "48.80" {
+		t.Errorf("got %+v, want the Nominatim result", got)
+	}
+	if calls := len(rec.recorded()); calls == 0 {
+		t.Error("made no geocoding requests, want the ambiguous name to go to Nominatim")
+	}
+}
+
+// TestPinRecordsItsSource checks that a pin remembers which place name it was
+// resolved from, including when it is served from the cache later.
+func TestPinRecordsItsSource(t *testing.T) {
+	initTestCache(t)
+
+	newRecordingServer(t, map[string][]models.Geocoordinates{
+		"Heidelberg": {{
+			Lat: "49.41", Lon: "8.69",
+			DisplayName: "Heidelberg, Baden-Württemberg, Deutschland",
+			Address:     models.Address{State: "Baden-Württemberg", City: "Heidelberg"},
+		}},
+	})
+
+	fed := models.Federation{Id: "BAD", State: "Baden-Württemberg"}
+	tournament := models.Tournament{Id: "1", Organizer: "Heidelberger Tennis-Club 1890 e.V."}
+
+	got := GetGeocoordinatesForFederation(context.Background(), fed, tournament)
+	if got.Address.Source != addressprovider.SourceOrganizerDerived {
+		t.Errorf("Address.Source = %q, want %q", got.Address.Source, addressprovider.SourceOrganizerDerived)
+	}
+
+	cached := GetGeocoordinatesForFederation(context.Background(), fed, tournament)
+	if cached.Address.Source != got.Address.Source {
+		t.Errorf("cached Address.Source = %q, want %q", cached.Address.Source, got.Address.Source)
+	}
+}
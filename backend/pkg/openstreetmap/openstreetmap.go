@@ -2,24 +2,18 @@ package openstreetmap
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/addressprovider"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/cache"
-	"github.com/timoknapp/tennis-tournament-finder/pkg/clublocations"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
-	"github.com/timoknapp/tennis-tournament-finder/pkg/placename"
-	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 )
 
 var CachedGeocoordinates map[string]models.Geocoordinates
@@ -34,56 +28,6 @@ var useMemoryCache bool
 // race (and can crash the process with "concurrent map writes").
 var cacheMu sync.RWMutex
 
-// defaultNominatimBaseURL is the public Nominatim search endpoint.
-const defaultNominatimBaseURL = "https://nominatim.openstreetmap.org/search.php"
-
-// maxGeocodingResponseBytes bounds how much of an upstream response is read.
-const maxGeocodingResponseBytes = 1 << 20 // 1 MiB
-
-// defaultNominatimInterval honours the Nominatim usage policy of at most one
-// request per second for the shared public instance.
-const defaultNominatimInterval = time.Second
-
-// maxGeocodeCandidates bounds how many place-name guesses are derived per
-// tournament.
-const maxGeocodeCandidates = 4
-
-// maxGeocodeRequests bounds the total upstream requests per tournament across
-// both lookup passes. Each request is rate limited, so this keeps the cost of
-// one tournament predictable.
-const maxGeocodeRequests = 6
-
-var (
-	geocodingLimiterOnce sync.Once
-	geocodingLimiter     *ratelimit.Limiter
-)
-
-// nominatimBaseURL returns the geocoding endpoint. It is configurable so the
-// service can be pointed at a self-hosted Nominatim instance, and so tests can
-// direct it at a local mock server.
-func nominatimBaseURL() string {
-	if raw := os.Getenv("TTF_NOMINATIM_URL"); raw != "" {
-		return raw
-	}
-	return defaultNominatimBaseURL
-}
-
-// geocodingRateLimiter returns the process-wide limiter for uncached geocoding
-// requests. The interval can be lowered for a self-hosted instance via
-// TTF_NOMINATIM_INTERVAL_MS.
-func geocodingRateLimiter() *ratelimit.Limiter {
-	geocodingLimiterOnce.Do(func() {
-		interval := defaultNominatimInterval
-		if raw := os.Getenv("TTF_NOMINATIM_INTERVAL_MS"); raw != "" {
-			if ms, err := strconv.Atoi(raw); err == nil && ms >= 0 {
-				interval = time.Duration(ms) * time.Millisecond
-			}
-		}
-		geocodingLimiter = ratelimit.New(interval)
-	})
-	return geocodingLimiter
-}
-
 func InitCache() {
 	// Initialize environment-based configuration
 	useMemoryCache = os.Getenv("TTF_CACHE_MEMORY") != "false" // default true
@@ -240,13 +184,42 @@ func generateOrganizerCacheKey(organizer, state string) string {
 	return fmt.Sprintf("org:%s:%s", normalized, state)
 }
 
-func GetGeocoordinatesFromCache(state string, tournament models.Tournament) models.Geocoordinates {
-	return GetGeocoordinatesForFederation(models.Federation{State: state}, tournament)
+func GetGeocoordinatesFromCache(ctx context.Context, state string, tournament models.Tournament) models.Geocoordinates {
+	return GetGeocoordinatesForFederation(ctx, models.Federation{State: state}, tournament)
 }
 
 // GetGeocoordinatesForFederation resolves a tournament's coordinates, accepting
-// results from any state the federation covers.
-func GetGeocoordinatesForFederation(fed models.Federation, tournament models.Tournament) models.Geocoordinates {
+// results from any state the federation covers. Lookups stop when ctx is
+// cancelled, including while waiting for the shared rate limiter.
+func GetGeocoordinatesForFederation(ctx context.Context, fed models.Federation, tournament models.Tournament) models.Geocoordinates {
+	cached, status := CachedGeocoordinatesForFederation(fed, tournament)
+	if status != CacheMiss {
+		return cached
+	}
+
+	logger.Debug("No Geocoordinate Cache entry found for (%s): '%s' at '%s'. Fetching data from server.",
+		tournament.Id, tournament.Organizer, tournament.Location)
+
+	return getGeocoordinatesForStates(ctx, fed.AcceptedStates(), tournament)
+}
+
+// CacheStatus is the outcome of a cache-only lookup.
+type CacheStatus int
+
+const (
+	// CacheMiss means a lookup is needed.
+	CacheMiss CacheStatus = iota
+	// CacheHit means the returned coordinates are usable.
+	CacheHit
+	// CacheBackoff means earlier lookups failed and none may be retried yet,
+	// so the caller should fall back to the federation's default.
+	CacheBackoff
+)
+
+// CachedGeocoordinatesForFederation answers from the cache only and never
+// contacts Nominatim. It lets the request path decide whether a lookup is
+// worth waiting for.
+func CachedGeocoordinatesForFederation(fed models.Federation, tournament models.Tournament) (models.Geocoordinates, CacheStatus) {
 	acceptedStates := fed.AcceptedStates()
 	primaryState := ""
 	if len(acceptedStates) > 0 {
@@ -269,7 +242,7 @@ func GetGeocoordinatesForFederation(fed models.Federation, tournament models.Tou
 
 		if cachedGeo.Lat != "" && cachedGeo.Lon != "" {
 			logger.Debug("Cache HIT: %s for tournament %s", key, tournament.Id)
-			return cachedGeo
+			return cachedGeo, CacheHit
 		}
 
 		knownEntries++
@@ -283,13 +256,10 @@ func GetGeocoordinatesForFederation(fed models.Federation, tournament models.Tou
 	// Every known key is in backoff: do not hit the upstream service again.
 	// The caller falls back to the federation's default coordinates.
 	if knownEntries > 0 && blockedEntries == knownEntries && blockedEntries == len(keys) {
-		return models.Geocoordinates{}
+		return models.Geocoordinates{}, CacheBackoff
 	}
 
-	logger.Debug("No Geocoordinate Cache entry found for (%s): '%s' at '%s'. Fetching data from server.",
-		tournament.Id, tournament.Organizer, tournament.Location)
-
-	return getGeocoordinatesForStates(acceptedStates, tournament)
+	return models.Geocoordinates{}, CacheMiss
 }
 
 // shouldRetryGeocodingRequest determines if a failed geocoding request should be retried
@@ -450,340 +420,68 @@ func CleanupOldFailedEntries() int {
 	return cleaned
 }
 
-// geocodeQuery describes one attempt to resolve a place.
-type geocodeQuery struct {
-	value  string // what to send to the geocoder
-	source string // where it came from, for logging
+func getGeocoordinates(ctx context.Context, state string, tournament models.Tournament) models.Geocoordinates {
+	return getGeocoordinatesForStates(ctx, []string{state}, tournament)
 }
 
-// buildGeocodeQueries returns the ordered list of place names to try for a
-// tournament.
-//
-// Order of precedence:
-//  1. a manual override for the organizer (highest confidence)
-//  2. the location published by the federation, when present
-//  3. candidates derived from the organizer's club name
-//
-// The caller stops at the first candidate that resolves inside an accepted
-// state, so more specific guesses must come first.
-func buildGeocodeQueries(tournament models.Tournament) ([]geocodeQuery, *clublocations.Override) {
-	var queries []geocodeQuery
-	seen := make(map[string]bool)
-
-	add := func(value, source string) {
-		value = strings.TrimSpace(value)
-		if value == "" || seen[strings.ToLower(value)] {
-			return
-		}
-		seen[strings.ToLower(value)] = true
-		queries = append(queries, geocodeQuery{value: value, source: source})
-	}
-
-	var override *clublocations.Override
-	if table, err := clublocations.Default(); err == nil && tournament.Organizer != "" {
-		if o, ok := table.Lookup(tournament.Organizer); ok {
-			override = &o
-			if o.City != "" {
-				add(o.City, "override")
-			}
-		}
-	}
-
-	// The published location is usually a plain city name and is trusted
-	// before anything derived from the club name.
-	if loc := strings.TrimSpace(tournament.Location); loc != "" {
-		add(loc, "location")
-		for _, c := range placename.Candidates(loc) {
-			add(c, "location-derived")
-		}
-	}
-
-	for _, c := range placename.Candidates(tournament.Organizer) {
-		add(c, "organizer-derived")
-	}
-
-	if len(queries) > maxGeocodeCandidates {
-		queries = queries[:maxGeocodeCandidates]
-	}
-
-	return queries, override
-}
-
-// matchesState reports whether a geocoding result belongs to one of the
-// accepted states.
-//
-// The structured address field is authoritative. DisplayName is only consulted
-// as a fallback for responses without address details.
-func matchesState(geo models.Geocoordinates, acceptedStates []string) bool {
-	if len(acceptedStates) == 0 {
-		return true // no restriction configured
-	}
-
-	for _, state := range acceptedStates {
-		if state == "" {
-			continue
-		}
-		if geo.Address.State != "" {
-			if strings.EqualFold(geo.Address.State, state) {
-				return true
-			}
-			continue
-		}
-		if strings.Contains(geo.DisplayName, state) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func getGeocoordinates(state string, tournament models.Tournament) models.Geocoordinates {
-	return getGeocoordinatesForStates([]string{state}, tournament)
-}
-
-// getGeocoordinatesForStates resolves a tournament's coordinates, trying each
-// candidate place name until one resolves inside an accepted state.
-func getGeocoordinatesForStates(acceptedStates []string, tournament models.Tournament) models.Geocoordinates {
+// getGeocoordinatesForStates resolves a tournament's coordinates through the
+// address provider chain and caches the outcome, success or failure. A lookup
+// cut short by ctx is not an outcome and is not cached.
+func getGeocoordinatesForStates(ctx context.Context, acceptedStates []string, tournament models.Tournament) models.Geocoordinates {
 	primaryState := ""
 	if len(acceptedStates) > 0 {
 		primaryState = acceptedStates[0]
 	}
 
-	queries, override := buildGeocodeQueries(tournament)
-
-	// An override may pin coordinates directly, which skips the network.
-	if override != nil && override.HasCoordinates() {
-		result := models.Geocoordinates{
-			Lat:         override.Lat,
-			Lon:         override.Lon,
-			DisplayName: override.City,
-		}
-		logger.Debug("Using pinned override coordinates for tournament %s (%s)",
-			tournament.Id, tournament.Organizer)
-		saveGeocoordinatesInCache(tournament, primaryState, result)
-		return result
-	}
-
-	if override != nil && override.State != "" {
-		// An override may also correct the expected state.
-		acceptedStates = append([]string{override.State}, acceptedStates...)
-	}
-
-	if len(queries) == 0 {
-		logger.Warn("No geocoding candidates for tournament %s (organizer %q, location %q)",
-			tournament.Id, tournament.Organizer, tournament.Location)
-		saveFailedGeocodingAttempt(primaryState, tournament)
-		return models.Geocoordinates{}
-	}
-
 	previousFailCount := previousFailCountFor(primaryState, tournament)
 
-	ctx, cancel := context.WithTimeout(context.Background(), httpclient.DefaultTimeout)
+	lookupCtx, cancel := context.WithTimeout(ctx, httpclient.DefaultTimeout)
 	defer cancel()
 
-	var lastErr error
-	// A result that matches the state but not the queried name exactly is kept
-	// as a fallback while better candidates are tried. Without this, a query
-	// like "Bremer" happily settles for the hamlet "Bremer Sand" instead of
-	// reaching the city of Bremen via the de-adjectived candidate.
-	var fallback *models.Geocoordinates
-	var fallbackQuery geocodeQuery
-
-	// Each request is rate limited, so the total number of upstream calls per
-	// tournament is capped regardless of how many candidates or passes exist.
-	budget := maxGeocodeRequests
-
-	// Two passes. The first restricts results to settlements, which is what a
-	// map pin should point at. Only if nothing resolves at all do we allow
-	// arbitrary features, so a club in a tiny hamlet still gets a location
-	// rather than the federation's default.
-passes:
-	for _, settlementsOnly := range []bool{true, false} {
-		for _, q := range queries {
-			if budget <= 0 {
-				break passes
-			}
-			budget--
-
-			results, err := queryNominatim(ctx, q.value, tournament.Id, settlementsOnly)
-			if err != nil {
-				lastErr = err
-				// A transport-level failure affects every candidate equally.
-				break passes
-			}
-
-			for _, candidate := range results {
-				if !matchesState(candidate, acceptedStates) {
-					continue
-				}
-
-				result := candidate
-				result.IsFailed = false
-				result.FailCount = 0
-				result.LastAttempt = 0
-
-				if placeMatchesQuery(result, q.value) {
-					logger.Debug("Geocoded tournament %s via %s query %q (settlements=%v) -> %s",
-						tournament.Id, q.source, q.value, settlementsOnly, result.DisplayName)
-					saveGeocoordinatesInCache(tournament, primaryState, result)
-					return result
-				}
-
-				if fallback == nil {
-					kept := result
-					fallback = &kept
-					fallbackQuery = q
-				}
-			}
-		}
-	}
-
-	// No exact name match anywhere: use the best inexact hit rather than
-	// falling back to the federation's default coordinates.
-	if fallback != nil {
-		logger.Debug("Geocoded tournament %s via %s query %q (inexact) -> %s",
-			tournament.Id, fallbackQuery.source, fallbackQuery.value, fallback.DisplayName)
-		saveGeocoordinatesInCache(tournament, primaryState, *fallback)
-		return *fallback
-	}
-
-	if lastErr != nil {
-		logger.Error("Geocoding failed for tournament %s: %v", tournament.Id, lastErr)
-	} else {
-		logger.Warn("No suitable geocoordinates for tournament %s (organizer %q) in %v; tried %d candidates",
-			tournament.Id, tournament.Organizer, acceptedStates, len(queries))
+	addr, err := addressprovider.Default().Resolve(lookupCtx, addressprovider.Request{
+		TournamentID:   tournament.Id,
+		Organizer:      tournament.Organizer,
+		Location:       tournament.Location,
+		AcceptedStates: acceptedStates,
+	})
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The caller gave up. Recording a failure would put a club that never
+		// got its lookup into backoff.
+		logger.Debug("Geocoding for tournament %s cancelled: %v", tournament.Id, ctx.Err())
+		return models.Geocoordinates{}
+	case err == nil:
+		result := pinFromAddress(addr)
+		logger.Debug("Geocoded tournament %s from %s -> %s (%s,%s)",
+			tournament.Id, addr.Source, result.DisplayName, result.Lat, result.Lon)
+		saveGeocoordinatesInCache(tournament, primaryState, result)
+		return result
+	case errors.Is(err, addressprovider.ErrNotFound):
+		logger.Warn("No suitable geocoordinates for tournament %s (organizer %q, location %q) in %v",
+			tournament.Id, tournament.Organizer, tournament.Location, acceptedStates)
+	default:
+		logger.Error("Geocoding failed for tournament %s: %v", tournament.Id, err)
 	}
 
 	saveFailedGeocodingAttemptWithCount(primaryState, tournament, previousFailCount)
 	return models.Geocoordinates{}
 }
 
-// placeMatchesQuery reports whether a geocoding result actually names the
-// place that was asked for, rather than merely containing it.
-//
-// Nominatim readily returns "Bremer Sand" for "Bremer" or "Ratinger Straße"
-// for "Ratinger". Accepting those produces a pin in the wrong town.
-func placeMatchesQuery(geo models.Geocoordinates, query string) bool {
-	place := geo.Address.Place()
-	if place == "" {
-		return false
-	}
-
-	normPlace := normalizePlaceName(place)
-	normQuery := normalizePlaceName(query)
-	if normPlace == "" || normQuery == "" {
-		return false
-	}
-
-	if normPlace == normQuery {
-		return true
-	}
-
-	// Accept official long forms of the same place: "Bad Homburg" ->
-	// "Bad Homburg vor der Höhe", "Frankfurt" -> "Frankfurt am Main".
-	//
-	// Such suffixes are geographic qualifiers that begin with a lowercase
-	// preposition. Requiring that is what keeps "Bremer" from matching the
-	// hamlet "Bremer Sand", whose suffix is a capitalized noun.
-	rest, ok := strings.CutPrefix(normPlace, normQuery+" ")
-	if !ok {
-		return false
-	}
-
-	first, _, _ := strings.Cut(rest, " ")
-	return placeQualifiers[first]
-}
-
-// placeQualifiers introduce the geographic suffix of an official German place
-// name ("Frankfurt am Main", "Bad Homburg vor der Höhe").
-var placeQualifiers = map[string]bool{
-	"am": true, "an": true, "im": true, "in": true, "vor": true,
-	"bei": true, "auf": true, "ob": true, "unter": true, "a": true,
-	"i": true, "v": true,
-}
-
-// normalizePlaceName lowercases and collapses whitespace for comparison.
-func normalizePlaceName(s string) string {
-	return strings.ToLower(strings.Join(strings.Fields(s), " "))
-}
-
-// queryNominatim performs one geocoding request and returns the parsed results.
-func queryNominatim(ctx context.Context, query, tournamentId string, settlementsOnly bool) ([]models.Geocoordinates, error) {
-	// Honour the Nominatim usage policy: at most one request per second.
-	// Cache hits never reach this point, so cached lookups do not consume
-	// rate-limit capacity.
-	if err := geocodingRateLimiter().Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limiter aborted: %w", err)
+// pinFromAddress turns a resolved address into the cached pin. The structured
+// address keeps what the geocoder reported and records the Source.
+func pinFromAddress(addr addressprovider.Address) models.Geocoordinates {
+	details := addr.Details
+	if details.State == "" {
+		details.State = addr.State
 	}
+	details.Source = addr.Source
 
-	reqURL, err := buildNominatimURL(query, settlementsOnly)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	return models.Geocoordinates{
+		Lat:         addr.Lat,
+		Lon:         addr.Lon,
+		DisplayName: addr.DisplayName,
+		Address:     details,
 	}
-	// Nominatim requires an identifying User-Agent.
-	httpclient.ApplyDefaultHeaders(req)
-	req.Header.Set("Accept", "application/json")
-
-	res, err := httpclient.Geocoding().Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
-			logger.Warn("Geocoding throttled for tournament %s: status %d, Retry-After: %s",
-				tournamentId, res.StatusCode, retryAfter)
-		}
-		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxGeocodingResponseBytes))
-		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
-	}
-
-	body, err := io.ReadAll(io.LimitReader(res.Body, maxGeocodingResponseBytes))
-	if err != nil {
-		return nil, fmt.Errorf("read error: %w", err)
-	}
-
-	var geoCoords []models.Geocoordinates
-	if err := json.Unmarshal(body, &geoCoords); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return geoCoords, nil
-}
-
-// buildNominatimURL assembles the geocoding request URL with proper encoding.
-//
-// When settlementsOnly is set, Nominatim is restricted to cities, towns,
-// villages and hamlets. Without that restriction a query like "Ratinger"
-// happily matches "Ratinger Straße" in a completely different city, which is a
-// major source of wrong map pins.
-func buildNominatimURL(query string, settlementsOnly bool) (string, error) {
-	u, err := url.Parse(nominatimBaseURL())
-	if err != nil {
-		return "", fmt.Errorf("invalid Nominatim base URL: %w", err)
-	}
-
-	q := u.Query()
-	q.Set("limit", "5")
-	q.Set("accept-language", "de")
-	q.Set("format", "jsonv2")
-	// Structured address details let us verify the state reliably instead of
-	// substring-matching the display name.
-	q.Set("addressdetails", "1")
-	q.Set("countrycodes", "de")
-	if settlementsOnly {
-		q.Set("featureType", "settlement")
-	}
-	q.Set("q", query)
-	u.RawQuery = q.Encode()
-
-	return u.String(), nil
 }
 
 // previousFailCountFor returns the highest recorded failure count across the
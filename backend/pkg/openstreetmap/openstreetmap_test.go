@@ -1,6 +1,7 @@
 package openstreetmap
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,6 +24,11 @@ func TestMain(m *testing.M) {
 		logger.SetLogLevel(logger.ErrorLevel)
 	}
 
+	// The mocked Nominatim answers for cities the gazetteer also knows.
+	// Disable it so these tests keep exercising the network path; the
+	// gazetteer has tests of its own.
+	os.Setenv("TTF_GAZETTEER", "off")
+
 	os.Exit(m.Run())
 }
 
@@ -101,7 +107,7 @@ func TestGeocodingSendsIdentifyingUserAgent(t *testing.T) {
 		Lat: "49.0069", Lon: "8.4037", DisplayName: "Karlsruhe, Baden-Württemberg, Deutschland",
 	}))
 
-	got := GetGeocoordinatesFromCache("Baden-Württemberg", models.Tournament{
+	got := GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", models.Tournament{
 		Id: "1", Location: "Karlsruhe", Organizer: "TC Karlsruhe",
 	})
 
@@ -134,7 +140,7 @@ func TestGeocodingUserAgentIsConfigurable(t *testing.T) {
 		Lat: "1", Lon: "2", DisplayName: "Hessen",
 	}))
 
-	GetGeocoordinatesFromCache("Hessen", models.Tournament{Id: "1", Location: "Frankfurt"})
+	GetGeocoordinatesFromCache(context.Background(), "Hessen", models.Tournament{Id: "1", Location: "Frankfurt"})
 
 	if got := mock.lastUserAgent(); got != "MyFork/2.0 (+https://example.test/contact)" {
 		t.Errorf("User-Agent = %q, want the configured override", got)
@@ -155,7 +161,7 @@ func TestGeocodingRespectsRateLimit(t *testing.T) {
 	start := time.Now()
 	for i := 0; i < requests; i++ {
 		// Distinct locations so every call misses the cache.
-		GetGeocoordinatesFromCache("Bayern", models.Tournament{
+		GetGeocoordinatesFromCache(context.Background(), "Bayern", models.Tournament{
 			Id: fmt.Sprint(i), Location: fmt.Sprintf("Stadt-%d", i),
 		})
 	}
@@ -175,6 +181,52 @@ func TestGeocodingRespectsRateLimit(t *testing.T) {
 	}
 }
 
+// TestCancelledLookupIsNotCached covers a client that disconnects while its
+// lookup waits behind the shared limiter: the wait ends with the request, and
+// the club is not put into backoff for a lookup that never happened.
+func TestCancelledLookupIsNotCached(t *testing.T) {
+	initTestCache(t)
+	t.Setenv("TTF_NOMINATIM_INTERVAL_MS", "10000")
+	resetRateLimiterForTest()
+	t.Cleanup(func() {
+		os.Setenv("TTF_NOMINATIM_INTERVAL_MS", "0")
+		resetRateLimiterForTest()
+	})
+
+	mock := newMockGeocodingServer(t, respondWith(models.Geocoordinates{
+		Lat: "49.0069", Lon: "8.4037", DisplayName: "Karlsruhe, Baden-Württemberg",
+		Address: models.Address{City: "Karlsruhe", State: "Baden-Württemberg"},
+	}))
+	fed := models.Federation{State: "Baden-Württemberg"}
+
+	// Takes the limiter's only slot for the next ten seconds.
+	if got := GetGeocoordinatesForFederation(context.Background(), fed,
+		models.Tournament{Id: "1", Location: "Karlsruhe"}); got.Lat == "" {
+		t.Fatal("first lookup did not resolve")
+	}
+	before := mock.callCount()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	waiting := models.Tournament{Id: "2", Location: "Ettlingen"}
+	start := time.Now()
+	got := GetGeocoordinatesForFederation(ctx, fed, waiting)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled lookup took %v, want it to stop with the context", elapsed)
+	}
+	if got.Lat != "" {
+		t.Errorf("got %+v, want no coordinates", got)
+	}
+	if calls := mock.callCount(); calls != before {
+		t.Errorf("made %d upstream requests after cancellation, want none", calls-before)
+	}
+	if _, status := CachedGeocoordinatesForFederation(fed, waiting); status != CacheMiss {
+		t.Errorf("cache status = %v, want a miss so the club is looked up next time", status)
+	}
+}
+
 func TestGeocodingCacheHitsSkipUpstreamAndRateLimit(t *testing.T) {
 	initTestCache(t)
 
@@ -186,15 +238,15 @@ func TestGeocodingCacheHitsSkipUpstreamAndRateLimit(t *testing.T) {
 	// A different tournament at the same location must reuse the cached entry.
 	tournamentB := models.Tournament{Id: "2", Location: "Karlsruhe", Organizer: "TC Karlsruhe"}
 
-	GetGeocoordinatesFromCache("Baden-Württemberg", tournamentA)
+	GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournamentA)
 	afterFirst := mock.callCount()
 	if afterFirst == 0 {
 		t.Fatal("no upstream request was made for the first lookup")
 	}
 
 	for i := 0; i < 5; i++ {
-		GetGeocoordinatesFromCache("Baden-Württemberg", tournamentA)
-		GetGeocoordinatesFromCache("Baden-Württemberg", tournamentB)
+		GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournamentA)
+		GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournamentB)
 	}
 
 	if got := mock.callCount(); got != afterFirst {
@@ -219,7 +271,7 @@ func TestFailedLookupsUseTheSameCacheKeys(t *testing.T) {
 	// place-name candidates are derived from the location and organizer, so
 	// more than one upstream request is expected here; what matters is that
 	// the attempt is recorded under the shared cache keys.
-	if got := GetGeocoordinatesFromCache("Baden-Württemberg", tournament); got.Lat != "" {
+	if got := GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournament); got.Lat != "" {
 		t.Errorf("expected no coordinates, got %+v", got)
 	}
 	firstRound := mock.callCount()
@@ -239,7 +291,7 @@ func TestFailedLookupsUseTheSameCacheKeys(t *testing.T) {
 
 	// Subsequent attempts must be suppressed entirely by the backoff.
 	for i := 0; i < 3; i++ {
-		GetGeocoordinatesFromCache("Baden-Württemberg", tournament)
+		GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournament)
 	}
 	if got := mock.callCount(); got != firstRound {
 		t.Errorf("made %d requests total, want %d (backoff not honoured)", got, firstRound)
@@ -247,7 +299,7 @@ func TestFailedLookupsUseTheSameCacheKeys(t *testing.T) {
 
 	// A different tournament at the same location must also be suppressed.
 	other := models.Tournament{Id: "999", Location: "Unbekanntstadt", Organizer: "TC Unbekannt"}
-	GetGeocoordinatesFromCache("Baden-Württemberg", other)
+	GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", other)
 	if got := mock.callCount(); got != firstRound {
 		t.Errorf("made %d requests, want %d (per-location backoff not shared)", got, firstRound)
 	}
@@ -266,7 +318,7 @@ func TestFailureCountIncrementsAcrossRetries(t *testing.T) {
 	var perRound int64
 	for attempt := 1; attempt <= 3; attempt++ {
 		before := mock.callCount()
-		GetGeocoordinatesFromCache("Baden-Württemberg", tournament)
+		GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournament)
 		if attempt == 1 {
 			perRound = mock.callCount() - before
 		}
@@ -317,7 +369,7 @@ func TestSuccessAfterFailureClearsFailureState(t *testing.T) {
 	locKey := generateLocationCacheKey(tournament.Location, "Baden-Württemberg")
 
 	// First attempt fails.
-	GetGeocoordinatesFromCache("Baden-Württemberg", tournament)
+	GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournament)
 	cached, _ := getFromCache(locKey)
 	if !cached.IsFailed {
 		t.Fatal("first attempt was not recorded as failed")
@@ -328,7 +380,7 @@ func TestSuccessAfterFailureClearsFailureState(t *testing.T) {
 	setInCache(locKey, cached)
 	succeed.Store(true)
 
-	got := GetGeocoordinatesFromCache("Baden-Württemberg", tournament)
+	got := GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", tournament)
 	if got.Lat != "49.0069" {
 		t.Fatalf("retry returned %+v, want successful coordinates", got)
 	}
@@ -393,7 +445,7 @@ func TestGeocodingHandlesUpstreamErrors(t *testing.T) {
 			newMockGeocodingServer(t, tt.handler)
 
 			// Must not panic and must report "no coordinates".
-			got := GetGeocoordinatesFromCache("Baden-Württemberg", models.Tournament{
+			got := GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", models.Tournament{
 				Id: "1", Location: "Karlsruhe", Organizer: "TC Karlsruhe",
 			})
 
@@ -420,7 +472,7 @@ func TestGeocodingRejectsWrongState(t *testing.T) {
 		models.Geocoordinates{Lat: "49.0", Lon: "8.4", DisplayName: "Karlsruhe, Baden-Württemberg, Deutschland"},
 	))
 
-	got := GetGeocoordinatesFromCache("Baden-Württemberg", models.Tournament{
+	got := GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", models.Tournament{
 		Id: "1", Location: "Karlsruhe",
 	})
 
@@ -435,7 +487,7 @@ func TestGeocodingSkipsRequestWithoutQuery(t *testing.T) {
 	mock := newMockGeocodingServer(t, respondWith())
 
 	// Neither a location nor an organizer: nothing sensible to ask for.
-	got := GetGeocoordinatesFromCache("Hessen", models.Tournament{Id: "1"})
+	got := GetGeocoordinatesFromCache(context.Background(), "Hessen", models.Tournament{Id: "1"})
 
 	if got.Lat != "" {
 		t.Errorf("got %+v, want empty", got)
@@ -453,7 +505,7 @@ func TestGeocodingFallsBackToOrganizerQuery(t *testing.T) {
 	}))
 
 	// No location, so the city is derived from the organizer name.
-	GetGeocoordinatesFromCache("Baden-Württemberg", models.Tournament{
+	GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", models.Tournament{
 		Id: "1", Organizer: "TC Rot-Weiß Karlsruhe e.V.",
 	})
 
@@ -487,7 +539,7 @@ func TestConcurrentGeocodingIsRaceFree(t *testing.T) {
 		go func(i int) {
 			defer wg.Done()
 			for j := 0; j < 8; j++ {
-				GetGeocoordinatesFromCache("Baden-Württemberg", models.Tournament{
+				GetGeocoordinatesFromCache(context.Background(), "Baden-Württemberg", models.Tournament{
 					Id:        fmt.Sprintf("%d-%d", i, j),
 					Location:  fmt.Sprintf("Ort-%d", (i+j)%5),
 					Organizer: fmt.Sprintf("TC %d", i%3),
@@ -504,42 +556,6 @@ func TestConcurrentGeocodingIsRaceFree(t *testing.T) {
 	}
 }
 
-func TestBuildNominatimURL(t *testing.T) {
-	t.Setenv("TTF_NOMINATIM_URL", "https://nominatim.example.test/search.php")
-
-	raw, err := buildNominatimURL("Bad Homburg vor der Höhe", true)
-	if err != nil {
-		t.Fatalf("buildNominatimURL() error = %v", err)
-	}
-
-	if !strings.HasPrefix(raw, "https://nominatim.example.test/search.php?") {
-		t.Errorf("URL = %q, want the configured base", raw)
-	}
-	// Spaces and umlauts must be percent-encoded, not naively replaced.
-	for _, want := range []string{"format=jsonv2", "limit=5", "addressdetails=1", "accept-language=de", "q=Bad+Homburg+vor+der+H%C3%B6he"} {
-		if !strings.Contains(raw, want) {
-			t.Errorf("URL %q does not contain %q", raw, want)
-		}
-	}
-
-	if !strings.Contains(raw, "featureType=settlement") {
-		t.Errorf("URL %q does not restrict results to settlements", raw)
-	}
-
-	permissive, err := buildNominatimURL("Kleinort", false)
-	if err != nil {
-		t.Fatalf("buildNominatimURL(permissive) error = %v", err)
-	}
-	if strings.Contains(permissive, "featureType") {
-		t.Errorf("permissive URL %q should not restrict the feature type", permissive)
-	}
-
-	t.Setenv("TTF_NOMINATIM_URL", "://broken")
-	if _, err := buildNominatimURL("x", true); err == nil {
-		t.Error("expected an error for an invalid base URL")
-	}
-}
-
 func TestShouldRetryGeocodingRequestBackoff(t *testing.T) {
 	now := time.Now().Unix()
 
@@ -6,33 +6,104 @@ import (
 	"time"
 )
 
+// Priority orders callers competing for the limiter. Lower values go first.
+type Priority int
+
+const (
+	// PriorityInteractive is for lookups a user is waiting on. It is the
+	// default for a context without a priority.
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for warmup and maintenance lookups, which only
+	// get a slot when no interactive caller is waiting.
+	PriorityBackground
+
+	numPriorities = int(PriorityBackground) + 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority marks every limited operation performed under ctx with p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority attached to ctx, or PriorityInteractive.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && int(p) < numPriorities {
+		return p
+	}
+	return PriorityInteractive
+}
+
 // Limiter enforces a minimum interval between successive operations across all
 // goroutines. It is used to honour the Nominatim usage policy, which permits at
 // most one request per second for the public endpoint.
+//
+// Callers queue in lanes by priority. The next slot goes to the longest
+// waiting caller of the most urgent non-empty lane, so a user request needing
+// one lookup does not wait behind a warmup needing hundreds.
 type Limiter struct {
 	mu       sync.Mutex
 	interval time.Duration
 	last     time.Time
 
+	lanes [numPriorities][]*waiter
+	// changed is closed and replaced whenever the head of the queue may have
+	// changed, waking the callers waiting for their turn.
+	changed chan struct{}
+	stats   [numPriorities]laneCounters
+
 	// now and sleep are injectable for deterministic tests.
 	now   func() time.Time
 	sleep func(context.Context, time.Duration) error
 }
 
+type waiter struct {
+	priority Priority
+	since    time.Time
+}
+
+type laneCounters struct {
+	granted   int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// LaneStats describes one priority lane.
+type LaneStats struct {
+	Priority string `json:"priority"`
+	// Waiting is the number of callers currently queued.
+	Waiting int `json:"waiting"`
+	// Granted counts slots handed out since start.
+	Granted   int64   `json:"granted"`
+	AvgWaitMs float64 `json:"avg_wait_ms"`
+	MaxWaitMs int64   `json:"max_wait_ms"`
+}
+
 // New creates a Limiter allowing one operation per interval.
 // A non-positive interval disables rate limiting.
 func New(interval time.Duration) *Limiter {
 	return &Limiter{
 		interval: interval,
+		changed:  make(chan struct{}),
 		now:      time.Now,
 		sleep:    sleepContext,
 	}
 }
 
-// Wait blocks until the caller may proceed or ctx is done.
-//
-// The reservation is made while holding the lock, so concurrent callers are
-// spaced out by interval instead of all waking up at the same time.
+// Wait blocks until the caller may proceed or ctx is done. The caller's lane
+// is taken from ctx; see WithPriority.
 func (l *Limiter) Wait(ctx context.Context) error {
 	if l == nil || l.interval <= 0 {
 		return ctx.Err()
@@ -43,27 +114,114 @@ func (l *Limiter) Wait(ctx context.Context) error {
 	}
 
 	l.mu.Lock()
-	now := l.now()
-	var wait time.Duration
-	if l.last.IsZero() {
-		// First call may proceed immediately.
-		l.last = now
-	} else {
-		next := l.last.Add(l.interval)
-		if next.After(now) {
-			wait = next.Sub(now)
-			l.last = next
-		} else {
+	w := &waiter{priority: PriorityFrom(ctx), since: l.now()}
+	l.lanes[w.priority] = append(l.lanes[w.priority], w)
+
+	for {
+		if l.head() != w {
+			// Not our turn: wait until the queue moves.
+			changed := l.changed
+			l.mu.Unlock()
+
+			select {
+			case <-changed:
+				l.mu.Lock()
+				continue
+			case <-ctx.Done():
+				l.mu.Lock()
+				l.remove(w)
+				l.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+
+		now := l.now()
+		var wait time.Duration
+		if !l.last.IsZero() {
+			wait = l.last.Add(l.interval).Sub(now)
+		}
+
+		if wait <= 0 {
+			// First call, or the interval has elapsed.
 			l.last = now
+			l.remove(w)
+			l.record(w, now)
+			l.mu.Unlock()
+			return nil
+		}
+
+		// Sleep until the slot opens, then check again: a more urgent caller
+		// may have arrived in the meantime and takes the slot instead.
+		l.mu.Unlock()
+		if err := l.sleep(ctx, wait); err != nil {
+			l.mu.Lock()
+			l.remove(w)
+			l.mu.Unlock()
+			return err
 		}
+		l.mu.Lock()
 	}
-	l.mu.Unlock()
+}
 
-	if wait <= 0 {
+// Stats reports each lane's queue depth and waiting times.
+func (l *Limiter) Stats() []LaneStats {
+	if l == nil {
 		return nil
 	}
 
-	return l.sleep(ctx, wait)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]LaneStats, 0, numPriorities)
+	for p := 0; p < numPriorities; p++ {
+		c := l.stats[p]
+		s := LaneStats{
+			Priority:  Priority(p).String(),
+			Waiting:   len(l.lanes[p]),
+			Granted:   c.granted,
+			MaxWaitMs: c.maxWait.Milliseconds(),
+		}
+		if c.granted > 0 {
+			s.AvgWaitMs = float64(c.totalWait.Microseconds()) / float64(c.granted) / 1000
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// head returns the caller entitled to the next slot. Callers hold l.mu.
+func (l *Limiter) head() *waiter {
+	for p := range l.lanes {
+		if len(l.lanes[p]) > 0 {
+			return l.lanes[p][0]
+		}
+	}
+	return nil
+}
+
+// remove takes w out of its lane and wakes the other waiters. Callers hold
+// l.mu.
+func (l *Limiter) remove(w *waiter) {
+	lane := l.lanes[w.priority]
+	for i, other := range lane {
+		if other == w {
+			l.lanes[w.priority] = append(lane[:i:i], lane[i+1:]...)
+			break
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// record adds a granted slot to the lane's statistics. Callers hold l.mu.
+func (l *Limiter) record(w *waiter, grantedAt time.Time) {
+	waited := grantedAt.Sub(w.since)
+	c := &l.stats[w.priority]
+	c.granted++
+	c.totalWait += waited
+	if waited > c.maxWait {
+		c.maxWait = waited
+	}
 }
 
 func sleepContext(ctx context.Context, d time.Duration) error {
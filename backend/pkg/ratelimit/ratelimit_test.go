@@ -174,3 +174,175 @@ func TestConcurrentCallersAreSerialized(t *testing.T) {
 		t.Errorf("got %d sleeps, want %d", got, callers-1)
 	}
 }
+
+// gatedClock blocks sleepers until the test advances time, so the order in
+// which queued callers are served can be observed.
+type gatedClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers int
+	release  chan struct{}
+}
+
+func newGatedLimiter(interval time.Duration) (*Limiter, *gatedClock) {
+	clock := &gatedClock{now: time.Unix(1700000000, 0), release: make(chan struct{})}
+	l := New(interval)
+	l.now = clock.Now
+	l.sleep = clock.Sleep
+	return l, clock
+}
+
+func (c *gatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *gatedClock) Sleep(ctx context.Context, _ time.Duration) error {
+	c.mu.Lock()
+	c.sleepers++
+	release := c.release
+	c.mu.Unlock()
+
+	select {
+	case <-release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// advance moves time forward and wakes every sleeper.
+func (c *gatedClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	close(c.release)
+	c.release = make(chan struct{})
+}
+
+func (c *gatedClock) sleeping() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sleepers
+}
+
+// eventually polls cond until it holds, failing the test after a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waiting(l *Limiter, p Priority) int {
+	return l.Stats()[p].Waiting
+}
+
+func TestInteractiveCallersJumpAheadOfBackground(t *testing.T) {
+	l, clock := newGatedLimiter(time.Second)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan string, 3)
+	start := func(name string, p Priority) {
+		go func() {
+			if err := l.Wait(WithPriority(context.Background(), p)); err != nil {
+				t.Errorf("Wait(%s) error = %v", name, err)
+			}
+			order <- name
+		}()
+	}
+
+	// Two warmup lookups queue first; the first is already sleeping towards
+	// the next slot when the user's lookup arrives.
+	start("warmup-1", PriorityBackground)
+	eventually(t, "warmup-1 to sleep", func() bool { return clock.sleeping() == 1 })
+	start("warmup-2", PriorityBackground)
+	eventually(t, "warmup-2 to queue", func() bool { return waiting(l, PriorityBackground) == 2 })
+	start("user", PriorityInteractive)
+	eventually(t, "user to sleep", func() bool { return clock.sleeping() == 2 })
+
+	var got []string
+	for sleepers := 3; len(got) < 3; sleepers++ {
+		clock.advance(time.Second)
+		got = append(got, <-order)
+		if len(got) < 3 {
+			eventually(t, "next caller to sleep", func() bool { return clock.sleeping() >= sleepers })
+		}
+	}
+
+	want := []string{"user", "warmup-1", "warmup-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("served %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCancelledWaiterLeavesTheQueue(t *testing.T) {
+	l, clock := newGatedLimiter(time.Second)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The head sleeps; the second caller waits behind it and gives up.
+	go l.Wait(WithPriority(context.Background(), PriorityBackground))
+	eventually(t, "head to sleep", func() bool { return clock.sleeping() == 1 })
+
+	ctx, cancel := context.WithCancel(WithPriority(context.Background(), PriorityBackground))
+	done := make(chan error)
+	go func() { done <- l.Wait(ctx) }()
+	eventually(t, "second caller to queue", func() bool { return waiting(l, PriorityBackground) == 2 })
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("Wait() returned nil after cancellation")
+	}
+	if got := waiting(l, PriorityBackground); got != 1 {
+		t.Errorf("Waiting = %d after cancellation, want 1", got)
+	}
+	clock.advance(time.Second)
+}
+
+func TestStatsReportWaitTimePerLane(t *testing.T) {
+	l, _ := newTestLimiter(time.Second)
+	background := WithPriority(context.Background(), PriorityBackground)
+
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(background); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := l.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("got %d lanes, want 2", len(stats))
+	}
+	bg := stats[PriorityBackground]
+	if bg.Priority != "background" || bg.Granted != 3 || bg.Waiting != 0 {
+		t.Errorf("background lane = %+v, want 3 granted and none waiting", bg)
+	}
+	// The first slot is immediate, the others wait a full interval each.
+	if bg.MaxWaitMs != 1000 {
+		t.Errorf("MaxWaitMs = %d, want 1000", bg.MaxWaitMs)
+	}
+	if stats[PriorityInteractive].Granted != 0 {
+		t.Errorf("interactive lane = %+v, want unused", stats[PriorityInteractive])
+	}
+}
+
+func TestPriorityDefaultsToInteractive(t *testing.T) {
+	if got := PriorityFrom(context.Background()); got != PriorityInteractive {
+		t.Errorf("PriorityFrom(background ctx) = %v, want interactive", got)
+	}
+	ctx := WithPriority(context.Background(), PriorityBackground)
+	if got := PriorityFrom(ctx); got != PriorityBackground {
+		t.Errorf("PriorityFrom() = %v, want background", got)
+	}
+}
@@ -0,0 +1,242 @@
+package resultcache
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// Every entry is one fetched window of one federation: a segment of that
+// federation's calendar. A query is answered from any set of fresh segments
+// that together cover its window, so the scheduler's 30-day warmup serves the
+// frontend's 14-day default, a window starting tomorrow, or a single weekend,
+// without a live scrape.
+//
+// Segments are composed rather than merged into one per-federation list
+// because each has its own age: a request spanning a fresh and an older
+// segment reports both.
+
+// Segment describes one cached fetch window contributing to a result.
+type Segment struct {
+	DateFrom string
+	DateTo   string
+	Age      time.Duration
+}
+
+// ParseKey reverses Key.String.
+func ParseKey(s string) (Key, bool) {
+	parts := strings.Split(s, "|")
+	if len(parts) != 4 {
+		return Key{}, false
+	}
+	return Key{FederationID: parts[0], DateFrom: parts[1], DateTo: parts[2], CompType: parts[3]}, true
+}
+
+// window returns the key's date range. ok is false when either end does not
+// parse, in which case the key is only ever matched exactly.
+func (k Key) window() (from, to time.Time, ok bool) {
+	from, err := daterange.ParseDay(k.DateFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	to, err = daterange.ParseDay(k.DateTo)
+	if err != nil || to.Before(from) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// segmentPrefix selects the entries of one federation in the store.
+func segmentPrefix(federationID string) string {
+	return federationID + "|"
+}
+
+// span is a stored entry that may contribute to a query.
+type span struct {
+	key      Key
+	from, to time.Time
+	entry    Entry
+	age      time.Duration
+}
+
+// spansFor lists the entries sharing key's federation and competition type
+// whose window overlaps [from, to] and which are younger than maxAge. Entries
+// marked invalidated are only accepted when allowInvalidated is set.
+func (c *Cache) spansFor(key Key, from, to time.Time, maxAge time.Duration, allowInvalidated bool) ([]span, error) {
+	now := c.now()
+	var spans []span
+
+	err := c.store.ForEachPrefix(segmentPrefix(key.FederationID), func(storeKey string, entry Entry) error {
+		k, ok := ParseKey(storeKey)
+		if !ok || k.FederationID != key.FederationID || k.CompType != key.CompType {
+			return nil
+		}
+		if entry.Tournaments == nil || (entry.Invalidated && !allowInvalidated) {
+			return nil
+		}
+		age := entry.Age(now)
+		if age >= maxAge {
+			return nil
+		}
+		f, t, ok := k.window()
+		if !ok || f.After(to) || t.Before(from) {
+			return nil
+		}
+		spans = append(spans, span{key: k, from: f, to: t, entry: entry, age: age})
+		return nil
+	})
+
+	return spans, err
+}
+
+// cover picks segments that together cover [from, to], preferring few
+// segments and, among equally long ones, the freshest. It returns false when
+// a day of the window is missing.
+func cover(spans []span, from, to time.Time) ([]span, bool) {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from.Before(spans[j].from) })
+
+	var chosen []span
+	cursor := from
+	for !cursor.After(to) {
+		best := -1
+		for i, s := range spans {
+			if s.from.After(cursor) || s.to.Before(cursor) {
+				continue
+			}
+			if best < 0 || s.to.After(spans[best].to) ||
+				(s.to.Equal(spans[best].to) && s.age < spans[best].age) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+		chosen = append(chosen, spans[best])
+		cursor = spans[best].to.AddDate(0, 0, 1)
+	}
+	return chosen, true
+}
+
+// compose answers key from stored segments no older than maxAge. The returned
+// tournaments are those of the chosen segments that overlap key's window, each
+// once; an undated tournament is kept, as the upstream filter keeps it.
+func (c *Cache) compose(key Key, maxAge time.Duration, allowInvalidated bool) (Result, bool) {
+	from, to, ok := key.window()
+	if !ok {
+		return Result{}, false
+	}
+
+	spans, err := c.spansFor(key, from, to, maxAge, allowInvalidated)
+	if err != nil || len(spans) == 0 {
+		return Result{}, false
+	}
+	chosen, ok := cover(spans, from, to)
+	if !ok {
+		return Result{}, false
+	}
+
+	res := Result{Cached: true, Tournaments: []models.Tournament{}}
+	// position and ageOf let a fresher segment's copy of a tournament replace
+	// an older one in place, keeping the calendar order.
+	position := make(map[string]int)
+	ageOf := make(map[string]time.Duration)
+
+	for _, s := range chosen {
+		res.Segments = append(res.Segments, Segment{DateFrom: s.key.DateFrom, DateTo: s.key.DateTo, Age: s.age})
+		if s.age > res.Age {
+			res.Age = s.age
+		}
+		// Past the TTL alone is not stale: that is a soft-TTL hit whose
+		// refresh is under way. Stale means a refresh was wanted and failed,
+		// or is still owed.
+		if s.entry.Invalidated || s.entry.Err != "" {
+			res.Stale = true
+		}
+
+		for _, t := range s.entry.Tournaments {
+			if !overlapsWindow(t, from, to) {
+				continue
+			}
+			if t.Id == "" {
+				res.Tournaments = append(res.Tournaments, t)
+				continue
+			}
+			if i, seen := position[t.Id]; seen {
+				if s.age < ageOf[t.Id] {
+					res.Tournaments[i] = t
+					ageOf[t.Id] = s.age
+				}
+				continue
+			}
+			position[t.Id] = len(res.Tournaments)
+			ageOf[t.Id] = s.age
+			res.Tournaments = append(res.Tournaments, t)
+		}
+	}
+
+	return res, true
+}
+
+// overlapsWindow reports whether a tournament's parsed days touch [from, to].
+// Tournaments without parsed days are kept.
+func overlapsWindow(t models.Tournament, from, to time.Time) bool {
+	start, err := daterange.ParseISO(t.StartDate)
+	if err != nil {
+		return true
+	}
+	end, err := daterange.ParseISO(t.EndDate)
+	if err != nil {
+		end = start
+	}
+	return daterange.Range{Start: start, End: end}.Overlaps(from, to)
+}
+
+// related lists the stored keys of key's federation and competition type whose
+// window overlaps key's or, with containedOnly, lies within it. key itself is
+// always included when stored.
+func (c *Cache) related(key Key, containedOnly bool) ([]string, error) {
+	from, to, ok := key.window()
+	if !ok {
+		return []string{key.String()}, nil
+	}
+
+	var keys []string
+	err := c.store.ForEachPrefix(segmentPrefix(key.FederationID), func(storeKey string, _ Entry) error {
+		k, ok := ParseKey(storeKey)
+		if !ok || k.FederationID != key.FederationID || k.CompType != key.CompType {
+			return nil
+		}
+		f, t, ok := k.window()
+		if !ok {
+			return nil
+		}
+		if containedOnly && !f.Before(from) && !t.After(to) ||
+			!containedOnly && !f.After(to) && !t.Before(from) {
+			keys = append(keys, storeKey)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// pruneContained drops segments made redundant by a fresh fetch of key: any
+// older entry whose window lies within key's. Without it the store would grow
+// with every distinct window a user ever asked for.
+func (c *Cache) pruneContained(key Key, storedAt time.Time) {
+	keys, err := c.related(key, true)
+	if err != nil {
+		return
+	}
+	self := key.String()
+	for _, k := range keys {
+		if k == self {
+			continue
+		}
+		if entry, found, err := c.store.Get(k); err == nil && found && entry.StoredAt.Before(storedAt) {
+			_ = c.store.Delete(k)
+		}
+	}
+}
@@ -13,6 +13,11 @@
 //   - stale-tolerant: expired data is still served when the upstream is
 //     unavailable, with its age reported so callers can surface it
 //   - stampede-safe: concurrent misses for the same key trigger one refresh
+//   - optionally stale-while-revalidate: an expired entry is served at once
+//     while a single background refresh replaces it
+//   - range-aware: a query is answered from any cached windows that together
+//     cover its dates, so the scheduler's warmup also serves narrower and
+//     shifted windows (see ranges.go)
 package resultcache
 
 import (
@@ -27,6 +32,7 @@ import (
 	"time"
 
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 )
 
 // Default cache lifetimes. Tournament calendars change slowly: entries are
@@ -37,6 +43,9 @@ const (
 	// DefaultStaleTTL is how long expired data may still be served when the
 	// upstream cannot be reached. Stale results beat an empty map.
 	DefaultStaleTTL = 24 * time.Hour
+	// revalidateTimeout bounds a background refresh, which no request waits
+	// on and so has no deadline of its own.
+	revalidateTimeout = 5 * time.Minute
 )
 
 // Entry is one cached federation result.
@@ -45,10 +54,14 @@ type Entry struct {
 	Query        string              `json:"query"`
 	Tournaments  []models.Tournament `json:"tournaments"`
 	StoredAt     time.Time           `json:"stored_at"`
-	// Err records why the last refresh failed, if it did. An entry can hold
+	// Err records why the last refresh failed, if it did, and is cleared by the
+	// next successful one. An entry can hold
 	// both tournaments and an error: the data is from an earlier successful
 	// run and the error explains why it was not refreshed.
 	Err string `json:"err,omitempty"`
+	// Invalidated marks an entry a caller asked to refresh. It is never served
+	// as fresh, but remains a fallback should the refresh fail.
+	Invalidated bool `json:"invalidated,omitempty"`
 }
 
 // Age reports how long ago the entry was stored.
@@ -75,6 +88,8 @@ type Store interface {
 	Set(key string, entry Entry) error
 	Delete(key string) error
 	ForEach(fn func(key string, entry Entry) error) error
+	// ForEachPrefix visits the entries whose key starts with prefix.
+	ForEachPrefix(prefix string, fn func(key string, entry Entry) error) error
 	Close() error
 }
 
@@ -90,11 +105,17 @@ type Result struct {
 	// Stale reports that the TTL had expired and the upstream could not be
 	// refreshed, so this is older data.
 	Stale bool
+	// Revalidating reports that expired data was served while a background
+	// refresh replaces it. It is only set in soft-TTL mode.
+	Revalidating bool
 	// Cached reports that no upstream request was made.
 	Cached bool
 	// Err is the refresh error when stale data is returned, or the load error
 	// when nothing could be served.
 	Err error
+	// Segments lists the cached windows the result was composed from, each
+	// with its own age. It is empty for a freshly loaded result.
+	Segments []Segment
 }
 
 // Cache serves tournament results, refreshing them on demand.
@@ -106,9 +127,18 @@ type Cache struct {
 	// now is injectable so tests do not depend on wall-clock time.
 	now func() time.Time
 
+	// revalidate enables the soft-TTL mode; see Options.Revalidate.
+	revalidate bool
+
 	// inflight collapses concurrent refreshes of the same key.
 	mu       sync.Mutex
 	inflight map[string]*call
+
+	// background is cancelled by Close, which then waits for the background
+	// refreshes tracked by wg.
+	background context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 }
 
 type call struct {
@@ -120,7 +150,11 @@ type call struct {
 type Options struct {
 	TTL      time.Duration
 	StaleTTL time.Duration
-	Now      func() time.Time
+	// Revalidate serves entries past TTL but within StaleTTL immediately and
+	// refreshes them in the background, so no user waits on a scrape while a
+	// usable copy exists. Otherwise the refresh runs in the request.
+	Revalidate bool
+	Now        func() time.Time
 }
 
 // New creates a cache backed by store.
@@ -138,20 +172,26 @@ func New(store Store, opts Options) *Cache {
 		now = time.Now
 	}
 
+	background, cancel := context.WithCancel(context.Background())
+
 	return &Cache{
-		store:    store,
-		ttl:      ttl,
-		stale:    stale,
-		now:      now,
-		inflight: make(map[string]*call),
+		store:      store,
+		ttl:        ttl,
+		stale:      stale,
+		now:        now,
+		revalidate: opts.Revalidate,
+		inflight:   make(map[string]*call),
+		background: background,
+		cancel:     cancel,
 	}
 }
 
 // OptionsFromEnv reads cache tuning from the environment.
 func OptionsFromEnv() Options {
 	return Options{
-		TTL:      durationFromEnv("TTF_CACHE_TTL_MINUTES", DefaultTTL),
-		StaleTTL: durationFromEnv("TTF_CACHE_STALE_MINUTES", DefaultStaleTTL),
+		TTL:        durationFromEnv("TTF_CACHE_TTL_MINUTES", DefaultTTL),
+		StaleTTL:   durationFromEnv("TTF_CACHE_STALE_MINUTES", DefaultStaleTTL),
+		Revalidate: boolFromEnv("TTF_CACHE_REVALIDATE", true),
 	}
 }
 
@@ -162,6 +202,17 @@ func Enabled() bool {
 	return v != "false" && v != "0" && v != "off"
 }
 
+func boolFromEnv(key string, fallback bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "":
+		return fallback
+	case "false", "0", "off":
+		return false
+	default:
+		return true
+	}
+}
+
 func durationFromEnv(key string, fallback time.Duration) time.Duration {
 	raw := os.Getenv(key)
 	if raw == "" {
@@ -198,11 +249,43 @@ func (c *Cache) Get(ctx context.Context, key Key, load Loader) Result {
 		cached, found = Entry{}, false
 	}
 
-	if found && cached.Age(now) < c.ttl && cached.Tournaments != nil {
+	if found && cached.Age(now) < c.ttl && cached.Tournaments != nil && !cached.Invalidated {
 		return Result{
 			Tournaments: cached.Tournaments,
 			Age:         cached.Age(now),
 			Cached:      true,
+			Segments:    []Segment{{DateFrom: key.DateFrom, DateTo: key.DateTo, Age: cached.Age(now)}},
+		}
+	}
+
+	// Not cached under this exact window: compose it from the fresh windows
+	// fetched for other queries.
+	if res, ok := c.compose(key, c.ttl, false); ok {
+		return res
+	}
+
+	// Soft TTL: serve the expired copy and refresh behind it. Invalidated
+	// entries are skipped, as whoever invalidated them wants current data.
+	if c.revalidate {
+		if found && cached.Tournaments != nil && !cached.Invalidated && cached.Age(now) < c.stale {
+			c.revalidateInBackground(ctx, key, load)
+			res := Result{
+				Tournaments:  cached.Tournaments,
+				Age:          cached.Age(now),
+				Cached:       true,
+				Revalidating: true,
+				Segments:     []Segment{{DateFrom: key.DateFrom, DateTo: key.DateTo, Age: cached.Age(now)}},
+			}
+			if cached.Err != "" {
+				res.Stale = true
+				res.Err = errors.New(cached.Err)
+			}
+			return res
+		}
+		if res, ok := c.compose(key, c.stale, false); ok {
+			c.revalidateInBackground(ctx, key, load)
+			res.Revalidating = true
+			return res
 		}
 	}
 
@@ -220,36 +303,57 @@ func (c *Cache) Get(ctx context.Context, key Key, load Loader) Result {
 				Stale:       true,
 				Cached:      true,
 				Err:         res.Err,
+				Segments:    []Segment{{DateFrom: key.DateFrom, DateTo: key.DateTo, Age: age}},
 			}
 		}
 	}
+	if res.Err != nil {
+		if stale, ok := c.compose(key, c.stale, true); ok {
+			stale.Stale = true
+			stale.Err = res.Err
+			return stale
+		}
+	}
 
 	return res
 }
 
 // refresh loads fresh data, ensuring only one load per key runs at a time.
 func (c *Cache) refresh(ctx context.Context, key Key, load Loader) Result {
-	k := key.String()
-
-	c.mu.Lock()
-	if existing, ok := c.inflight[k]; ok {
-		c.mu.Unlock()
+	cl, leader := c.join(key.String())
+	if !leader {
 		// Wait for the in-flight refresh instead of starting a second one.
 		select {
-		case <-existing.done:
-			return existing.res
+		case <-cl.done:
+			return cl.res
 		case <-ctx.Done():
 			return Result{Err: ctx.Err()}
 		}
 	}
+	return c.run(ctx, key, load, cl)
+}
 
+// join returns the refresh in flight for k, or registers a new one the caller
+// leads and must run.
+func (c *Cache) join(k string) (*call, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.inflight[k]; ok {
+		return existing, false
+	}
 	cl := &call{done: make(chan struct{})}
 	c.inflight[k] = cl
-	c.mu.Unlock()
+	return cl, true
+}
 
+// run performs the refresh registered as cl and releases its waiters.
+func (c *Cache) run(ctx context.Context, key Key, load Loader, cl *call) Result {
+	k := key.String()
 	tournaments, err := load(ctx, key)
 
-	if err == nil {
+	if err != nil {
+		c.recordFailure(ctx, k, err)
+	} else {
 		entry := Entry{
 			FederationID: key.FederationID,
 			Query:        k,
@@ -259,6 +363,8 @@ func (c *Cache) refresh(ctx context.Context, key Key, load Loader) Result {
 		if storeErr := c.store.Set(k, entry); storeErr != nil {
 			// Failing to persist is not fatal; the data is still returned.
 			err = nil
+		} else {
+			c.pruneContained(key, entry.StoredAt)
 		}
 	}
 
@@ -272,20 +378,129 @@ func (c *Cache) refresh(ctx context.Context, key Key, load Loader) Result {
 	return cl.res
 }
 
-// Invalidate removes a cached entry.
+// revalidateInBackground refreshes key without making the caller wait. A
+// refresh already running for key is left to finish instead of starting
+// another. The refresh outlives the request that triggered it, runs at
+// background priority, and is cancelled by Close.
+func (c *Cache) revalidateInBackground(ctx context.Context, key Key, load Loader) {
+	cl, leader := c.join(key.String())
+	if !leader {
+		return
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		// Keep the request's values, but not its cancellation.
+		bg, cancel := context.WithTimeout(context.WithoutCancel(ctx), revalidateTimeout)
+		defer cancel()
+		stop := context.AfterFunc(c.background, cancel)
+		defer stop()
+
+		c.run(ratelimit.WithPriority(bg, ratelimit.PriorityBackground), key, load, cl)
+	}()
+}
+
+// recordFailure notes a failed refresh on the existing entry, keeping its data
+// and age. A caller that went away is not a failure of the upstream.
+func (c *Cache) recordFailure(ctx context.Context, k string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	entry, found, getErr := c.store.Get(k)
+	if getErr != nil || !found {
+		return
+	}
+	entry.Err = err.Error()
+	_ = c.store.Set(k, entry)
+}
+
+// Invalidate forces the next Get for key upstream. Every entry overlapping
+// key's window is marked, not only the exact one, as any of them could
+// otherwise answer the query. Marked entries stay available as a fallback
+// when the refresh fails.
 func (c *Cache) Invalidate(key Key) error {
 	if c == nil {
 		return nil
 	}
-	return c.store.Delete(key.String())
+
+	keys, err := c.related(key, false)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		entry, found, err := c.store.Get(k)
+		if err != nil {
+			return err
+		}
+		if !found || entry.Invalidated {
+			continue
+		}
+		entry.Invalidated = true
+		if err := c.store.Set(k, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hit is a single tournament found in the cache.
+type Hit struct {
+	Tournament models.Tournament
+	// Query is the key of the entry the tournament came from.
+	Query string
+	Age   time.Duration
+	// Stale reports that the entry is past its TTL but still within the
+	// stale window.
+	Stale bool
+}
+
+// Find looks up one tournament of a federation across every cached query,
+// without contacting the upstream.
+//
+// The same tournament is usually cached under several windows; the freshest
+// copy wins. Entries past the stale window are ignored, as Get would ignore
+// them too.
+func (c *Cache) Find(federationID string, match func(models.Tournament) bool) (Hit, bool, error) {
+	if c == nil {
+		return Hit{}, false, nil
+	}
+
+	now := c.now()
+	var best Hit
+	found := false
+
+	err := c.store.ForEach(func(key string, entry Entry) error {
+		if entry.FederationID != federationID {
+			return nil
+		}
+		age := entry.Age(now)
+		if age >= c.stale || (found && age >= best.Age) {
+			return nil
+		}
+		for _, t := range entry.Tournaments {
+			if match(t) {
+				best = Hit{Tournament: t, Query: key, Age: age, Stale: age >= c.ttl}
+				found = true
+				break
+			}
+		}
+		return nil
+	})
+
+	return best, found, err
 }
 
 // Stats summarises cache contents for diagnostics.
 type Stats struct {
-	Entries       int            `json:"entries"`
-	Fresh         int            `json:"fresh"`
-	Stale         int            `json:"stale"`
-	Expired       int            `json:"expired"`
+	Entries int `json:"entries"`
+	Fresh   int `json:"fresh"`
+	Stale   int `json:"stale"`
+	Expired int `json:"expired"`
+	// Refreshing counts refreshes currently running, in the request or in
+	// the background.
+	Refreshing    int            `json:"refreshing"`
 	Tournaments   int            `json:"tournaments"`
 	OldestSecond  int64          `json:"oldest_entry_seconds"`
 	PerFederation map[string]int `json:"per_federation"`
@@ -298,6 +513,10 @@ func (c *Cache) Stats() (Stats, error) {
 		return stats, nil
 	}
 
+	c.mu.Lock()
+	stats.Refreshing = len(c.inflight)
+	c.mu.Unlock()
+
 	now := c.now()
 	err := c.store.ForEach(func(_ string, entry Entry) error {
 		stats.Entries++
@@ -306,7 +525,7 @@ func (c *Cache) Stats() (Stats, error) {
 
 		age := entry.Age(now)
 		switch {
-		case age < c.ttl:
+		case age < c.ttl && !entry.Invalidated:
 			stats.Fresh++
 		case age < c.stale:
 			stats.Stale++
@@ -328,6 +547,8 @@ func (c *Cache) Close() error {
 	if c == nil {
 		return nil
 	}
+	c.cancel()
+	c.wg.Wait()
 	return c.store.Close()
 }
 
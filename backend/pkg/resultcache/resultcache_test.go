@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 )
 
 // fakeClock gives tests deterministic control over cache expiry.
@@ -389,6 +390,51 @@ func TestStats(t *testing.T) {
 	}
 }
 
+func TestFindPrefersTheFreshestEntry(t *testing.T) {
+	clock := newFakeClock()
+	cache := newTestCache(clock, time.Hour, 24*time.Hour)
+	ctx := context.Background()
+
+	older := Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "31.08.2026"}
+	newer := Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "15.08.2026"}
+	other := Key{FederationID: "WTB", DateFrom: "01.08.2026", DateTo: "15.08.2026"}
+
+	var calls int64
+	cache.Get(ctx, older, countingLoader(&calls, tournaments("1", "2"), nil))
+	clock.Advance(2 * time.Hour)
+	cache.Get(ctx, newer, countingLoader(&calls, tournaments("1"), nil))
+	cache.Get(ctx, other, countingLoader(&calls, tournaments("3"), nil))
+
+	byID := func(id string) func(models.Tournament) bool {
+		return func(t models.Tournament) bool { return t.Id == id }
+	}
+
+	hit, ok, err := cache.Find("BAD", byID("1"))
+	if err != nil || !ok {
+		t.Fatalf("Find() = %v, %v", ok, err)
+	}
+	if hit.Query != newer.String() || hit.Age != 0 || hit.Stale {
+		t.Errorf("hit = %+v, want the fresh entry", hit)
+	}
+
+	// Only in the older entry, which is past its TTL.
+	hit, ok, _ = cache.Find("BAD", byID("2"))
+	if !ok || !hit.Stale || hit.Age != 2*time.Hour {
+		t.Errorf("hit = %+v, ok = %v, want the stale entry", hit, ok)
+	}
+
+	// Federations do not share ids.
+	if _, ok, _ := cache.Find("BAD", byID("3")); ok {
+		t.Error("found a tournament of another federation")
+	}
+
+	// Past the stale window the entry is ignored.
+	clock.Advance(24 * time.Hour)
+	if _, ok, _ := cache.Find("BAD", byID("1")); ok {
+		t.Error("found a tournament in an expired entry")
+	}
+}
+
 func TestKeyString(t *testing.T) {
 	k := Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "15.08.2026", CompType: "Damen+Einzel"}
 	want := "BAD|01.08.2026|15.08.2026|Damen+Einzel"
@@ -433,6 +479,14 @@ func TestOptionsFromEnv(t *testing.T) {
 		t.Errorf("StaleTTL = %v, want 120m", opts.StaleTTL)
 	}
 
+	if !opts.Revalidate {
+		t.Error("Revalidate = false, want the soft TTL on by default")
+	}
+	t.Setenv("TTF_CACHE_REVALIDATE", "false")
+	if OptionsFromEnv().Revalidate {
+		t.Error("Revalidate = true, want it switched off")
+	}
+
 	// Invalid values fall back to the defaults rather than disabling caching.
 	t.Setenv("TTF_CACHE_TTL_MINUTES", "not-a-number")
 	if got := OptionsFromEnv().TTL; got != DefaultTTL {
@@ -526,3 +580,307 @@ func TestBoltStoreMissingKey(t *testing.T) {
 		t.Errorf("Get(missing) = found %v, err %v; want not found, nil", found, err)
 	}
 }
+
+// dated builds a tournament held on one ISO day.
+func dated(id, day string) models.Tournament {
+	return models.Tournament{Id: id, StartDate: day, EndDate: day}
+}
+
+func TestSubWindowIsServedFromWiderWindow(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, time.Hour, 24*time.Hour)
+
+	wide := Key{FederationID: "BAD", DateFrom: "14.08.2026", DateTo: "12.09.2026"}
+	var calls int64
+	c.Get(context.Background(), wide, countingLoader(&calls, []models.Tournament{
+		dated("early", "2026-08-16"),
+		dated("late", "2026-09-05"),
+		{Id: "undated"},
+	}, nil))
+
+	clock.Advance(10 * time.Minute)
+
+	// The frontend's two-week default, starting the next day.
+	narrow := Key{FederationID: "BAD", DateFrom: "15.08.2026", DateTo: "28.08.2026"}
+	res := c.Get(context.Background(), narrow, countingLoader(&calls, nil, errors.New("must not load")))
+
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Fatalf("loader ran %d times, want 1", got)
+	}
+	if !res.Cached || res.Err != nil {
+		t.Fatalf("result = %+v, want a cache hit", res)
+	}
+	var ids []string
+	for _, tm := range res.Tournaments {
+		ids = append(ids, tm.Id)
+	}
+	if fmt.Sprint(ids) != "[early undated]" {
+		t.Errorf("tournaments = %v, want [early undated]", ids)
+	}
+	if len(res.Segments) != 1 || res.Segments[0].DateFrom != wide.DateFrom || res.Segments[0].Age != 10*time.Minute {
+		t.Errorf("segments = %+v, want the wide window aged 10m", res.Segments)
+	}
+}
+
+func TestAdjacentWindowsAreComposed(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, time.Hour, 24*time.Hour)
+
+	first := Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "15.08.2026"}
+	second := Key{FederationID: "BAD", DateFrom: "16.08.2026", DateTo: "31.08.2026"}
+	// A tournament spanning the boundary is listed by both windows.
+	spanning := models.Tournament{Id: "weekend", StartDate: "2026-08-15", EndDate: "2026-08-16"}
+
+	c.Get(context.Background(), first, func(context.Context, Key) ([]models.Tournament, error) {
+		return []models.Tournament{dated("a", "2026-08-10"), spanning}, nil
+	})
+	clock.Advance(20 * time.Minute)
+	c.Get(context.Background(), second, func(context.Context, Key) ([]models.Tournament, error) {
+		return []models.Tournament{spanning, dated("b", "2026-08-20")}, nil
+	})
+	clock.Advance(5 * time.Minute)
+
+	var calls int64
+	res := c.Get(context.Background(), Key{FederationID: "BAD", DateFrom: "08.08.2026", DateTo: "22.08.2026"},
+		countingLoader(&calls, nil, errors.New("must not load")))
+
+	if atomic.LoadInt64(&calls) != 0 || !res.Cached {
+		t.Fatalf("result = %+v after %d loads, want composed from cache", res, calls)
+	}
+	if len(res.Tournaments) != 3 {
+		t.Errorf("got %d tournaments, want 3 with the spanning one once", len(res.Tournaments))
+	}
+	if len(res.Segments) != 2 {
+		t.Fatalf("segments = %+v, want two", res.Segments)
+	}
+	if res.Segments[0].Age != 25*time.Minute || res.Segments[1].Age != 5*time.Minute {
+		t.Errorf("segment ages = %v, %v; want 25m, 5m", res.Segments[0].Age, res.Segments[1].Age)
+	}
+	if res.Age != 25*time.Minute {
+		t.Errorf("Age = %v, want the oldest segment's", res.Age)
+	}
+}
+
+func TestPartiallyCoveredWindowLoads(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, time.Hour, 24*time.Hour)
+
+	c.Get(context.Background(), Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "15.08.2026"},
+		countingLoader(new(int64), tournaments("1"), nil))
+
+	var calls int64
+	for _, k := range []Key{
+		{FederationID: "BAD", DateFrom: "10.08.2026", DateTo: "20.08.2026"},
+		{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "15.08.2026", CompType: "Damen+Einzel"},
+		{FederationID: "HTV", DateFrom: "01.08.2026", DateTo: "15.08.2026"},
+	} {
+		if res := c.Get(context.Background(), k, countingLoader(&calls, tournaments("2"), nil)); res.Cached {
+			t.Errorf("%s served from cache, want a load", k)
+		}
+	}
+	if got := atomic.LoadInt64(&calls); got != 3 {
+		t.Errorf("loader ran %d times, want 3", got)
+	}
+}
+
+func TestInvalidateKeepsStaleFallback(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(clock, time.Hour, 24*time.Hour)
+
+	wide := Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "31.08.2026"}
+	narrow := Key{FederationID: "BAD", DateFrom: "10.08.2026", DateTo: "20.08.2026"}
+	c.Get(context.Background(), wide, countingLoader(new(int64), tournaments("1"), nil))
+
+	// Invalidating a sub-window also invalidates the wider entry covering it.
+	if err := c.Invalidate(narrow); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+
+	boom := errors.New("upstream down")
+	var calls int64
+	res := c.Get(context.Background(), narrow, countingLoader(&calls, nil, boom))
+	if atomic.LoadInt64(&calls) != 1 {
+		t.Fatalf("loader ran %d times, want 1 after invalidation", calls)
+	}
+	if !res.Stale || !errors.Is(res.Err, boom) || len(res.Tournaments) != 1 {
+		t.Errorf("result = %+v, want the invalidated data as a stale fallback", res)
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Fresh != 0 || stats.Stale != 1 {
+		t.Errorf("stats = %+v, want the invalidated entry counted stale", stats)
+	}
+}
+
+func TestRefreshPrunesContainedWindows(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryStore()
+	c := New(store, Options{TTL: time.Hour, StaleTTL: 24 * time.Hour, Now: clock.Now})
+
+	inner := Key{FederationID: "BAD", DateFrom: "10.08.2026", DateTo: "20.08.2026"}
+	other := Key{FederationID: "HTV", DateFrom: "10.08.2026", DateTo: "20.08.2026"}
+	for _, k := range []Key{inner, other} {
+		c.Get(context.Background(), k, countingLoader(new(int64), tournaments("1"), nil))
+	}
+	clock.Advance(time.Minute)
+	c.Get(context.Background(), Key{FederationID: "BAD", DateFrom: "01.08.2026", DateTo: "31.08.2026"},
+		countingLoader(new(int64), tournaments("1"), nil))
+
+	if _, found, _ := store.Get(inner.String()); found {
+		t.Error("contained window still stored after the wider refresh")
+	}
+	if _, found, _ := store.Get(other.String()); !found {
+		t.Error("another federation's window was pruned")
+	}
+}
+
+func TestBoltStoreForEachPrefix(t *testing.T) {
+	store, err := NewBoltStore(t.TempDir() + "/results.bolt")
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"BAD|a|b|", "BAD|c|d|", "BADEN|a|b|", "HTV|a|b|"} {
+		if err := store.Set(key, Entry{Tournaments: tournaments("1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	if err := store.ForEachPrefix("BAD|", func(key string, _ Entry) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keys) != "[BAD|a|b| BAD|c|d|]" {
+		t.Errorf("visited %v, want the two BAD entries", keys)
+	}
+}
+
+func newRevalidatingCache(clock *fakeClock) *Cache {
+	return New(NewMemoryStore(), Options{TTL: time.Hour, StaleTTL: 24 * time.Hour, Revalidate: true, Now: clock.Now})
+}
+
+// TestRevalidateServesExpiredEntryImmediately covers the soft TTL: the first
+// users after expiry get the old copy at once, and one refresh replaces it.
+func TestRevalidateServesExpiredEntryImmediately(t *testing.T) {
+	clock := newFakeClock()
+	c := newRevalidatingCache(clock)
+	c.Get(context.Background(), testKey(), countingLoader(new(int64), tournaments("old"), nil))
+	clock.Advance(2 * time.Hour)
+
+	var calls int64
+	release := make(chan struct{})
+	var priority ratelimit.Priority
+	load := func(ctx context.Context, _ Key) ([]models.Tournament, error) {
+		atomic.AddInt64(&calls, 1)
+		priority = ratelimit.PriorityFrom(ctx)
+		<-release
+		return tournaments("new"), nil
+	}
+
+	// The request's context ends with the request; the refresh must not.
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 3; i++ {
+		res := c.Get(ctx, testKey(), load)
+		if !res.Cached || !res.Revalidating || res.Stale || res.Err != nil {
+			t.Errorf("call %d = %+v, want the expired copy while revalidating", i, res)
+		}
+		if len(res.Tournaments) != 1 || res.Tournaments[0].Id != "old" {
+			t.Errorf("call %d returned %+v, want the old copy", i, res.Tournaments)
+		}
+	}
+	cancel()
+
+	close(release)
+	c.wg.Wait()
+
+	if got := atomic.LoadInt64(&calls); got != 1 {
+		t.Errorf("loader ran %d times, want one background refresh", got)
+	}
+	if priority != ratelimit.PriorityBackground {
+		t.Errorf("refresh ran at %v, want background priority", priority)
+	}
+	res := c.Get(context.Background(), testKey(), load)
+	if res.Revalidating || res.Tournaments[0].Id != "new" {
+		t.Errorf("result = %+v, want the refreshed copy", res)
+	}
+}
+
+func TestRevalidateRecordsFailure(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryStore()
+	c := New(store, Options{TTL: time.Hour, StaleTTL: 24 * time.Hour, Revalidate: true, Now: clock.Now})
+	c.Get(context.Background(), testKey(), countingLoader(new(int64), tournaments("1"), nil))
+	clock.Advance(2 * time.Hour)
+
+	boom := errors.New("federation unreachable")
+	var calls int64
+	c.Get(context.Background(), testKey(), countingLoader(&calls, nil, boom))
+	c.wg.Wait()
+
+	entry, _, _ := store.Get(testKey().String())
+	if entry.Err != boom.Error() || len(entry.Tournaments) != 1 {
+		t.Fatalf("entry = %+v, want the data kept and the failure recorded", entry)
+	}
+
+	// The next caller learns the copy could not be refreshed, and a new
+	// attempt is made behind it.
+	res := c.Get(context.Background(), testKey(), countingLoader(&calls, tournaments("2"), nil))
+	if !res.Stale || res.Err == nil || !res.Revalidating {
+		t.Errorf("result = %+v, want stale data with the recorded failure", res)
+	}
+	c.wg.Wait()
+
+	entry, _, _ = store.Get(testKey().String())
+	if entry.Err != "" || atomic.LoadInt64(&calls) != 2 {
+		t.Errorf("entry = %+v after %d loads, want the failure cleared by the refresh", entry, calls)
+	}
+}
+
+func TestRevalidateSkipsInvalidatedEntries(t *testing.T) {
+	clock := newFakeClock()
+	c := newRevalidatingCache(clock)
+	c.Get(context.Background(), testKey(), countingLoader(new(int64), tournaments("old"), nil))
+	clock.Advance(2 * time.Hour)
+
+	// A scheduled warmup invalidates and expects to wait for current data.
+	if err := c.Invalidate(testKey()); err != nil {
+		t.Fatal(err)
+	}
+	res := c.Get(context.Background(), testKey(), countingLoader(new(int64), tournaments("new"), nil))
+	if res.Cached || res.Tournaments[0].Id != "new" {
+		t.Errorf("result = %+v, want a synchronous refresh", res)
+	}
+}
+
+func TestCloseCancelsBackgroundRefresh(t *testing.T) {
+	clock := newFakeClock()
+	c := newRevalidatingCache(clock)
+	c.Get(context.Background(), testKey(), countingLoader(new(int64), tournaments("1"), nil))
+	clock.Advance(2 * time.Hour)
+
+	started := make(chan struct{})
+	c.Get(context.Background(), testKey(), func(ctx context.Context, _ Key) ([]models.Tournament, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	done := make(chan error)
+	go func() { done <- c.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not cancel the background refresh")
+	}
+}
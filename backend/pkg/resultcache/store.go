@@ -1,9 +1,11 @@
 package resultcache
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"go.etcd.io/bbolt"
@@ -109,6 +111,27 @@ func (s *BoltStore) ForEach(fn func(key string, entry Entry) error) error {
 	})
 }
 
+func (s *BoltStore) ForEachPrefix(prefix string, fn func(key string, entry Entry) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(resultsBucket))
+		if bucket == nil {
+			return nil
+		}
+		p := []byte(prefix)
+		c := bucket.Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			entry, err := decode(v)
+			if err != nil {
+				continue // skip corrupt entries
+			}
+			if err := fn(string(k), entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *BoltStore) Close() error {
 	if s.db == nil {
 		return nil
@@ -165,4 +188,13 @@ func (s *MemoryStore) ForEach(fn func(key string, entry Entry) error) error {
 	return nil
 }
 
+func (s *MemoryStore) ForEachPrefix(prefix string, fn func(key string, entry Entry) error) error {
+	return s.ForEach(func(key string, entry Entry) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		return fn(key, entry)
+	})
+}
+
 func (s *MemoryStore) Close() error { return nil }
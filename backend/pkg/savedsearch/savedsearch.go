@@ -0,0 +1,58 @@
+// Package savedsearch keeps searches under opaque tokens, so they can be run
+// again without the client repeating the parameters.
+//
+// A saved search holds the GetTournaments query parameters, not its results:
+// a search for "the next 8 weeks" (days=56) moves along with the calendar, and
+// every run reads the current data. The token is the only credential: whoever
+// knows it can read the feed, so it is random and never derived from the
+// query.
+package savedsearch
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Enabled reports whether saved searches are available. They are on by
+// default; set TTF_SAVED_SEARCHES=false to turn them off.
+func Enabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("TTF_SAVED_SEARCHES")))
+	return v != "false" && v != "0" && v != "off"
+}
+
+// MaxNameLength is how many characters a search name may have. The name is a
+// feed title and heads push notifications, which must stay small.
+const MaxNameLength = 100
+
+// Search is one saved search.
+type Search struct {
+	Token string `json:"token"`
+	// Name is an optional label, used as the feed title.
+	Name string `json:"name,omitempty"`
+	// Query holds the GetTournaments parameters, e.g. federations=BAD,WTB.
+	Query     url.Values `json:"query"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// New returns a search with a fresh token.
+func New(name string, query url.Values, now time.Time) (Search, error) {
+	token, err := NewToken()
+	if err != nil {
+		return Search{}, err
+	}
+	return Search{Token: token, Name: name, Query: query, CreatedAt: now}, nil
+}
+
+// NewToken returns 128 random bits, URL-safe: 22 characters that cannot be
+// guessed or enumerated.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
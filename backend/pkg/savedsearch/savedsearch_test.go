@@ -0,0 +1,88 @@
+package savedsearch
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTokenIsRandomAndURLSafe(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		token, err := NewToken()
+		if err != nil {
+			t.Fatalf("NewToken() error = %v", err)
+		}
+		if len(token) != 22 || url.PathEscape(token) != token {
+			t.Errorf("token %q is not 22 URL-safe characters", token)
+		}
+		if seen[token] {
+			t.Fatalf("token %q issued twice", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "searches.bolt")
+	bolt, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "bolt": bolt} {
+		search, err := New("Jugend Einzel", url.Values{"federations": {"BAD,WTB"}, "days": {"56"}}, now)
+		if err != nil {
+			t.Fatalf("%s: New() error = %v", name, err)
+		}
+		if err := store.Put(search); err != nil {
+			t.Fatalf("%s: Put() error = %v", name, err)
+		}
+
+		got, found, err := store.Get(search.Token)
+		if err != nil || !found {
+			t.Fatalf("%s: Get() = %v, %v", name, found, err)
+		}
+		if got.Name != "Jugend Einzel" || got.Query.Get("federations") != "BAD,WTB" || !got.CreatedAt.Equal(now) {
+			t.Errorf("%s: Get() = %+v", name, got)
+		}
+
+		count := 0
+		store.ForEach(func(Search) error { count++; return nil })
+		if count != 1 {
+			t.Errorf("%s: ForEach() visited %d searches, want 1", name, count)
+		}
+
+		if err := store.Delete(search.Token); err != nil {
+			t.Fatalf("%s: Delete() error = %v", name, err)
+		}
+		if _, found, _ := store.Get(search.Token); found {
+			t.Errorf("%s: search still found after Delete()", name)
+		}
+	}
+
+	// Saved searches survive a restart.
+	search, _ := New("", url.Values{"compType": {"Jugend"}}, now)
+	bolt.Put(search)
+	bolt.Close()
+
+	bolt, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer bolt.Close()
+	if got, found, _ := bolt.Get(search.Token); !found || got.Query.Get("compType") != "Jugend" {
+		t.Errorf("Get() after reopen = %+v, %v", got, found)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false, "0": false, "off": false} {
+		t.Setenv("TTF_SAVED_SEARCHES", value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package savedsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// searchesBucket holds one search per token.
+const searchesBucket = "searches"
+
+// Store persists saved searches.
+type Store interface {
+	Get(token string) (Search, bool, error)
+	Put(search Search) error
+	Delete(token string) error
+	ForEach(fn func(search Search) error) error
+	Close() error
+}
+
+// BoltStore persists searches in BoltDB.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (or creates) the search database at dbPath.
+func NewBoltStore(dbPath string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create saved search directory: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open saved searches at %s: %w", dbPath, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(searchesBucket))
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create searches bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(token string) (Search, bool, error) {
+	var search Search
+	var found bool
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(searchesBucket))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(token))
+		if data == nil {
+			return nil
+		}
+		// An unreadable search is a miss.
+		if err := json.Unmarshal(data, &search); err == nil {
+			found = true
+		}
+		return nil
+	})
+
+	return search, found, err
+}
+
+func (s *BoltStore) Put(search Search) error {
+	data, err := json.Marshal(search)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(searchesBucket))
+		if bucket == nil {
+			return fmt.Errorf("searches bucket does not exist")
+		}
+		return bucket.Put([]byte(search.Token), data)
+	})
+}
+
+func (s *BoltStore) Delete(token string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(searchesBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(token))
+	})
+}
+
+func (s *BoltStore) ForEach(fn func(search Search) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(searchesBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var search Search
+			if err := json.Unmarshal(v, &search); err != nil {
+				return nil // skip corrupt searches
+			}
+			return fn(search)
+		})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// MemoryStore keeps searches in memory. It is used by tests and as a fallback
+// when the persistent store cannot be opened.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	searches map[string]Search
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{searches: make(map[string]Search)}
+}
+
+func (s *MemoryStore) Get(token string) (Search, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	search, ok := s.searches[token]
+	return search, ok, nil
+}
+
+func (s *MemoryStore) Put(search Search) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches[search.Token] = search
+	return nil
+}
+
+func (s *MemoryStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.searches, token)
+	return nil
+}
+
+func (s *MemoryStore) ForEach(fn func(search Search) error) error {
+	s.mu.RLock()
+	snapshot := make([]Search, 0, len(s.searches))
+	for _, search := range s.searches {
+		snapshot = append(snapshot, search)
+	}
+	s.mu.RUnlock()
+
+	for _, search := range snapshot {
+		if err := fn(search); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"sync"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/robfig/cron/v3"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/tournament"
 )
 
@@ -53,7 +55,8 @@ func warmupDaysFromEnv() int {
 	return days
 }
 
-// runWarmup pre-fetches the configured window into the result cache.
+// runWarmup pre-fetches the configured window into the result cache, then
+// pushes the tournaments it found to subscribers of saved searches.
 func runWarmup(cfg Config) {
 	days := cfg.WarmupDays
 	if days <= 0 {
@@ -67,6 +70,13 @@ func runWarmup(cfg Config) {
 	logger.Info("Scheduler tick: warming cache for %s..%s", dateFrom, dateTo)
 	total := tournament.Warmup(dateFrom, dateTo, cfg.CompType, cfg.Federations)
 	logger.Info("Scheduler warmup done, tournaments fetched: %d", total)
+
+	// The saved searches may miss the cache and geocode; that yields to
+	// lookups a user is waiting on, as the warmup does.
+	ctx := ratelimit.WithPriority(context.Background(), ratelimit.PriorityBackground)
+	if sent := tournament.NotifySubscribers(ctx); sent > 0 {
+		logger.Info("Scheduler sent %d push notifications", sent)
+	}
 }
 
 func New(cfg Config) (*Scheduler, error) {
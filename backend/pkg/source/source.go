@@ -0,0 +1,150 @@
+// Package source defines how tournaments are fetched from an upstream
+// federation site, and keeps the registry of available implementations.
+//
+// Federations do not share one platform: most run one of two nuLiga
+// generations, Bavaria runs its own ZK widget, and others may follow. Each
+// platform is a Source registered under the ApiVersion used in the federation
+// configuration, so supporting a new upstream means registering one more
+// implementation rather than editing the request path.
+//
+// A fork adds a source from its own package:
+//
+//	func init() {
+//		source.Register("myplatform", mySource{})
+//	}
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// Query is what a caller asks a source for.
+type Query struct {
+	// DateFrom and DateTo bound the window, formatted "02.01.2006".
+	DateFrom string
+	DateTo   string
+	// CompType is the optional competition filter, e.g. "Herren+Einzel".
+	CompType string
+}
+
+// Capabilities describes which parts of a Query a source can push upstream.
+// Anything a source cannot filter itself is applied by the caller.
+//
+// There is no competition filter: Canonical drops it from every query sent
+// upstream, and the caller always filters the parsed competitions.
+type Capabilities struct {
+	// DateFilter reports that the source returns only tournaments in the
+	// requested date window. Without it the caller drops the others.
+	DateFilter bool
+}
+
+// Source fetches and parses one upstream platform's tournament listing.
+//
+// Implementations must respect ctx, and should return the tournaments parsed
+// so far together with an error when only part of the listing could be read:
+// a partial list is more useful to a player than none.
+type Source interface {
+	// Fetch retrieves the federation's tournaments matching q.
+	Fetch(ctx context.Context, fed models.Federation, q Query) ([]models.Tournament, error)
+	// Capabilities reports which query fields the upstream honours.
+	Capabilities() Capabilities
+}
+
+// ErrWindowIgnored is returned, alone, with a listing the upstream may not
+// have restricted to the requested window, e.g. because it can fall back to a
+// default one without saying so. The tournaments are valid and have been filtered to the window, so the
+// caller serves and caches them, but they may miss some that lie in it: the
+// listing must not be taken as everything the federation has there.
+var ErrWindowIgnored = errors.New("upstream ignored the requested date window")
+
+// Canonicalizer is implemented by a source whose broadest useful upstream
+// query differs from the default one described at Canonical.
+type Canonicalizer interface {
+	Canonical(q Query) Query
+}
+
+// Canonical returns the query to send upstream and to cache for q: the
+// broadest one src supports, so a single scrape serves every competition
+// filter and the caller applies q.CompType to the parsed competitions.
+//
+// By default the competition filter is dropped. Upstream filters are coarse
+// ("general" covers Herren and Damen) or missing, so pushing them down saves
+// little and costs a separate scrape per filter.
+func Canonical(src Source, q Query) Query {
+	if c, ok := src.(Canonicalizer); ok {
+		return c.Canonical(q)
+	}
+	q.CompType = ""
+	return q
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Source)
+)
+
+// Register makes a source available under an ApiVersion.
+//
+// It panics when apiVersion is empty, src is nil or the version is already
+// taken: all three are programming errors that would otherwise surface only
+// as a federation silently returning nothing.
+func Register(apiVersion string, src Source) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if apiVersion == "" {
+		panic("source: Register called with an empty ApiVersion")
+	}
+	if src == nil {
+		panic("source: Register source is nil for " + apiVersion)
+	}
+	if _, dup := registry[apiVersion]; dup {
+		panic("source: Register called twice for " + apiVersion)
+	}
+	registry[apiVersion] = src
+}
+
+// Lookup returns the source registered for apiVersion.
+func Lookup(apiVersion string) (Source, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	src, ok := registry[apiVersion]
+	return src, ok
+}
+
+// For returns the source serving a federation, or an error naming the
+// federation when its ApiVersion is unknown.
+func For(fed models.Federation) (Source, error) {
+	src, ok := Lookup(fed.ApiVersion)
+	if !ok {
+		return nil, fmt.Errorf("unknown API version %q for federation %s", fed.ApiVersion, fed.Id)
+	}
+	return src, nil
+}
+
+// Versions lists the registered ApiVersions in sorted order.
+func Versions() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	versions := make([]string, 0, len(registry))
+	for v := range registry {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Unregister removes a source. It exists for tests that register a stub.
+func Unregister(apiVersion string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, apiVersion)
+}
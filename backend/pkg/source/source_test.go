@@ -0,0 +1,143 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// stubSource returns a fixed list and records the last query.
+type stubSource struct {
+	caps Capabilities
+	last Query
+}
+
+func (s *stubSource) Fetch(_ context.Context, fed models.Federation, q Query) ([]models.Tournament, error) {
+	s.last = q
+	return []models.Tournament{{Id: fed.Id + "-1"}}, nil
+}
+
+func (s *stubSource) Capabilities() Capabilities { return s.caps }
+
+func TestRegisterAndLookup(t *testing.T) {
+	stub := &stubSource{caps: Capabilities{DateFilter: true}}
+	Register("test-lookup", stub)
+	t.Cleanup(func() { Unregister("test-lookup") })
+
+	got, ok := Lookup("test-lookup")
+	if !ok {
+		t.Fatal("Lookup() did not find the registered source")
+	}
+	if !got.Capabilities().DateFilter {
+		t.Error("Capabilities() did not come from the registered source")
+	}
+
+	if _, ok := Lookup("never-registered"); ok {
+		t.Error("Lookup() found a source that was never registered")
+	}
+}
+
+func TestForReportsUnknownApiVersion(t *testing.T) {
+	_, err := For(models.Federation{Id: "XYZ", ApiVersion: "bogus"})
+	if err == nil {
+		t.Fatal("For() returned nil error for an unknown ApiVersion")
+	}
+	// The federation id makes the log line actionable.
+	if !strings.Contains(err.Error(), "XYZ") || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("error = %q, want it to name the federation and version", err)
+	}
+}
+
+func TestForDispatchesByApiVersion(t *testing.T) {
+	stub := &stubSource{}
+	Register("test-dispatch", stub)
+	t.Cleanup(func() { Unregister("test-dispatch") })
+
+	src, err := For(models.Federation{Id: "FED", ApiVersion: "test-dispatch"})
+	if err != nil {
+		t.Fatalf("For() error = %v", err)
+	}
+
+	tournaments, err := src.Fetch(context.Background(), models.Federation{Id: "FED"},
+		Query{DateFrom: "01.08.2026", DateTo: "15.08.2026"})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(tournaments) != 1 || tournaments[0].Id != "FED-1" {
+		t.Errorf("Fetch() = %+v, want the stub's tournament", tournaments)
+	}
+	if stub.last.DateFrom != "01.08.2026" || stub.last.DateTo != "15.08.2026" {
+		t.Errorf("query = %+v, want the dates passed through", stub.last)
+	}
+}
+
+// TestRegisterRejectsMistakes covers the cases that would otherwise surface
+// as a federation silently returning nothing.
+func TestRegisterRejectsMistakes(t *testing.T) {
+	Register("test-dup", &stubSource{})
+	t.Cleanup(func() { Unregister("test-dup") })
+
+	tests := []struct {
+		name       string
+		apiVersion string
+		src        Source
+	}{
+		{"empty version", "", &stubSource{}},
+		{"nil source", "test-nil", nil},
+		{"duplicate", "test-dup", &stubSource{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Register() did not panic")
+				}
+			}()
+			Register(tt.apiVersion, tt.src)
+		})
+	}
+}
+
+func TestVersionsAreSorted(t *testing.T) {
+	Register("test-b", &stubSource{})
+	Register("test-a", &stubSource{})
+	t.Cleanup(func() {
+		Unregister("test-a")
+		Unregister("test-b")
+	})
+
+	versions := Versions()
+	ia, ib := -1, -1
+	for i, v := range versions {
+		switch v {
+		case "test-a":
+			ia = i
+		case "test-b":
+			ib = i
+		}
+	}
+	if ia < 0 || ib < 0 || ia > ib {
+		t.Errorf("Versions() = %v, want both test versions in sorted order", versions)
+	}
+}
+
+// narrowSource keeps the competition filter upstream.
+type narrowSource struct{ stubSource }
+
+func (narrowSource) Canonical(q Query) Query { return q }
+
+func TestCanonicalDropsTheCompetitionFilter(t *testing.T) {
+	q := Query{DateFrom: "01.08.2026", DateTo: "15.08.2026", CompType: "Herren+Einzel"}
+
+	got := Canonical(&stubSource{}, q)
+	if got.CompType != "" || got.DateFrom != q.DateFrom || got.DateTo != q.DateTo {
+		t.Errorf("Canonical() = %+v, want the window without the competition filter", got)
+	}
+
+	if got := Canonical(&narrowSource{}, q); got != q {
+		t.Errorf("Canonical() = %+v, want the source's own choice %+v", got, q)
+	}
+}
@@ -0,0 +1,79 @@
+package tournament
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/catalog"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
+)
+
+// ChangesPath serves the catalog's change log.
+const ChangesPath = "/changes"
+
+// changesPageSize caps one response; clients follow the cursor for the rest.
+const changesPageSize = 500
+
+// ChangesResponse is one page of the change log.
+type ChangesResponse struct {
+	Changes []catalog.Change `json:"changes"`
+	// Cursor resumes after this page. It is returned even when the page is
+	// empty, so clients can always store the latest one.
+	Cursor string `json:"cursor"`
+	// More reports that the log continues past Cursor.
+	More bool `json:"more"`
+	// Truncated reports that changes after since were pruned, so the client
+	// missed some and should reload its tournaments instead.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetChanges serves the tournaments added, modified and removed after the
+// since cursor, oldest first, optionally for a comma-separated list of
+// federations. Without since it starts at the beginning of the log.
+func GetChanges(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	if Catalog() == nil {
+		http.Error(w, "tournament catalog is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	since, ok := catalog.ParseCursor(query.Get("since"))
+	if !ok {
+		http.Error(w, "invalid since cursor", http.StatusBadRequest)
+		return
+	}
+
+	var federations map[string]bool
+	for _, id := range strings.Split(query.Get("federations"), ",") {
+		if trimmed := strings.TrimSpace(id); trimmed != "" {
+			if federations == nil {
+				federations = make(map[string]bool)
+			}
+			federations[trimmed] = true
+		}
+	}
+
+	changes, next, truncated, err := Catalog().Changes(since, federations, changesPageSize)
+	if err != nil {
+		logger.Error("Failed to read the change log: %v", err)
+		http.Error(w, "failed to read the change log", http.StatusInternalServerError)
+		return
+	}
+	if changes == nil {
+		changes = []catalog.Change{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ChangesResponse{
+		Changes:   changes,
+		Cursor:    catalog.FormatCursor(next),
+		More:      len(changes) == changesPageSize,
+		Truncated: truncated,
+	}); err != nil {
+		logger.Error("Failed to encode changes response: %v", err)
+	}
+}
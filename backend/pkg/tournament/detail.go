@@ -0,0 +1,113 @@
+package tournament
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/resultcache"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
+)
+
+// DetailPattern routes a single tournament. It uses the path wildcards of
+// net/http's ServeMux, which GetTournament reads back with PathValue.
+const DetailPattern = "GET /tournaments/{federation}/{id}"
+
+// TournamentDetail is one tournament together with where its data came from.
+type TournamentDetail struct {
+	models.Tournament
+	// Federation is the id of the federation that published the tournament.
+	Federation string `json:"federation"`
+	// AgeSeconds is how old the copy is.
+	AgeSeconds int `json:"age_seconds"`
+	// Stale reports that the copy is past the cache TTL.
+	Stale bool `json:"stale,omitempty"`
+	// FirstSeen and LastSeen are when the catalog first and last saw the
+	// tournament upstream. They are omitted when it was served from the
+	// result cache alone.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+}
+
+// GetTournament serves one tournament from the catalog, or from the result
+// cache when the catalog does not know it (or is disabled).
+//
+// It never contacts the upstream: a single tournament cannot be fetched from
+// any federation without scraping its whole listing, which is exactly what the
+// caches exist to avoid. A tournament no refresh has returned is a 404, even
+// if it exists upstream.
+func GetTournament(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	federationID := r.PathValue("federation")
+	id := r.PathValue("id")
+
+	cache := ResultCache()
+	if cache == nil && Catalog() == nil {
+		http.Error(w, "result cache is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	detail, found, err := lookupTournament(cache, federationID, id)
+	if err != nil {
+		logger.Error("Failed to look up tournament %s/%s: %v", federationID, id, err)
+		http.Error(w, "failed to read result cache", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "tournament not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detail); err != nil {
+		logger.Error("Failed to encode tournament response: %v", err)
+	}
+}
+
+// lookupTournament looks the tournament up by canonical id in the catalog,
+// falling back to scanning the result cache for tournaments cached before the
+// catalog was installed.
+//
+// A tournament the catalog saw removed is only served while it is flagged
+// possibly cancelled. After that searches no longer return it, and neither
+// does the detail; an older cached copy is not consulted.
+func lookupTournament(cache *resultcache.Cache, federationID, id string) (TournamentDetail, bool, error) {
+	record, found, err := Catalog().Get(tournamentid.Canonical(federationID, models.Tournament{Id: id}))
+	if err != nil {
+		return TournamentDetail{}, false, err
+	}
+	if found {
+		status := Catalog().Status(record)
+		if record.Removed && status == "" {
+			return TournamentDetail{}, false, nil
+		}
+		age := time.Since(record.LastSeen)
+		t := record.Tournament
+		t.Status = status
+		return TournamentDetail{
+			Tournament: t,
+			Federation: record.Federation,
+			AgeSeconds: int(age.Seconds()),
+			Stale:      cache != nil && age >= cache.TTL(),
+			FirstSeen:  &record.FirstSeen,
+			LastSeen:   &record.LastSeen,
+		}, true, nil
+	}
+
+	hit, found, err := cache.Find(federationID, func(t models.Tournament) bool {
+		return t.Id == id
+	})
+	if err != nil || !found {
+		return TournamentDetail{}, false, err
+	}
+	return TournamentDetail{
+		Tournament: hit.Tournament,
+		Federation: federationID,
+		AgeSeconds: int(hit.Age.Seconds()),
+		Stale:      hit.Stale,
+	}, true, nil
+}
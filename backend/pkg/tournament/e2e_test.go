@@ -2,12 +2,22 @@ package tournament_test
 
 import (
 	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,11 +25,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/catalog"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/geoqueue"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/openstreetmap"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/resultcache"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/savedsearch"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/source"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/tournament"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/undated"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/webpush"
 )
 
 // TestMain wires the package's external dependencies to local mocks so the
@@ -35,6 +53,10 @@ func TestMain(m *testing.M) {
 		logger.SetLogLevel(logger.ErrorLevel)
 	}
 
+	// The mocked Nominatim is what these tests assert against, so keep
+	// geocoding on the network path.
+	os.Setenv("TTF_GAZETTEER", "off")
+
 	os.Exit(m.Run())
 }
 
@@ -170,11 +192,15 @@ func TestEndToEndOldApiFederation(t *testing.T) {
 	if gotContentType != "application/x-www-form-urlencoded" {
 		t.Errorf("content type = %q", gotContentType)
 	}
-	for _, want := range []string{"queryDateFrom=01.08.2026", "federation=BAD", "compType=Herren%2BEinzel"} {
+	for _, want := range []string{"queryDateFrom=01.08.2026", "federation=BAD"} {
 		if !strings.Contains(gotBody, want) {
 			t.Errorf("request body %q missing %q", gotBody, want)
 		}
 	}
+	// The competition filter is applied locally, so one scrape serves them all.
+	if strings.Contains(gotBody, "compType") {
+		t.Errorf("request body %q carries the competition filter upstream", gotBody)
+	}
 
 	if len(tournaments) != 1 {
 		t.Fatalf("got %d tournaments, want 1", len(tournaments))
@@ -184,6 +210,9 @@ func TestEndToEndOldApiFederation(t *testing.T) {
 	if got.Id != "700001" || got.Title != "Sommer Open" {
 		t.Errorf("tournament = %+v", got)
 	}
+	if got.CanonicalId != "BAD:700001" {
+		t.Errorf("canonical id = %q, want BAD:700001", got.CanonicalId)
+	}
 	if got.Organizer != "TC Karlsruhe" {
 		t.Errorf("organizer = %q, want %q", got.Organizer, "TC Karlsruhe")
 	}
@@ -191,8 +220,22 @@ func TestEndToEndOldApiFederation(t *testing.T) {
 	if got.Lat != "49.0069" || got.Lon != "8.4037" {
 		t.Errorf("coordinates = (%q,%q), want mocked geocoding result", got.Lat, got.Lon)
 	}
-	if len(got.Entries) != 2 {
-		t.Errorf("entries = %d, want 2", len(got.Entries))
+	if len(got.Entries) != 1 || got.Entries[0].Competition != "Herren Einzel" {
+		t.Fatalf("entries = %+v, want only Herren Einzel", got.Entries)
+	}
+	// The competition name is also exposed parsed.
+	if e := got.Entries[0]; e.Gender != "Herren" || e.AgeClass != "open" || e.Discipline != "Einzel" || e.DrawType != "" {
+		t.Errorf("entry = %+v, want the structured fields filled in", e)
+	}
+	if got.StartDate != "2026-08-01" || got.EndDate != "2026-08-03" {
+		t.Errorf("start/end = %q/%q, want the parsed date range", got.StartDate, got.EndDate)
+	}
+	// The old API publishes no location, so the pin comes from the club name.
+	if got.LocationSource != "organizer-derived" {
+		t.Errorf("location source = %q, want organizer-derived", got.LocationSource)
+	}
+	if got.LocationDisplayName != "Karlsruhe, Baden-Württemberg, Deutschland" {
+		t.Errorf("location display name = %q, want the geocoder's name", got.LocationDisplayName)
 	}
 	if atomic.LoadInt64(geoCalls) == 0 {
 		t.Error("expected at least one geocoding request")
@@ -346,6 +389,104 @@ func TestEndToEndNewApiTerminatesWhenUpstreamIgnoresOffset(t *testing.T) {
 	}
 }
 
+// btvWidget mocks the BTV widget with the recorded grid fixture. Update
+// requests after the first fail once failAfter is reached; 0 never fails.
+func btvWidget(t *testing.T, bootstrap string, failAfter int) *httptest.Server {
+	t.Helper()
+
+	fixture, err := os.ReadFile(filepath.Join("..", "btv", "testdata", "grid_response.txt"))
+	if err != nil {
+		t.Fatalf("failed to read BTV fixture: %v", err)
+	}
+
+	var updates atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/btvtrnsearch/zkau", func(w http.ResponseWriter, r *http.Request) {
+		if n := updates.Add(1); failAfter > 0 && n > int64(failAfter) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(fixture)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "S"})
+		fmt.Fprint(w, bootstrap)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// btvBootstrap is a widget page exposing both date boxes.
+const btvBootstrap = `<script>zkmx([0,'u',{dt:'z_1',uu:'\x2Fbtvtrnsearch\x2Fzkau'}],
+['zul.db.Datebox','dbFrom',{format:'dd.MM.yyyy'}],
+['zul.db.Datebox','dbTo',{format:'dd.MM.yyyy'}])</script>`
+
+// TestEndToEndBTVFailedPageKeepsReadPages checks that a BTV listing cut short
+// by a failed page still delivers the pages that were read, with the error.
+func TestEndToEndBTVFailedPageKeepsReadPages(t *testing.T) {
+	initIsolatedCache(t)
+	mockNominatim(t, "Bad Füssing, Bayern, Deutschland", "48.35", "13.31")
+
+	srv := btvWidget(t, btvBootstrap, 1)
+	fed := models.Federation{
+		Id: "BTV", Url: srv.URL + "/", Name: "Bayerischer Tennis-Verband", State: "Bayern", ApiVersion: "btv",
+		Geocoordinates: models.Geocoordinates{Lat: "48.13", Lon: "11.57"},
+	}
+
+	tournaments, results := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.08.2026", "31.08.2026", "")
+
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("results = %+v, want the failed page reported", results)
+	}
+	if len(tournaments) == 0 {
+		t.Error("the pages read before the failure were dropped")
+	}
+}
+
+// TestEndToEndBTVIgnoredWindowRemovesNothing checks that a BTV listing is
+// served, but not taken as complete: the widget may have ignored the requested
+// window, so known tournaments it leaves out are not marked removed.
+func TestEndToEndBTVIgnoredWindowRemovesNothing(t *testing.T) {
+	initIsolatedCache(t)
+	mockNominatim(t, "Bad Füssing, Bayern, Deutschland", "48.35", "13.31")
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	c := withCatalog(t)
+
+	from := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC)
+	known := models.Tournament{Id: "known", Title: "Known Cup", Date: "20.08.2026", StartDate: "2026-08-20"}
+	if _, err := c.Upsert(catalog.Refresh{Federation: "BTV", Tournaments: []models.Tournament{known}, From: from, To: to, Complete: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without date boxes the widget serves its default window. With them
+	// it may still do so, and the fixture's rows look the same either way.
+	for _, tc := range []struct{ name, bootstrap string }{
+		{"no date boxes", `<script>zkmx([0,'u',{dt:'z_1',uu:'\x2Fbtvtrnsearch\x2Fzkau'}])</script>`},
+		{"date boxes set", btvBootstrap},
+	} {
+		name := tc.name
+		srv := btvWidget(t, tc.bootstrap, 0)
+		fed := models.Federation{
+			Id: "BTV", Url: srv.URL + "/", Name: "Bayerischer Tennis-Verband", State: "Bayern", ApiVersion: "btv",
+			Geocoordinates: models.Geocoordinates{Lat: "48.13", Lon: "11.57"},
+		}
+		tournament.InvalidateCache([]models.Federation{fed}, "01.08.2026", "31.08.2026", "")
+		tournaments, results := tournament.CollectTournaments(
+			context.Background(), []models.Federation{fed}, "01.08.2026", "31.08.2026", "")
+		if len(results) != 1 || results[0].Err != nil || len(tournaments) == 0 {
+			t.Fatalf("%s: results = %+v, want the listing served without error", name, results)
+		}
+
+		record, _, _ := c.Get(tournamentid.Canonical("BTV", known))
+		if record.Removed {
+			t.Errorf("%s: known tournament marked removed by a listing that may be incomplete", name)
+		}
+	}
+}
+
 func TestEndToEndPartialFailureKeepsHealthyFederations(t *testing.T) {
 	initIsolatedCache(t)
 	mockNominatim(t, "Karlsruhe, Baden-Württemberg, Deutschland", "49.0069", "8.4037")
@@ -586,6 +727,138 @@ func TestEndToEndGeocodingResultsAreCached(t *testing.T) {
 	}
 }
 
+// TestEndToEndGeocodingMissesAreQueued covers the async path: an uncached club
+// must not hold the request, and the answer appears once the queue resolved it.
+func TestEndToEndGeocodingMissesAreQueued(t *testing.T) {
+	initIsolatedCache(t)
+	_, geoCalls := mockNominatim(t, "Karlsruhe, Baden-Württemberg, Deutschland", "49.0069", "8.4037")
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	queue := geoqueue.New(geoqueue.NewMemoryStore(),
+		func(ctx context.Context, fed models.Federation, queued models.Tournament) {
+			openstreetmap.GetGeocoordinatesForFederation(ctx, fed, queued)
+		})
+	tournament.SetGeocodeQueue(queue)
+	t.Cleanup(func() {
+		tournament.SetGeocodeQueue(nil)
+		queue.Stop()
+	})
+
+	var fetches int64
+	fedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&fetches, 1)
+		fmt.Fprint(w, oldAPIResponse)
+	}))
+	defer fedSrv.Close()
+
+	fed := models.Federation{
+		Id: "BAD", Url: fedSrv.URL, State: "Baden-Württemberg", ApiVersion: "old",
+		Geocoordinates: models.Geocoordinates{Lat: "49.0", Lon: "8.4"},
+	}
+	collect := func() models.Tournament {
+		t.Helper()
+		tournaments, results := tournament.CollectTournaments(
+			context.Background(), []models.Federation{fed}, "01.08.2026", "15.08.2026", "")
+		if results[0].Err != nil || len(tournaments) != 1 {
+			t.Fatalf("results = %+v, tournaments = %d", results, len(tournaments))
+		}
+		return tournaments[0]
+	}
+
+	// The worker is not running yet, so the lookup can only be queued.
+	first := collect()
+	if !first.LocationPending || !first.ApproximateLocation {
+		t.Errorf("first = %+v, want a pending pin", first)
+	}
+	if first.Lat != "49.0" || first.Lon != "8.4" {
+		t.Errorf("coordinates = (%q,%q), want the federation default", first.Lat, first.Lon)
+	}
+	if got := atomic.LoadInt64(geoCalls); got != 0 {
+		t.Errorf("made %d geocoding requests on the request path, want 0", got)
+	}
+
+	queue.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats, err := queue.Stats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue still holds %d jobs", stats.Pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The cached result is reused; only the pin is refreshed.
+	second := collect()
+	if second.LocationPending || second.ApproximateLocation {
+		t.Errorf("second = %+v, want a resolved pin", second)
+	}
+	if second.Lat != "49.0069" || second.Lon != "8.4037" {
+		t.Errorf("coordinates = (%q,%q), want the geocoded club", second.Lat, second.Lon)
+	}
+	if got := atomic.LoadInt64(&fetches); got != 1 {
+		t.Errorf("scraped the federation %d times, want 1", got)
+	}
+}
+
+// TestEndToEndCancellationStopsGeocoding covers a client that disconnects
+// mid-request: no further Nominatim calls are made for it, and the half-placed
+// result is not cached.
+func TestEndToEndCancellationStopsGeocoding(t *testing.T) {
+	initIsolatedCache(t)
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client goes away while the first lookup is in flight.
+	var geoCalls int64
+	geoSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&geoCalls, 1)
+		cancel()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "[]")
+	}))
+	defer geoSrv.Close()
+	t.Setenv("TTF_NOMINATIM_URL", geoSrv.URL)
+
+	var fetches int64
+	fedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&fetches, 1)
+		count := 0
+		if firstResultParam(t, r) == 0 {
+			count = 5
+		}
+		fmt.Fprint(w, newAPIPage(3000, count))
+	}))
+	defer fedSrv.Close()
+
+	fed := models.Federation{
+		Id: "WTB", Url: fedSrv.URL, State: "Baden-Württemberg", ApiVersion: "new",
+		Geocoordinates: models.Geocoordinates{Lat: "48.85", Lon: "9.13"},
+	}
+
+	_, results := tournament.CollectTournaments(ctx, []models.Federation{fed}, "01.09.2026", "30.09.2026", "")
+
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", results[0].Err)
+	}
+	if got := atomic.LoadInt64(&geoCalls); got != 1 {
+		t.Errorf("made %d geocoding requests, want 1 (none after cancellation)", got)
+	}
+
+	// A later request must not be served the federation-default pins.
+	tournament.CollectTournaments(context.Background(), []models.Federation{fed}, "01.09.2026", "30.09.2026", "")
+	if got := atomic.LoadInt64(&fetches); got < 2 {
+		t.Errorf("scraped the federation %d times, want a fresh fetch after the cancelled one", got)
+	}
+}
+
 func TestEndToEndWarmupPopulatesCache(t *testing.T) {
 	initIsolatedCache(t)
 
@@ -619,6 +892,35 @@ func withResultCache(t *testing.T, opts resultcache.Options) *resultcache.Cache
 	return cache
 }
 
+func withCatalog(t *testing.T) *catalog.Catalog {
+	t.Helper()
+
+	c := catalog.New(catalog.NewMemoryStore())
+	tournament.SetCatalog(c)
+	t.Cleanup(func() { tournament.SetCatalog(nil) })
+
+	return c
+}
+
+// withPersistentCatalog installs a catalog backed by a bolt file, created
+// now.
+func withPersistentCatalog(t *testing.T) *catalog.Catalog {
+	t.Helper()
+
+	store, err := catalog.NewBoltStore(filepath.Join(t.TempDir(), "tournaments.bolt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := catalog.New(store)
+	tournament.SetCatalog(c)
+	t.Cleanup(func() {
+		tournament.SetCatalog(nil)
+		c.Close()
+	})
+
+	return c
+}
+
 // TestEndToEndResultsAreCachedAcrossRequests is the core benefit of the result
 // cache: repeated user requests must not re-scrape the federation.
 func TestEndToEndResultsAreCachedAcrossRequests(t *testing.T) {
@@ -854,8 +1156,55 @@ func TestEndToEndWarmupRefreshesCache(t *testing.T) {
 	}
 }
 
-// TestEndToEndCacheKeysAreQuerySpecific ensures a different date range or
-// competition filter is not served stale data from another query.
+// TestEndToEndWarmWindowServesNarrowerQueries covers the scheduler's 30-day
+// warmup answering the frontend's two-week default without a scrape.
+func TestEndToEndWarmWindowServesNarrowerQueries(t *testing.T) {
+	initIsolatedCache(t)
+	mockNominatim(t, "Karlsruhe, Baden-Württemberg, Deutschland", "49.0069", "8.4037")
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	var fetches int64
+	fedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&fetches, 1)
+		fmt.Fprint(w, oldAPIResponse)
+	}))
+	defer fedSrv.Close()
+
+	fed := models.Federation{
+		Id: "BAD", Url: fedSrv.URL, State: "Baden-Württemberg", ApiVersion: "old",
+		Geocoordinates: models.Geocoordinates{Lat: "49.0", Lon: "8.4"},
+	}
+
+	tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.08.2026", "30.08.2026", "")
+
+	tournaments, results := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "02.08.2026", "15.08.2026", "")
+
+	if got := atomic.LoadInt64(&fetches); got != 1 {
+		t.Errorf("scraped the federation %d times, want 1", got)
+	}
+	// The tournament runs from the 1st to the 3rd, so it overlaps the window.
+	if len(tournaments) != 1 {
+		t.Fatalf("got %d tournaments, want 1", len(tournaments))
+	}
+	if !results[0].Cached || len(results[0].Segments) != 1 {
+		t.Fatalf("result = %+v, want one cached segment", results[0])
+	}
+	if seg := results[0].Segments[0]; seg.DateFrom != "01.08.2026" || seg.DateTo != "30.08.2026" {
+		t.Errorf("segment = %+v, want the warmed window", seg)
+	}
+
+	// A window starting after the tournament ended is answered empty.
+	tournaments, _ = tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "10.08.2026", "20.08.2026", "")
+	if len(tournaments) != 0 || atomic.LoadInt64(&fetches) != 1 {
+		t.Errorf("got %d tournaments after %d fetches, want 0 from the cache", len(tournaments), fetches)
+	}
+}
+
+// TestEndToEndCacheKeysAreQuerySpecific ensures a different date range is not
+// served data from another query, while competition filters share a scrape.
 func TestEndToEndCacheKeysAreQuerySpecific(t *testing.T) {
 	initIsolatedCache(t)
 	mockNominatim(t, "Karlsruhe, Baden-Württemberg, Deutschland", "49.0069", "8.4037")
@@ -873,19 +1222,31 @@ func TestEndToEndCacheKeysAreQuerySpecific(t *testing.T) {
 		Geocoordinates: models.Geocoordinates{Lat: "49.0", Lon: "8.4"},
 	}
 
-	queries := []struct{ from, to, comp string }{
-		{"01.08.2026", "15.08.2026", ""},
-		{"01.09.2026", "15.09.2026", ""},
-		{"01.08.2026", "15.08.2026", "Damen+Einzel"},
+	queries := []struct {
+		from, to, comp string
+		entries        int
+	}{
+		{"01.08.2026", "15.08.2026", "", 2},
+		{"01.09.2026", "15.09.2026", "", 2},
+		{"01.08.2026", "15.08.2026", "Damen+Einzel", 1},
+		{"01.08.2026", "15.08.2026", "Herren+Doppel", 0},
 	}
 
 	for _, q := range queries {
-		tournament.CollectTournaments(
+		tournaments, _ := tournament.CollectTournaments(
 			context.Background(), []models.Federation{fed}, q.from, q.to, q.comp)
+		entries := 0
+		for _, tm := range tournaments {
+			entries += len(tm.Entries)
+		}
+		if entries != q.entries {
+			t.Errorf("%s-%s %q: got %d entries, want %d", q.from, q.to, q.comp, entries, q.entries)
+		}
 	}
 
-	if got := atomic.LoadInt64(&fetches); got != int64(len(queries)) {
-		t.Errorf("made %d fetches for %d distinct queries, want one each", got, len(queries))
+	// One scrape per window; the competition filters reuse the first.
+	if got := atomic.LoadInt64(&fetches); got != 2 {
+		t.Errorf("made %d fetches, want 2 (one per date window)", got)
 	}
 }
 
@@ -970,3 +1331,944 @@ func TestEndToEndInvalidLKParameterIsIgnored(t *testing.T) {
 		}
 	}
 }
+
+// fakeSource is a third-party platform registered from outside the package.
+type fakeSource struct {
+	calls atomic.Int64
+}
+
+func (s *fakeSource) Fetch(_ context.Context, fed models.Federation, q source.Query) ([]models.Tournament, error) {
+	s.calls.Add(1)
+	return []models.Tournament{{
+		Id: "fake-1", Title: "Fork Open", Date: q.DateFrom,
+		Lat: fed.Geocoordinates.Lat, Lon: fed.Geocoordinates.Lon,
+	}}, nil
+}
+
+func (s *fakeSource) Capabilities() source.Capabilities { return source.Capabilities{} }
+
+// TestEndToEndRegisteredSourceIsConsulted covers the extension point: a source
+// registered outside the core is used for federations naming its ApiVersion.
+func TestEndToEndRegisteredSourceIsConsulted(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	fake := &fakeSource{}
+	source.Register("fake", fake)
+	t.Cleanup(func() { source.Unregister("fake") })
+
+	fed := models.Federation{
+		Id: "FORK", ApiVersion: "fake",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "9.0"},
+	}
+
+	tournaments, results := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.08.2026", "15.08.2026", "")
+
+	if results[0].Err != nil {
+		t.Fatalf("registered source reported error: %v", results[0].Err)
+	}
+	if len(tournaments) != 1 || tournaments[0].Id != "fake-1" {
+		t.Fatalf("got %+v, want the registered source's tournament", tournaments)
+	}
+	if tournaments[0].Date != "01.08.2026" {
+		t.Errorf("Date = %q, want the query passed to the source", tournaments[0].Date)
+	}
+	if got := fake.calls.Load(); got != 1 {
+		t.Errorf("source called %d times, want 1", got)
+	}
+}
+
+// staticSource serves a fixed list, copied so the cache cannot alias it.
+type staticSource []models.Tournament
+
+func (s staticSource) Fetch(context.Context, models.Federation, source.Query) ([]models.Tournament, error) {
+	return append([]models.Tournament(nil), s...), nil
+}
+
+func (staticSource) Capabilities() source.Capabilities { return source.Capabilities{} }
+
+// TestEndToEndWindowAppliedForSourceWithoutDateFilter covers a source that
+// returns its whole listing: the caller drops what lies outside the window.
+func TestEndToEndWindowAppliedForSourceWithoutDateFilter(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	source.Register("unfiltered", staticSource{
+		{Id: "before", Title: "Frühjahrsturnier", Date: "10.05.2026"},
+		{Id: "inside", Title: "Sommer Open", Date: "05.08.2026 - 07.08.2026"},
+		{Id: "overlap", Title: "Monatswechsel", Date: "14.08.2026 - 17.08.2026"},
+		{Id: "after", Title: "Herbstcup", Date: "03.10.2026"},
+		{Id: "undated", Title: "Termin folgt", Date: "nach Absprache"},
+	})
+	t.Cleanup(func() { source.Unregister("unfiltered") })
+
+	fed := models.Federation{
+		Id: "FORK", ApiVersion: "unfiltered",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "9.0"},
+	}
+
+	tournaments, results := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.08.2026", "15.08.2026", "")
+
+	if results[0].Err != nil {
+		t.Fatalf("source reported error: %v", results[0].Err)
+	}
+	var got []string
+	for _, tt := range tournaments {
+		got = append(got, tt.Id)
+	}
+	slices.Sort(got)
+	// An unparseable date is kept, as for the BTV widget.
+	if want := []string{"inside", "overlap", "undated"}; !slices.Equal(got, want) {
+		t.Errorf("tournaments = %v, want %v", got, want)
+	}
+}
+
+// TestEndToEndUnparseableDatesAreRecorded checks that a date the parser does
+// not understand keeps the tournament and shows up in diagnostics.
+func TestEndToEndUnparseableDatesAreRecorded(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	undated.Reset()
+
+	src := staticSource{
+		{Id: "1", Title: "Sommer-Cup", Date: "demnächst"},
+		{Id: "2", Title: "Herbst-Cup", Date: "12.09.2026"},
+	}
+	source.Register("undated-test", src)
+	t.Cleanup(func() { source.Unregister("undated-test") })
+
+	fed := models.Federation{Id: "UND", ApiVersion: "undated-test", State: "Hessen"}
+	tournaments, _ := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.08.2026", "30.09.2026", "")
+
+	if len(tournaments) != 2 {
+		t.Fatalf("got %d tournaments, want both kept", len(tournaments))
+	}
+	for _, got := range tournaments {
+		switch got.Id {
+		case "1":
+			if got.StartDate != "" {
+				t.Errorf("unparseable date produced start_date %q", got.StartDate)
+			}
+		case "2":
+			if got.StartDate != "2026-09-12" || got.EndDate != "2026-09-12" {
+				t.Errorf("start/end = %q/%q, want 2026-09-12", got.StartDate, got.EndDate)
+			}
+		}
+	}
+
+	// A refresh sees the same tournament again; it is still one.
+	tournament.InvalidateCache([]models.Federation{fed}, "01.08.2026", "30.09.2026", "")
+	tournament.CollectTournaments(context.Background(), []models.Federation{fed}, "01.08.2026", "30.09.2026", "")
+
+	recorded := undated.Snapshot()
+	if len(recorded) != 1 || recorded[0].Text != "demnächst" || recorded[0].Federation != "UND" || recorded[0].Count != 1 {
+		t.Errorf("recorded = %+v, want the unparseable text of one tournament", recorded)
+	}
+}
+
+// withFederations serves the given federations from a temporary
+// TTF_FEDERATIONS file, so handler tests control what GetFederations returns.
+func withFederations(t *testing.T, feds ...models.Federation) {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string][]models.Federation{"federations": feds})
+	if err != nil {
+		t.Fatalf("failed to marshal federations: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "federations.json")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("failed to write federations: %v", err)
+	}
+
+	t.Setenv("TTF_FEDERATIONS", path)
+	federation.ResetForTest()
+	t.Cleanup(federation.ResetForTest)
+}
+
+func TestEndToEndICSExport(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	source.Register("ics-test", staticSource{
+		{
+			Id: "700001", Title: "Sommer Open", Date: "01.08.2026 - 03.08.2026",
+			URL:      "https://www.tennis.de/spielen/turniersuche.html#detail/700001",
+			Location: "Karlsruhe", Organizer: "TC Karlsruhe", Lat: "49.0069", Lon: "8.4037",
+			Entries: []models.CompetitionEntry{{Competition: "Herren Einzel", SkillLevel: "LK 12,0"}},
+		},
+		{Id: "700002", Title: "Ohne Datum", Date: "demnächst"},
+	})
+	t.Cleanup(func() { source.Unregister("ics-test") })
+
+	withFederations(t, models.Federation{
+		Id: "ICS", Name: "Test", Url: "https://example.org", State: "Hessen", ApiVersion: "ics-test",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "8.0"},
+	})
+
+	fetch := func() string {
+		req := httptest.NewRequest(http.MethodGet,
+			tournament.ICSPath+"?dateFrom=01.08.2026&dateTo=15.08.2026&federations=ICS", nil)
+		rec := httptest.NewRecorder()
+		tournament.GetTournamentsICS(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+			t.Errorf("content type = %q, want text/calendar", ct)
+		}
+		return rec.Body.String()
+	}
+
+	body := fetch()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:tournament-ICS:700001@tennis-tournament-finder\r\n",
+		"DTSTART;VALUE=DATE:20260801\r\n",
+		"DTEND;VALUE=DATE:20260804\r\n",
+		"LOCATION:Karlsruhe\r\n",
+		"GEO:49.0069;8.4037\r\n",
+		"URL:https://www.tennis.de/spielen/turniersuche.html#detail/700001\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("calendar missing %q:\n%s", want, body)
+		}
+	}
+	if !strings.Contains(strings.ReplaceAll(body, "\r\n ", ""), "Herren Einzel (LK 12\\,0)") {
+		t.Errorf("description does not list the competitions:\n%s", body)
+	}
+	// A tournament without a usable date cannot become an event.
+	if strings.Count(body, "BEGIN:VEVENT") != 1 {
+		t.Errorf("got %d events, want 1", strings.Count(body, "BEGIN:VEVENT"))
+	}
+
+	// Subscribed calendars match events by UID, so it must not change.
+	if again := fetch(); !strings.Contains(again, "UID:tournament-ICS:700001@tennis-tournament-finder\r\n") {
+		t.Error("UID changed between exports")
+	}
+}
+
+// TestEndToEndICSExportWhenAllFederationsFail covers a calendar app polling
+// while upstream is down: an empty calendar would delete every event it shows.
+func TestEndToEndICSExportWhenAllFederationsFail(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	source.Register("ics-down", &countingSource{err: errors.New("upstream down")})
+	t.Cleanup(func() { source.Unregister("ics-down") })
+	withFederations(t, models.Federation{
+		Id: "ICS", Name: "Test", Url: "https://example.org", State: "Hessen", ApiVersion: "ics-down",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "8.0"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet,
+		tournament.ICSPath+"?dateFrom=01.08.2026&dateTo=15.08.2026&federations=ICS", nil)
+	rec := httptest.NewRecorder()
+	tournament.GetTournamentsICS(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "BEGIN:VCALENDAR") {
+		t.Errorf("served an empty calendar:\n%s", rec.Body)
+	}
+}
+
+func TestEndToEndTournamentDetailFromCache(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	source.Register("detail-test", staticSource{{
+		Id: "700001", Title: "Sommer Open", Date: "01.08.2026 - 03.08.2026",
+		Lat: "49.0", Lon: "8.4", ApproximateLocation: true,
+		Entries: []models.CompetitionEntry{{Competition: "Herren Einzel", SkillLevel: "LK 12,0"}},
+	}})
+	t.Cleanup(func() { source.Unregister("detail-test") })
+
+	fed := models.Federation{Id: "DET", ApiVersion: "detail-test", State: "Hessen"}
+	tournament.CollectTournaments(context.Background(), []models.Federation{fed}, "01.08.2026", "15.08.2026", "")
+
+	// Route through a real mux so the path wildcards are populated.
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.DetailPattern, tournament.GetTournament)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := serve("/tournaments/DET/700001")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var got tournament.TournamentDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if got.Id != "700001" || got.Federation != "DET" || !got.ApproximateLocation || len(got.Entries) != 1 {
+		t.Errorf("detail = %+v", got)
+	}
+	if got.StartDate != "2026-08-01" {
+		t.Errorf("start_date = %q, want the parsed date", got.StartDate)
+	}
+
+	for _, path := range []string{"/tournaments/DET/999999", "/tournaments/XXX/700001"} {
+		if rec := serve(path); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+// TestEndToEndIdlessTournamentIsAddressable verifies a new API row whose link
+// carries no id is kept, gets a content hash as its id and can be looked up.
+func TestEndToEndIdlessTournamentIsAddressable(t *testing.T) {
+	initIsolatedCache(t)
+	mockNominatim(t, "Stuttgart, Baden-Württemberg, Deutschland", "48.7758", "9.1829")
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	page := strings.Replace(newAPIPage(800001, 2),
+		"https://www.tennis.de/spielen/turniersuche.html#detail/800002", "https://www.wtb-tennis.de/turniere/herbstturnier", 1)
+	fedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	defer fedSrv.Close()
+
+	fed := models.Federation{
+		Id: "WTB", Url: fedSrv.URL, State: "Baden-Württemberg", ApiVersion: "new",
+		Geocoordinates: models.Geocoordinates{Lat: "48.85", Lon: "9.13"},
+	}
+	tournaments, _ := tournament.CollectTournaments(
+		context.Background(), []models.Federation{fed}, "01.09.2026", "15.09.2026", "")
+	if len(tournaments) != 2 {
+		t.Fatalf("got %d tournaments, want the id-less row kept", len(tournaments))
+	}
+
+	var idless models.Tournament
+	for _, tr := range tournaments {
+		if tr.Title == "Turnier 800002" {
+			idless = tr
+		}
+	}
+	if !strings.HasPrefix(idless.Id, "h") || idless.CanonicalId != "WTB:"+idless.Id {
+		t.Fatalf("id-less tournament = %q / %q, want a content hash", idless.Id, idless.CanonicalId)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.DetailPattern, tournament.GetTournament)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tournaments/WTB/"+idless.Id, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("detail status = %d, want 200", rec.Code)
+	}
+}
+
+// TestEndToEndRefreshesFillTheCatalog verifies every window's refresh upserts
+// into one record per tournament, which detail lookups then serve.
+func TestEndToEndRefreshesFillTheCatalog(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	cat := withCatalog(t)
+
+	source.Register("catalog-test", staticSource{{
+		Id: "700001", Title: "Sommer Open", Date: "01.08.2026 - 03.08.2026", Lat: "49.0", Lon: "8.4",
+	}})
+	t.Cleanup(func() { source.Unregister("catalog-test") })
+
+	fed := models.Federation{Id: "CAT", ApiVersion: "catalog-test", State: "Hessen"}
+	// Two windows both covering the tournament.
+	tournament.CollectTournaments(context.Background(), []models.Federation{fed}, "01.08.2026", "15.08.2026", "")
+	tournament.CollectTournaments(context.Background(), []models.Federation{fed}, "20.07.2026", "02.08.2026", "")
+
+	stats, err := cat.Stats()
+	if err != nil || stats.Tournaments != 1 {
+		t.Fatalf("catalog stats = %+v, %v; want one record", stats, err)
+	}
+	record, found, _ := cat.Get("CAT:700001")
+	if !found || record.Federation != "CAT" || record.Tournament.StartDate != "2026-08-01" {
+		t.Fatalf("record = %+v, want the annotated tournament", record)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.DetailPattern, tournament.GetTournament)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tournaments/CAT/700001", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var got tournament.TournamentDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if got.Id != "700001" || got.FirstSeen == nil || got.LastSeen == nil || got.Stale {
+		t.Errorf("detail = %+v, want the catalog record", got)
+	}
+}
+
+func TestEndToEndChangeFeed(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	withCatalog(t)
+
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: "02.08.2026", Location: "Karlsruhe"},
+		{Id: "2", Title: "Herbst Cup", Date: "09.08.2026", Location: "Mannheim"},
+	}}
+	source.Register("changes-test", src)
+	t.Cleanup(func() { source.Unregister("changes-test") })
+
+	feds := []models.Federation{{Id: "CHG", ApiVersion: "changes-test", State: "Hessen"}}
+	tournament.CollectTournaments(context.Background(), feds, "01.08.2026", "31.08.2026", "")
+
+	// The next refresh moves one tournament and drops the other.
+	src.tournaments = []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: "16.08.2026", Location: "Karlsruhe"},
+	}
+	tournament.InvalidateCache(feds, "01.08.2026", "31.08.2026", "")
+	tournament.CollectTournaments(context.Background(), feds, "01.08.2026", "31.08.2026", "")
+
+	get := func(target string) tournament.ChangesResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		tournament.GetChanges(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200: %s", target, rec.Code, rec.Body)
+		}
+		var page tournament.ChangesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("response is not valid JSON: %v", err)
+		}
+		return page
+	}
+
+	page := get("/changes?federations=CHG")
+	var got []string
+	for _, c := range page.Changes {
+		got = append(got, c.Type+" "+c.ID+" "+strings.Join(c.Fields, ","))
+	}
+	want := []string{"added CHG:1 ", "added CHG:2 ", "modified CHG:1 date", "removed CHG:2 "}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+	if page.More || page.Truncated {
+		t.Errorf("page = %+v, want the whole log", page)
+	}
+
+	// Resuming from the cursor returns nothing new, and keeps the cursor.
+	if next := get("/changes?since=" + page.Cursor); len(next.Changes) != 0 || next.Cursor != page.Cursor {
+		t.Errorf("resumed page = %+v, want empty at cursor %s", next, page.Cursor)
+	}
+	if other := get("/changes?federations=XYZ"); len(other.Changes) != 0 {
+		t.Errorf("changes of another federation = %+v", other.Changes)
+	}
+
+	rec := httptest.NewRecorder()
+	tournament.GetChanges(rec, httptest.NewRequest(http.MethodGet, "/changes?since=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid cursor status = %d, want 400", rec.Code)
+	}
+}
+
+func TestEndToEndVanishedTournamentsArePossiblyCancelled(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	withCatalog(t)
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("02.01.2006") }
+	from, to := day(0), day(60)
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: day(10)},
+		{Id: "2", Title: "Herbst Cup", Date: day(20)},
+		{Id: "3", Title: "Jugend Cup", Date: day(30)},
+	}}
+	source.Register("cancel-test", src)
+	t.Cleanup(func() { source.Unregister("cancel-test") })
+
+	feds := []models.Federation{{Id: "CNL", ApiVersion: "cancel-test", State: "Hessen"}}
+	refresh := func(tournaments []models.Tournament, err error) map[string]string {
+		t.Helper()
+		src.tournaments, src.err = tournaments, err
+		tournament.InvalidateCache(feds, from, to, "")
+		got, _ := tournament.CollectTournaments(context.Background(), feds, from, to, "")
+		statuses := make(map[string]string, len(got))
+		for _, tm := range got {
+			statuses[tm.Id] = tm.Status
+		}
+		return statuses
+	}
+	refresh(src.tournaments, nil)
+
+	// A failed page leaves a tournament out, but flags nothing.
+	got := refresh(src.tournaments[:2], errors.New("page 2 failed"))
+	for id, status := range got {
+		if status != "" {
+			t.Errorf("after a partial refresh %s has status %q", id, status)
+		}
+	}
+
+	// A successful refresh without it keeps it, flagged.
+	got = refresh(src.tournaments[:2], nil)
+	want := map[string]string{"1": "", "2": "", "3": models.StatusPossiblyCancelled}
+	if !maps.Equal(got, want) {
+		t.Errorf("after a complete refresh = %v, want %v", got, want)
+	}
+
+	// The detail shows the marker too.
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.DetailPattern, tournament.GetTournament)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tournaments/CNL/3", nil))
+	var detail tournament.TournamentDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil || detail.Status != models.StatusPossiblyCancelled {
+		t.Errorf("detail = %s, want status %s", rec.Body, models.StatusPossiblyCancelled)
+	}
+}
+
+// TestEndToEndRemovedTournamentDetailIsNotFound checks that the detail of a
+// tournament its federation stopped listing, and that is not flagged possibly
+// cancelled, is a 404 like in searches.
+func TestEndToEndRemovedTournamentDetailIsNotFound(t *testing.T) {
+	// Without a grace period a removal is never flagged.
+	t.Setenv("TTF_CANCELLED_GRACE_DAYS", "0")
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	withCatalog(t)
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("02.01.2006") }
+	from, to := day(0), day(60)
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: day(10)},
+		{Id: "2", Title: "Herbst Cup", Date: day(20)},
+	}}
+	source.Register("removed-test", src)
+	t.Cleanup(func() { source.Unregister("removed-test") })
+
+	feds := []models.Federation{{Id: "RMV", ApiVersion: "removed-test", State: "Hessen"}}
+	tournament.CollectTournaments(context.Background(), feds, from, to, "")
+	src.tournaments = src.tournaments[:1]
+	tournament.InvalidateCache(feds, from, to, "")
+	tournament.CollectTournaments(context.Background(), feds, from, to, "")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.DetailPattern, tournament.GetTournament)
+	for target, want := range map[string]int{
+		"/tournaments/RMV/1": http.StatusOK,
+		"/tournaments/RMV/2": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s status = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
+
+func TestEndToEndSavedSearchFeed(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	withCatalog(t)
+	tournament.SetSavedSearches(savedsearch.NewMemoryStore())
+	t.Cleanup(func() { tournament.SetSavedSearches(nil) })
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("02.01.2006") }
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: day(5), URL: "https://example.org/1"},
+	}}
+	source.Register("feed-test", src)
+	t.Cleanup(func() { source.Unregister("feed-test") })
+	fed := models.Federation{
+		Id: "FED", Name: "Test", Url: "https://example.org", State: "Hessen", ApiVersion: "feed-test",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "8.0"},
+	}
+	withFederations(t, fed)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.CreateSearchPattern, tournament.CreateSearch)
+	mux.HandleFunc(tournament.SearchPattern, tournament.GetSearch)
+	mux.HandleFunc(tournament.DeleteSearchPattern, tournament.DeleteSearch)
+	mux.HandleFunc(tournament.FeedPattern, tournament.GetFeed)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/searches", "lat=50&radiusKm=20"); rec.Code != http.StatusBadRequest {
+		t.Errorf("incomplete radius status = %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/searches", "federations=FED&name="+strings.Repeat("x", savedsearch.MaxNameLength+1)); rec.Code != http.StatusBadRequest {
+		t.Errorf("long name status = %d, want 400", rec.Code)
+	}
+
+	rec := serve(http.MethodPost, "/searches", "name=Trainer&federations=FED&days=56&unknown=x")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var saved tournament.SavedSearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if saved.Token == "" || saved.Query.Has("unknown") || !strings.HasSuffix(saved.FeedURL, "/feeds/"+saved.Token+".atom") {
+		t.Fatalf("saved = %+v", saved)
+	}
+
+	type entry struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+	}
+	feed := func() []entry {
+		t.Helper()
+		rec := serve(http.MethodGet, "/feeds/"+saved.Token+".atom", "")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/atom+xml") {
+			t.Fatalf("feed status = %d, type %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+		var doc struct {
+			Title   string  `xml:"title"`
+			Entries []entry `xml:"entry"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("feed is not valid XML: %v", err)
+		}
+		if doc.Title != "Trainer" {
+			t.Errorf("feed title = %q, want the search name", doc.Title)
+		}
+		return doc.Entries
+	}
+
+	first := feed()
+	if len(first) != 1 {
+		t.Fatalf("first feed = %+v, want one entry", first)
+	}
+
+	// A tournament published later is listed first, though it takes place
+	// later, and the known one keeps its id.
+	src.tournaments = append(src.tournaments, models.Tournament{Id: "2", Title: "Herbst Cup", Date: day(40)})
+	tournament.InvalidateCache([]models.Federation{fed}, day(0), day(56), "")
+	second := feed()
+	if len(second) != 2 || second[0].Title != "Herbst Cup" || second[1].ID != first[0].ID {
+		t.Errorf("second feed = %+v, want the new tournament first and %s unchanged", second, first[0].ID)
+	}
+
+	if rec := serve(http.MethodGet, "/feeds/"+saved.Token, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("feed without extension status = %d, want 404", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/searches/"+saved.Token, ""); rec.Code != http.StatusOK {
+		t.Errorf("get status = %d, want 200", rec.Code)
+	}
+	if rec := serve(http.MethodDelete, "/searches/"+saved.Token, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/feeds/"+saved.Token+".atom", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("feed of a deleted search status = %d, want 404", rec.Code)
+	}
+}
+
+// TestEndToEndPushNotifications runs the notifier against a local fake push
+// service: a subscriber is told about tournaments that appear in its saved
+// search, once, and its subscription is dropped when the service reports it
+// expired.
+func TestEndToEndPushNotifications(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	withPersistentCatalog(t)
+	searches := savedsearch.NewMemoryStore()
+	tournament.SetSavedSearches(searches)
+	t.Cleanup(func() { tournament.SetSavedSearches(nil) })
+
+	var status atomic.Int32
+	status.Store(http.StatusCreated)
+	keys, subscriptions, deliveries, loopback := withFakePushService(t, &status)
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("02.01.2006") }
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: day(5)},
+	}}
+	source.Register("push-test", src)
+	t.Cleanup(func() { source.Unregister("push-test") })
+	fed := models.Federation{
+		Id: "FED", Name: "Test", Url: "https://example.org", State: "Hessen", ApiVersion: "push-test",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "8.0"},
+	}
+	withFederations(t, fed)
+
+	search, _ := savedsearch.New("Trainer", url.Values{"federations": {"FED"}, "days": {"56"}}, time.Now())
+	searches.Put(search)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tournament.PushKeyPattern, tournament.GetPushKey)
+	mux.HandleFunc(tournament.SubscribePattern, tournament.Subscribe)
+	mux.HandleFunc(tournament.UnsubscribePattern, tournament.Unsubscribe)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	var key tournament.PushKeyResponse
+	if rec := serve(http.MethodGet, "/push/key", ""); rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &key) != nil || key.PublicKey != keys.PublicKey() {
+		t.Fatalf("key status = %d: %s", rec.Code, rec.Body)
+	}
+
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() without subscribers = %d", sent)
+	}
+
+	subscription := pushSubscription(t, pushEndpoint)
+
+	if rec := serve(http.MethodPost, "/push/subscriptions", `{"subscription":`+subscription+`,"search":"unknown"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("subscribe to unknown search status = %d, want 404", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/push/subscriptions", `{"subscription":{"endpoint":"https://push.example.org"},"search":"`+search.Token+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("subscribe without keys status = %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/push/subscriptions", `{"subscription":`+pushSubscription(t, loopback)+`,"search":"`+search.Token+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("subscribe with a loopback endpoint status = %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/push/subscriptions", `{"subscription":`+subscription+`,"search":"`+search.Token+`"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("subscribe status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+
+	// What the search found when subscribing is not news.
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() with nothing new = %d, want 0", sent)
+	}
+
+	src.tournaments = append(src.tournaments, models.Tournament{Id: "2", Title: "Herbst Cup", Date: day(40)})
+	tournament.InvalidateCache([]models.Federation{fed}, day(0), day(56), "")
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 1 {
+		t.Fatalf("NotifySubscribers() after a new tournament = %d, want 1", sent)
+	}
+	got := <-deliveries
+	if got.header.Get("Content-Encoding") != "aes128gcm" ||
+		!strings.HasPrefix(got.header.Get("Authorization"), "vapid t=") || len(got.body) <= 86 {
+		t.Errorf("delivery headers = %v, body %d bytes", got.header, len(got.body))
+	}
+	// The token grants access to the search; the push service must not
+	// learn it.
+	if topic := got.header.Get("Topic"); topic == "" || len(topic) > 32 || strings.Contains(topic, search.Token) {
+		t.Errorf("Topic = %q, want a name of at most 32 characters not containing the token %q", topic, search.Token)
+	}
+
+	// Announced once only.
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() repeated = %d, want 0", sent)
+	}
+
+	// An expired subscription is removed.
+	status.Store(http.StatusGone)
+	src.tournaments = append(src.tournaments, models.Tournament{Id: "3", Title: "Winter Cup", Date: day(50)})
+	tournament.InvalidateCache([]models.Federation{fed}, day(0), day(56), "")
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() to an expired subscription = %d, want 0", sent)
+	}
+	<-deliveries
+	if _, found, _ := subscriptions.Get(pushEndpoint); found {
+		t.Errorf("expired subscription was kept")
+	}
+
+	// Unsubscribing an unknown endpoint succeeds.
+	if rec := serve(http.MethodDelete, "/push/subscriptions", subscription); rec.Code != http.StatusNoContent {
+		t.Errorf("unsubscribe status = %d, want 204", rec.Code)
+	}
+}
+
+// TestEndToEndPushWaitsForAReliableCatalog covers catalogs whose first-seen
+// times say nothing about a subscription: one in memory, which starts over on
+// every restart, and one created after the subscriber was last notified.
+// Neither may announce everything it finds.
+func TestEndToEndPushWaitsForAReliableCatalog(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+	searches := savedsearch.NewMemoryStore()
+	tournament.SetSavedSearches(searches)
+	t.Cleanup(func() { tournament.SetSavedSearches(nil) })
+
+	var status atomic.Int32
+	status.Store(http.StatusCreated)
+	_, subscriptions, deliveries, _ := withFakePushService(t, &status)
+
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format("02.01.2006") }
+	src := &countingSource{tournaments: []models.Tournament{
+		{Id: "1", Title: "Sommer Open", Date: day(5)},
+	}}
+	source.Register("push-catalog-test", src)
+	t.Cleanup(func() { source.Unregister("push-catalog-test") })
+	fed := models.Federation{
+		Id: "FED", Name: "Test", Url: "https://example.org", State: "Hessen", ApiVersion: "push-catalog-test",
+		Geocoordinates: models.Geocoordinates{Lat: "50.0", Lon: "8.0"},
+	}
+	withFederations(t, fed)
+
+	search, _ := savedsearch.New("Trainer", url.Values{"federations": {"FED"}, "days": {"56"}}, time.Now())
+	searches.Put(search)
+
+	// Subscribed and last notified before the restart.
+	var subscription webpush.Subscription
+	if err := json.Unmarshal([]byte(pushSubscription(t, pushEndpoint)), &subscription); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Add(-time.Hour)
+	subscriptions.Put(webpush.Record{Subscription: subscription, Search: search.Token, CreatedAt: before, LastNotified: before})
+
+	withCatalog(t)
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() with a catalog in memory = %d, want 0", sent)
+	}
+
+	withPersistentCatalog(t)
+	tournament.InvalidateCache([]models.Federation{fed}, day(0), day(56), "")
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 0 {
+		t.Errorf("NotifySubscribers() with a catalog newer than the subscription = %d, want 0", sent)
+	}
+
+	// From then on, additions are news again.
+	src.tournaments = append(src.tournaments, models.Tournament{Id: "2", Title: "Herbst Cup", Date: day(40)})
+	tournament.InvalidateCache([]models.Federation{fed}, day(0), day(56), "")
+	if sent := tournament.NotifySubscribers(context.Background()); sent != 1 {
+		t.Errorf("NotifySubscribers() after a new tournament = %d, want 1", sent)
+	}
+	if len(deliveries) != 1 {
+		t.Errorf("%d deliveries, want 1", len(deliveries))
+	}
+}
+
+// pushEndpoint is a public endpoint; the client withFakePushService installs
+// connects it to the fake service.
+const pushEndpoint = "https://example.com/send/abc"
+
+// pushDelivery is a request the fake push service received.
+type pushDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+// withFakePushService installs a Web Push sender delivering to a local fake
+// push service, which answers with status. It returns the sender's keys, the
+// subscription store, the received deliveries and a loopback endpoint of the
+// service.
+func withFakePushService(t *testing.T, status *atomic.Int32) (*webpush.Keys, webpush.Store, chan pushDelivery, string) {
+	t.Helper()
+
+	keys, err := webpush.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscriptions := webpush.NewMemoryStore()
+
+	deliveries := make(chan pushDelivery, 10)
+	pushService := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- pushDelivery{header: r.Header, body: body}
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(pushService.Close)
+
+	// Subscriptions must name a public host. The test server's certificate
+	// is valid for example.com, and its client connects every request to it.
+	client := pushService.Client()
+	transport := client.Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, pushService.Listener.Addr().String())
+	}
+	client.Transport = transport
+
+	tournament.SetPush(subscriptions, &webpush.Sender{Keys: keys, Subject: webpush.DefaultSubject, Client: client})
+	t.Cleanup(func() { tournament.SetPush(nil, nil) })
+
+	return keys, subscriptions, deliveries, pushService.URL + "/send/abc"
+}
+
+// pushSubscription returns what PushSubscription.toJSON() would for a fresh
+// browser key and endpoint.
+func pushSubscription(t *testing.T, endpoint string) string {
+	t.Helper()
+	browserKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf(`{"endpoint":%q,"expirationTime":null,"keys":{"p256dh":%q,"auth":"BTBZMqHH6r4Tts7J_aSIgg"}}`,
+		endpoint, base64.RawURLEncoding.EncodeToString(browserKey.PublicKey().Bytes()))
+}
+
+// countingSource serves a fixed list and counts fetches. A set err is
+// returned with the list, like a source that could only read part of it.
+type countingSource struct {
+	tournaments []models.Tournament
+	err         error
+	calls       atomic.Int64
+}
+
+func (s *countingSource) Fetch(context.Context, models.Federation, source.Query) ([]models.Tournament, error) {
+	s.calls.Add(1)
+	return append([]models.Tournament(nil), s.tournaments...), s.err
+}
+
+func (s *countingSource) Capabilities() source.Capabilities { return source.Capabilities{} }
+
+func TestEndToEndRadiusSearch(t *testing.T) {
+	withResultCache(t, resultcache.Options{TTL: time.Hour, StaleTTL: 24 * time.Hour})
+
+	south := &countingSource{tournaments: []models.Tournament{
+		{Id: "stuttgart", Title: "Stuttgart Open", Date: "02.08.2026", Lat: "48.7758", Lon: "9.1829"},
+		{Id: "karlsruhe", Title: "Karlsruhe Open", Date: "02.08.2026", Lat: "49.0069", Lon: "8.4037"},
+		{Id: "freiburg", Title: "Freiburg Open", Date: "02.08.2026", Lat: "47.999", Lon: "7.842"},
+	}}
+	north := &countingSource{tournaments: []models.Tournament{
+		{Id: "hamburg", Title: "Hamburg Open", Date: "02.08.2026", Lat: "53.5503", Lon: "10.0007"},
+	}}
+	source.Register("radius-south", south)
+	source.Register("radius-north", north)
+	t.Cleanup(func() {
+		source.Unregister("radius-south")
+		source.Unregister("radius-north")
+	})
+
+	withFederations(t,
+		models.Federation{
+			Id: "SUD", Name: "Süd", Url: "https://example.org/sued", State: "Baden-Württemberg", ApiVersion: "radius-south",
+			Geocoordinates: models.Geocoordinates{Lat: "48.8", Lon: "9.1"},
+			Bounds:         &models.Bounds{South: 47.5, West: 7.5, North: 49.8, East: 10.5},
+		},
+		models.Federation{
+			Id: "NRD", Name: "Nord", Url: "https://example.org/nord", State: "Hamburg", ApiVersion: "radius-north",
+			Geocoordinates: models.Geocoordinates{Lat: "53.55", Lon: "10.0"},
+			Bounds:         &models.Bounds{South: 53.39, West: 9.73, North: 53.97, East: 10.33},
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/?dateFrom=01.08.2026&dateTo=15.08.2026&lat=49.0069&lon=8.4037&radiusKm=70", nil)
+	rec := httptest.NewRecorder()
+	tournament.GetTournaments(rec, req)
+
+	var got []models.Tournament
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	if north.calls.Load() != 0 {
+		t.Error("fetched a federation the circle does not reach")
+	}
+	if len(got) != 2 || got[0].Id != "karlsruhe" || got[1].Id != "stuttgart" {
+		t.Fatalf("got %+v, want karlsruhe then stuttgart", got)
+	}
+	if got[1].DistanceKm == nil || *got[1].DistanceKm < 55 {
+		t.Errorf("distance_km = %v, want about 62", got[1].DistanceKm)
+	}
+	if !strings.Contains(rec.Body.String(), `"distance_km":`) {
+		t.Error("response does not carry distance_km")
+	}
+}
+
+// TestBuiltinSourcesAreRegistered keeps the configured ApiVersions in step
+// with the registry.
+func TestBuiltinSourcesAreRegistered(t *testing.T) {
+	for _, version := range []string{"old", "new", "btv"} {
+		src, ok := source.Lookup(version)
+		if !ok {
+			t.Errorf("no source registered for %q", version)
+			continue
+		}
+		if !src.Capabilities().DateFilter {
+			t.Errorf("source %q does not restrict results to the requested window", version)
+		}
+	}
+}
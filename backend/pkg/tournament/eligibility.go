@@ -0,0 +1,90 @@
+package tournament
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/competition"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/skilllevel"
+)
+
+// Profile is the player a search is for. FilterByLK only knows the LK; a
+// profile also rules out competitions for the other gender or another age
+// class, so a 45-year-old woman no longer sees Herren or U16 competitions.
+type Profile struct {
+	Player competition.Player
+	// LK is the player's LK; HasLK is false when it was not given.
+	LK    float64
+	HasLK bool
+}
+
+// ParseProfile reads the birthYear, gender and lk query parameters. It
+// reports false unless a birth year or gender was given: an LK alone is the
+// plain LK filter. Invalid values are ignored individually.
+func ParseProfile(birthYear, gender, lk string) (Profile, bool) {
+	var p Profile
+
+	if year, err := strconv.Atoi(strings.TrimSpace(birthYear)); err == nil && year > 1900 && year <= time.Now().Year() {
+		p.Player.BirthYear = year
+	}
+	if g, ok := competition.ParseGender(gender); ok {
+		p.Player.Gender = g
+	}
+	if p.Player.BirthYear == 0 && p.Player.Gender == competition.GenderUnknown {
+		return Profile{}, false
+	}
+
+	p.LK, p.HasLK = skilllevel.ParsePlayerLK(lk)
+	return p, true
+}
+
+// FilterByProfile keeps the competition entries the player may enter under
+// German age-class rules, each with the reason it was kept, and drops
+// tournaments left without any.
+//
+// It follows FilterByLK's policy: a constraint neither the competition nor the
+// player states is treated as open and named in the reason, and tournaments
+// without parsed entries stay. season is the year used for tournaments
+// without a parsed start date.
+func FilterByProfile(tournaments []models.Tournament, p Profile, season int) []models.Tournament {
+	return filterEntries(tournaments, func(t models.Tournament, e models.CompetitionEntry) (models.CompetitionEntry, bool) {
+		year := season
+		if start, err := daterange.ParseISO(t.StartDate); err == nil {
+			year = start.Year()
+		}
+
+		eligibility, ok := checkEntry(e, p, year)
+		e.Eligibility = eligibility
+		return e, ok
+	})
+}
+
+// checkEntry matches one competition against the profile.
+func checkEntry(e models.CompetitionEntry, p Profile, season int) (*models.Eligibility, bool) {
+	check := competition.Parse(e.Competition).Admits(p.Player, season)
+	if !check.Eligible {
+		return nil, false
+	}
+
+	reasons := check.Reasons
+	unknown := check.Unknown
+
+	range_, published := skilllevel.Parse(e.SkillLevel)
+	switch {
+	case !published:
+		reasons = append(reasons, "keine LK-Grenze ausgeschrieben")
+		unknown = append(unknown, "lk")
+	case !p.HasLK:
+		reasons = append(reasons, "LK nicht angegeben")
+		unknown = append(unknown, "lk")
+	case !range_.Includes(p.LK):
+		return nil, false
+	default:
+		reasons = append(reasons, "LK "+strings.Replace(strconv.FormatFloat(p.LK, 'f', 1, 64), ".", ",", 1)+" in "+range_.String())
+	}
+
+	return &models.Eligibility{Reason: strings.Join(reasons, ", "), Unknown: unknown}, true
+}
@@ -0,0 +1,316 @@
+package tournament
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/atom"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/savedsearch"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
+)
+
+// Saved search routes. They use the path wildcards of net/http's ServeMux.
+const (
+	CreateSearchPattern = "POST /searches"
+	SearchPattern       = "GET /searches/{token}"
+	DeleteSearchPattern = "DELETE /searches/{token}"
+	// FeedPattern serves /feeds/{token}.atom. A wildcard must fill a whole
+	// segment, so the extension is checked by GetFeed.
+	FeedPattern = "GET /feeds/{feed}"
+)
+
+// feedTagPrefix starts every feed and entry id: a tag URI (RFC 4151) on the
+// project's site, so the ids are stable and cannot collide with other feeds.
+const feedTagPrefix = "tag:timoknapp.github.io,2026:tennis-tournament-finder/"
+
+// maxSearchBytes bounds a saved search request body.
+const maxSearchBytes = 16 << 10
+
+// savedSearches stores saved searches. It is nil when they are disabled.
+var (
+	savedSearches   savedsearch.Store
+	savedSearchesMu sync.RWMutex
+)
+
+// SetSavedSearches installs the store saved searches are kept in.
+func SetSavedSearches(store savedsearch.Store) {
+	savedSearchesMu.Lock()
+	defer savedSearchesMu.Unlock()
+	savedSearches = store
+}
+
+// SavedSearches returns the installed store, or nil.
+func SavedSearches() savedsearch.Store {
+	savedSearchesMu.RLock()
+	defer savedSearchesMu.RUnlock()
+	return savedSearches
+}
+
+// SavedSearchResponse is a saved search with the URL of its feed.
+type SavedSearchResponse struct {
+	savedsearch.Search
+	FeedURL string `json:"feed_url"`
+}
+
+// CreateSearch saves the GetTournaments parameters of the request, from the
+// query string or a form body, plus an optional name. Parameters of no
+// search are dropped; a radius or days that would be ignored is rejected, so
+// a mistake does not silently become a search of everything.
+func CreateSearch(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	store := SavedSearches()
+	if store == nil {
+		http.Error(w, "saved searches are disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSearchBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid search parameters", http.StatusBadRequest)
+		return
+	}
+
+	query := url.Values{}
+	for _, key := range searchParams {
+		if v := strings.TrimSpace(r.Form.Get(key)); v != "" {
+			query.Set(key, v)
+		}
+	}
+	if problem := validateSearch(query); problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.Form.Get("name"))
+	if utf8.RuneCountInString(name) > savedsearch.MaxNameLength {
+		http.Error(w, fmt.Sprintf("name must be at most %d characters", savedsearch.MaxNameLength), http.StatusBadRequest)
+		return
+	}
+
+	search, err := savedsearch.New(name, query, time.Now())
+	if err == nil {
+		err = store.Put(search)
+	}
+	if err != nil {
+		logger.Error("Failed to save search: %v", err)
+		http.Error(w, "failed to save search", http.StatusInternalServerError)
+		return
+	}
+
+	writeSearch(w, r, search, http.StatusCreated)
+}
+
+// validateSearch returns why a saved query would not do what it says, or "".
+func validateSearch(query url.Values) string {
+	if _, ok := ParseCircle(query.Get("lat"), query.Get("lon"), query.Get("radiusKm")); !ok &&
+		(query.Has("lat") || query.Has("lon") || query.Has("radiusKm")) {
+		return "lat, lon and radiusKm must be given together and be valid"
+	}
+	if query.Has("days") {
+		if _, ok := parseWindowDays(query.Get("days")); !ok {
+			return "days must be a whole number from 1 to 366"
+		}
+	}
+	for _, key := range []string{"dateFrom", "dateTo"} {
+		if query.Has(key) {
+			if _, err := daterange.ParseDay(query.Get(key)); err != nil {
+				return key + " must be a day like 01.08.2026"
+			}
+		}
+	}
+	return ""
+}
+
+// GetSearch returns a saved search.
+func GetSearch(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	search, ok := lookupSearch(w, r.PathValue("token"))
+	if !ok {
+		return
+	}
+	writeSearch(w, r, search, http.StatusOK)
+}
+
+// DeleteSearch deletes a saved search. Deleting an unknown token succeeds, so
+// a retried request does not fail.
+func DeleteSearch(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	store := SavedSearches()
+	if store == nil {
+		http.Error(w, "saved searches are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	if err := store.Delete(r.PathValue("token")); err != nil {
+		logger.Error("Failed to delete saved search: %v", err)
+		http.Error(w, "failed to delete search", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFeed serves the tournaments matching a saved search as an Atom feed,
+// newest first.
+//
+// An entry's id is derived from the tournament's canonical id, so it stays
+// the same however often the feed is polled and readers only highlight
+// tournaments they have not seen. "Newest" is when the catalog first saw the
+// tournament, so a tournament published today is on top even if it takes
+// place in two months; its updated time moves when its data changes.
+func GetFeed(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	token, ok := strings.CutSuffix(r.PathValue("feed"), ".atom")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	search, ok := lookupSearch(w, token)
+	if !ok {
+		return
+	}
+
+	tournaments, _ := searchTournaments(r.Context(), federation.GetFederations(), parseSearchValues(search.Query, time.Now()))
+
+	title := search.Name
+	if title == "" {
+		title = "Tennisturniere"
+	}
+	feed := atom.Feed{
+		ID:      feedTagPrefix + "searches/" + search.Token,
+		Title:   title,
+		SelfURL: requestBase(r) + r.URL.Path,
+		Author:  "Tennis Tournament Finder",
+		Entries: feedEntries(tournaments),
+		// Without entries the feed last changed when it was created; a
+		// fixed time keeps readers from reporting a change on every poll.
+		Updated: search.CreatedAt,
+	}
+	for _, e := range feed.Entries {
+		if e.Updated.After(feed.Updated) {
+			feed.Updated = e.Updated
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if err := feed.Write(w); err != nil {
+		logger.Error("Failed to write feed: %v", err)
+	}
+}
+
+// feedEntries turns tournaments into entries, newest first. Tournaments the
+// catalog does not know (it may be disabled) have no publication time and
+// follow the others by date.
+func feedEntries(tournaments []models.Tournament) []atom.Entry {
+	type dated struct {
+		entry atom.Entry
+		start string
+	}
+	items := make([]dated, 0, len(tournaments))
+	for _, t := range tournaments {
+		id := t.CanonicalId
+		if id == "" {
+			id = tournamentid.Of(t)
+		}
+		entry := atom.Entry{
+			ID:      feedTagPrefix + id,
+			Title:   t.Title,
+			URL:     t.URL,
+			Summary: feedSummary(t),
+		}
+		if t.Status == models.StatusPossiblyCancelled {
+			entry.Title = "Evtl. abgesagt: " + t.Title
+		}
+		if record, found, err := Catalog().Get(id); err == nil && found {
+			entry.Published = record.FirstSeen
+			entry.Updated = record.Changed()
+		}
+		items = append(items, dated{entry: entry, start: t.StartDate})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].entry, items[j].entry
+		if !a.Published.Equal(b.Published) {
+			return a.Published.After(b.Published)
+		}
+		if items[i].start != items[j].start {
+			return items[i].start < items[j].start
+		}
+		return a.ID < b.ID
+	})
+
+	entries := make([]atom.Entry, len(items))
+	for i, item := range items {
+		entries[i] = item.entry
+	}
+	return entries
+}
+
+// feedSummary leads with the date and place, then lists what the calendar
+// export does.
+func feedSummary(t models.Tournament) string {
+	lines := []string{t.Date}
+	if t.Location != "" {
+		lines = append(lines, t.Location)
+	}
+	if description := eventDescription(t); description != "" {
+		lines = append(lines, description)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lookupSearch reads a saved search, answering the request itself when it
+// cannot be served.
+func lookupSearch(w http.ResponseWriter, token string) (savedsearch.Search, bool) {
+	store := SavedSearches()
+	if store == nil {
+		http.Error(w, "saved searches are disabled", http.StatusServiceUnavailable)
+		return savedsearch.Search{}, false
+	}
+	search, found, err := store.Get(token)
+	if err != nil {
+		logger.Error("Failed to read saved search: %v", err)
+		http.Error(w, "failed to read saved search", http.StatusInternalServerError)
+		return savedsearch.Search{}, false
+	}
+	if !found {
+		http.Error(w, "search not found", http.StatusNotFound)
+		return savedsearch.Search{}, false
+	}
+	return search, true
+}
+
+func writeSearch(w http.ResponseWriter, r *http.Request, search savedsearch.Search, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(SavedSearchResponse{
+		Search:  search,
+		FeedURL: requestBase(r) + "/feeds/" + search.Token + ".atom",
+	}); err != nil {
+		logger.Error("Failed to encode saved search response: %v", err)
+	}
+}
+
+// requestBase returns the scheme and host the request was addressed to,
+// honouring a TLS-terminating proxy.
+func requestBase(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
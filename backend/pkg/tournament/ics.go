@@ -0,0 +1,148 @@
+package tournament
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ical"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
+)
+
+// ICSPath serves a search as an iCalendar file.
+const ICSPath = "/tournaments.ics"
+
+// uidDomain qualifies event UIDs so they cannot collide with events from
+// other calendars.
+const uidDomain = "tennis-tournament-finder"
+
+// GetTournamentsICS serves the same search as GetTournaments as an RFC 5545
+// calendar with one all-day event per tournament.
+//
+// Calendar apps subscribe to the URL and poll it, so the UID of an event is
+// derived from the tournament id only: a changed date or title then updates
+// the existing event instead of adding a second one. For the same reason a
+// search no federation answered is a 503, not an empty calendar: the app keeps
+// the events it has instead of deleting them all.
+func GetTournamentsICS(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	tournaments, results := searchTournaments(r.Context(), federation.GetFederations(), parseSearchQuery(r))
+	if noFederationAnswered(results) {
+		http.Error(w, "no federation could be reached", http.StatusServiceUnavailable)
+		return
+	}
+
+	calendar := ical.Calendar{
+		ProdID: "-//Tennis Tournament Finder//DE",
+		Name:   "Tennisturniere",
+		Events: make([]ical.Event, 0, len(tournaments)),
+	}
+
+	skipped := 0
+	for _, t := range tournaments {
+		event, ok := tournamentEvent(t)
+		if !ok {
+			skipped++
+			continue
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+	if skipped > 0 {
+		// Their texts are listed at /stats/unparsed-dates.
+		logger.Info("ICS export: skipped %d tournaments without a parsed date", skipped)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tournaments.ics"`)
+	if err := calendar.Write(w); err != nil {
+		logger.Error("Failed to write ICS response: %v", err)
+	}
+}
+
+// noFederationAnswered reports that federations were searched and every one
+// failed without returning anything.
+func noFederationAnswered(results []FederationResult) bool {
+	for _, res := range results {
+		if res.Status() != "error" {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// tournamentEvent converts a tournament. Tournaments without a parsed date
+// cannot be placed in a calendar and are reported as not ok.
+func tournamentEvent(t models.Tournament) (ical.Event, bool) {
+	start, err := daterange.ParseISO(t.StartDate)
+	if err != nil {
+		return ical.Event{}, false
+	}
+	end, err := daterange.ParseISO(t.EndDate)
+	if err != nil {
+		end = start
+	}
+
+	event := ical.Event{
+		UID:         eventUID(t),
+		Summary:     t.Title,
+		Start:       start,
+		End:         end,
+		Location:    t.Location,
+		Description: eventDescription(t),
+		URL:         t.URL,
+		Organizer:   t.Organizer,
+		Lat:         t.Lat,
+		Lon:         t.Lon,
+	}
+	if t.Status == models.StatusPossiblyCancelled {
+		// Not CANCELLED: the federation only stopped listing it.
+		event.Status = "TENTATIVE"
+		event.Summary = "Evtl. abgesagt: " + t.Title
+	}
+	return event, true
+}
+
+// eventUID derives the UID from the canonical id, so federations sharing an
+// upstream id space do not overwrite each other's events. CollectTournaments
+// sets it on every tournament; the plain id is only a last resort. An id-less
+// tournament gets its content hash (see tournamentid.Hash), which includes the
+// start date, so each date of a recurring tournament is an event of its own.
+func eventUID(t models.Tournament) string {
+	id := t.CanonicalId
+	if id == "" {
+		id = tournamentid.Of(t)
+	}
+	return "tournament-" + id + "@" + uidDomain
+}
+
+// eventDescription lists the organizer and the competitions with their LK
+// ranges, one per line.
+func eventDescription(t models.Tournament) string {
+	var b strings.Builder
+	if t.Organizer != "" {
+		b.WriteString("Veranstalter: " + t.Organizer + "\n")
+	}
+	if len(t.Entries) > 0 {
+		b.WriteString("Konkurrenzen:\n")
+		for _, e := range t.Entries {
+			b.WriteString("- " + e.Competition)
+			if level := e.SkillLevel; level != "" {
+				// Some federations publish "LK 12,0", others a bare range.
+				if !strings.HasPrefix(level, "LK") {
+					level = "LK " + level
+				}
+				b.WriteString(" (" + level + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
+	if t.URL != "" {
+		b.WriteString(t.URL)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,393 @@
+package tournament
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/savedsearch"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/webpush"
+)
+
+// Web Push routes.
+const (
+	PushKeyPattern       = "GET /push/key"
+	SubscribePattern     = "POST /push/subscriptions"
+	UnsubscribePattern   = "DELETE /push/subscriptions"
+	PushPreflightPattern = "OPTIONS /push/subscriptions"
+)
+
+const (
+	// maxSubscriptionBytes bounds a subscription request body.
+	maxSubscriptionBytes = 4 << 10
+	// pushTTL is how long a push service holds a notification for a device
+	// that is offline. A day later the next warmup has news of its own.
+	pushTTL = 24 * time.Hour
+	// maxNotificationEntries is how many tournaments a notification lists.
+	maxNotificationEntries = 5
+	// maxNotificationText is how many characters the title and each listed
+	// tournament keep. Even if every character is escaped, title and body
+	// stay below webpush.MaxPayload; a larger payload is never delivered.
+	maxNotificationText = 80
+)
+
+// pushService holds what the Web Push endpoints and the notifier need. Both
+// are nil when Web Push is disabled.
+var (
+	pushService struct {
+		store  webpush.Store
+		sender *webpush.Sender
+	}
+	pushMu sync.RWMutex
+)
+
+// SetPush installs the subscription store and the sender notifications are
+// delivered with.
+func SetPush(store webpush.Store, sender *webpush.Sender) {
+	pushMu.Lock()
+	defer pushMu.Unlock()
+	pushService.store = store
+	pushService.sender = sender
+}
+
+// Push returns the installed subscription store and sender, or nils.
+func Push() (webpush.Store, *webpush.Sender) {
+	pushMu.RLock()
+	defer pushMu.RUnlock()
+	return pushService.store, pushService.sender
+}
+
+// PushKeyResponse carries the VAPID public key the frontend subscribes with.
+type PushKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+// SubscribeRequest subscribes a browser to a saved search. Subscription is
+// what PushSubscription.toJSON() returns.
+type SubscribeRequest struct {
+	Subscription webpush.Subscription `json:"subscription"`
+	Search       string               `json:"search"`
+}
+
+// SubscribeResponse confirms a subscription.
+type SubscribeResponse struct {
+	Search    string    `json:"search"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PushNotification is the payload the service worker shows. URL is the
+// tournament to open when a single one is announced; otherwise the app opens.
+type PushNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+	// Tag lets a newer notification for the same search replace an older
+	// one on the device.
+	Tag string `json:"tag"`
+}
+
+// pushTopic names a saved search towards the push service and the device
+// without revealing its token, which grants access to the search: a hash,
+// shortened to the 32 characters a Topic header may have.
+func pushTopic(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// GetPushKey returns the VAPID public key.
+func GetPushKey(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	_, sender := Push()
+	if sender == nil {
+		http.Error(w, "web push is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(PushKeyResponse{PublicKey: sender.Keys.PublicKey()}); err != nil {
+		logger.Error("Failed to encode push key response: %v", err)
+	}
+}
+
+// Subscribe stores a push subscription for a saved search. Subscribing an
+// endpoint again replaces its search; it is only told about tournaments
+// found from then on.
+func Subscribe(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	store, _ := Push()
+	if store == nil {
+		http.Error(w, "web push is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req SubscribeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubscriptionBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid subscription", http.StatusBadRequest)
+		return
+	}
+	if err := req.Subscription.Validate(); err != nil {
+		http.Error(w, "invalid subscription: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	search, ok := lookupSearch(w, req.Search)
+	if !ok {
+		return
+	}
+
+	// Run the search once, so the catalog knows what it finds today and only
+	// later additions are news. It is usually answered from the cache: the
+	// user has just searched.
+	searchTournaments(r.Context(), federation.GetFederations(), parseSearchValues(search.Query, time.Now()))
+
+	now := time.Now()
+	record := webpush.Record{
+		Subscription: req.Subscription,
+		Search:       req.Search,
+		CreatedAt:    now,
+		LastNotified: now,
+	}
+	if err := store.Put(record); err != nil {
+		logger.Error("Failed to store push subscription: %v", err)
+		http.Error(w, "failed to store subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(SubscribeResponse{Search: record.Search, CreatedAt: record.CreatedAt}); err != nil {
+		logger.Error("Failed to encode subscription response: %v", err)
+	}
+}
+
+// Unsubscribe deletes the subscription for the endpoint in the body, which
+// may be the whole PushSubscription.toJSON(). Deleting an unknown endpoint
+// succeeds, so a retried request does not fail.
+func Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+
+	store, _ := Push()
+	if store == nil {
+		http.Error(w, "web push is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubscriptionBytes)).Decode(&req); err != nil || req.Endpoint == "" {
+		http.Error(w, "endpoint is required", http.StatusBadRequest)
+		return
+	}
+	if err := store.Delete(req.Endpoint); err != nil {
+		logger.Error("Failed to delete push subscription: %v", err)
+		http.Error(w, "failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PushPreflight answers the CORS preflight browsers send before the JSON
+// requests to /push/subscriptions.
+func PushPreflight(w http.ResponseWriter, r *http.Request) {
+	util.EnableCors(&w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Max-Age", "86400")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NotifySubscribers tells every subscriber about tournaments that appeared in
+// their saved search since they were last notified, and returns how many
+// notifications were delivered. The scheduler runs it after each warmup, so
+// the searches are answered from the freshly filled cache.
+//
+// A tournament is new when the catalog first saw it after the subscriber's
+// last notification. Without a persistent catalog there is no telling, and
+// nothing is sent; neither is anything to a subscriber last notified before
+// the catalog was created. Subscriptions the push service reports as expired, those whose
+// endpoint resolves to a non-public address, and those whose saved search
+// was deleted, are removed. Other failures keep the subscription as it was,
+// so the news is retried after the next warmup.
+func NotifySubscribers(ctx context.Context) int {
+	store, sender := Push()
+	searches := SavedSearches()
+	if store == nil || sender == nil || searches == nil {
+		return 0
+	}
+	if Catalog() == nil {
+		logger.Info("Push notifications skipped: the tournament catalog is disabled")
+		return 0
+	}
+	// A catalog in memory starts over on every restart, and everything it
+	// finds would be announced as new.
+	if !Catalog().Persistent() {
+		logger.Info("Push notifications skipped: the tournament catalog is not persistent")
+		return 0
+	}
+	since := Catalog().Since()
+
+	now := time.Now()
+	// Subscribers often share a search; run each one once.
+	results := make(map[string][]models.Tournament)
+	sent := 0
+
+	err := store.ForEach(func(record webpush.Record) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		search, found, err := searches.Get(record.Search)
+		if err != nil {
+			logger.Error("Push: failed to read saved search: %v", err)
+			return nil
+		}
+		if !found {
+			logger.Info("Push: removing subscription to deleted search")
+			return deleteSubscription(store, record)
+		}
+
+		tournaments, ok := results[search.Token]
+		if !ok {
+			tournaments, _ = searchTournaments(ctx, federation.GetFederations(), parseSearchValues(search.Query, now))
+			results[search.Token] = tournaments
+		}
+
+		// The catalog was created after the last notification, e.g. when
+		// its file was lost: what it saw first is not news. Announce from
+		// now on.
+		if record.LastNotified.Before(since) {
+			logger.Info("Push: catalog is newer than a subscription, skipping it once")
+			_, record.LastNotified = newTournaments(tournaments, now)
+			if err := store.Put(record); err != nil {
+				logger.Error("Push: failed to update subscription: %v", err)
+			}
+			return nil
+		}
+
+		fresh, latest := newTournaments(tournaments, record.LastNotified)
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		payload, err := json.Marshal(notification(search, fresh))
+		if err != nil {
+			return err
+		}
+		err = sender.Send(ctx, record.Subscription, webpush.Message{Payload: payload, TTL: pushTTL, Topic: pushTopic(search.Token)})
+		switch {
+		case errors.Is(err, webpush.ErrGone):
+			logger.Info("Push: removing expired subscription")
+			return deleteSubscription(store, record)
+		case errors.Is(err, webpush.ErrEndpoint):
+			logger.Info("Push: removing subscription to a non-public endpoint: %v", err)
+			return deleteSubscription(store, record)
+		case err != nil:
+			logger.Error("Push: delivery failed: %v", err)
+			return nil
+		}
+
+		sent++
+		// Not now: a search that had to fetch may have put tournaments
+		// into the catalog after now, and they were just announced.
+		record.LastNotified = latest
+		if err := store.Put(record); err != nil {
+			logger.Error("Push: failed to update subscription: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Push: notifying subscribers stopped: %v", err)
+	}
+	return sent
+}
+
+// newTournaments returns the tournaments the catalog first saw after since,
+// leaving out those that are possibly cancelled, and when the latest of them
+// was first seen.
+func newTournaments(tournaments []models.Tournament, since time.Time) ([]models.Tournament, time.Time) {
+	var fresh []models.Tournament
+	latest := since
+	for _, t := range tournaments {
+		if t.Status == models.StatusPossiblyCancelled {
+			continue
+		}
+		id := t.CanonicalId
+		if id == "" {
+			id = tournamentid.Of(t)
+		}
+		if record, found, err := Catalog().Get(id); err == nil && found && record.FirstSeen.After(since) {
+			fresh = append(fresh, t)
+			if record.FirstSeen.After(latest) {
+				latest = record.FirstSeen
+			}
+		}
+	}
+	return fresh, latest
+}
+
+// notification describes the new tournaments of a search. The body lists the
+// first few, and long names are shortened, which keeps the payload below the
+// push size limit.
+func notification(search savedsearch.Search, fresh []models.Tournament) PushNotification {
+	n := PushNotification{Tag: pushTopic(search.Token)}
+	if len(fresh) == 1 {
+		n.Title = "Neues Turnier"
+		n.URL = fresh[0].URL
+	} else {
+		n.Title = fmt.Sprintf("%d neue Turniere", len(fresh))
+	}
+	if search.Name != "" {
+		n.Title = shorten(n.Title+": "+search.Name, maxNotificationText)
+	}
+
+	lines := make([]string, 0, maxNotificationEntries+1)
+	for i, t := range fresh {
+		if i == maxNotificationEntries {
+			lines = append(lines, fmt.Sprintf("und %d weitere", len(fresh)-i))
+			break
+		}
+		line := t.Title
+		if t.Date != "" {
+			line = t.Date + " " + line
+		}
+		lines = append(lines, shorten(line, maxNotificationText))
+	}
+	n.Body = strings.Join(lines, "\n")
+
+	// The URL only saves a tap; drop one that does not fit.
+	if raw, err := json.Marshal(n); err == nil && len(raw) > webpush.MaxPayload {
+		n.URL = ""
+	}
+	return n
+}
+
+// shorten cuts s to at most n characters, marking the cut with an ellipsis.
+func shorten(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+func deleteSubscription(store webpush.Store, record webpush.Record) error {
+	if err := store.Delete(record.Subscription.Endpoint); err != nil {
+		logger.Error("Push: failed to delete subscription: %v", err)
+	}
+	return nil
+}
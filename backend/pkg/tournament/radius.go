@@ -0,0 +1,93 @@
+package tournament
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/geo"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// Circle is the area of a radius search.
+type Circle struct {
+	Lat      float64
+	Lon      float64
+	RadiusKm float64
+}
+
+// ParseCircle reads the lat/lon/radiusKm query parameters. It reports false
+// when any is missing or invalid, in which case no radius search is done.
+func ParseCircle(lat, lon, radiusKm string) (Circle, bool) {
+	if lat == "" || lon == "" || radiusKm == "" {
+		return Circle{}, false
+	}
+
+	c := Circle{}
+	var err error
+	if c.Lat, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil || c.Lat < -90 || c.Lat > 90 {
+		return Circle{}, false
+	}
+	if c.Lon, err = strconv.ParseFloat(strings.TrimSpace(lon), 64); err != nil || c.Lon < -180 || c.Lon > 180 {
+		return Circle{}, false
+	}
+	if c.RadiusKm, err = strconv.ParseFloat(strings.TrimSpace(radiusKm), 64); err != nil || c.RadiusKm <= 0 {
+		return Circle{}, false
+	}
+	return c, true
+}
+
+// FederationsNear keeps the federations whose territory the circle reaches.
+// A federation without Bounds is kept, since there is no way to rule it out.
+func FederationsNear(federations []models.Federation, c Circle) []models.Federation {
+	var near []models.Federation
+	for _, fed := range federations {
+		if fed.Bounds == nil || geo.CircleIntersectsBounds(c.Lat, c.Lon, c.RadiusKm, *fed.Bounds) {
+			near = append(near, fed)
+		}
+	}
+	return near
+}
+
+// FilterByRadius keeps the tournaments inside the circle, nearest first, and
+// sets their DistanceKm.
+//
+// Tournaments pinned at their federation's default have no real location, so
+// no distance can be given. They are kept, without a distance and after all
+// measured ones, matching the frontend: dropping them would silently hide
+// tournaments that may well be nearby.
+func FilterByRadius(tournaments []models.Tournament, c Circle) []models.Tournament {
+	// Non-nil, so an empty result still encodes as [] rather than null.
+	measured := make([]models.Tournament, 0, len(tournaments))
+	var unplaced []models.Tournament
+
+	for _, t := range tournaments {
+		if t.ApproximateLocation {
+			t.DistanceKm = nil
+			unplaced = append(unplaced, t)
+			continue
+		}
+
+		lat, errLat := strconv.ParseFloat(t.Lat, 64)
+		lon, errLon := strconv.ParseFloat(t.Lon, 64)
+		if errLat != nil || errLon != nil {
+			continue
+		}
+
+		distance := geo.DistanceKm(c.Lat, c.Lon, lat, lon)
+		if distance > c.RadiusKm {
+			continue
+		}
+		// Rounded to 100 m: more digits would claim a precision club-level
+		// coordinates do not have.
+		rounded := float64(int(distance*10+0.5)) / 10
+		t.DistanceKm = &rounded
+		measured = append(measured, t)
+	}
+
+	sort.SliceStable(measured, func(i, j int) bool {
+		return *measured[i].DistanceKm < *measured[j].DistanceKm
+	})
+
+	return append(measured, unplaced...)
+}
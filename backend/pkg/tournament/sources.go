@@ -0,0 +1,52 @@
+package tournament
+
+import (
+	"context"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/source"
+)
+
+// The built-in platforms. They are registered here rather than in their own
+// packages because both nuLiga parsers live in this package and the BTV client
+// is deliberately kept free of geocoding.
+func init() {
+	source.Register("old", nuLigaOldSource{})
+	source.Register("new", nuLigaNewSource{})
+	source.Register("btv", btvSource{})
+}
+
+// nuLigaOldSource serves federations on the liga.nu form-post calendar.
+type nuLigaOldSource struct{}
+
+func (nuLigaOldSource) Fetch(ctx context.Context, fed models.Federation, q source.Query) ([]models.Tournament, error) {
+	return getTournamentsFromFederationOldApi(ctx, fed, q.DateFrom, q.DateTo, q.CompType)
+}
+
+func (nuLigaOldSource) Capabilities() source.Capabilities {
+	return source.Capabilities{DateFilter: true}
+}
+
+// nuLigaNewSource serves federations on the TYPO3/nuPortal calendar.
+type nuLigaNewSource struct{}
+
+func (nuLigaNewSource) Fetch(ctx context.Context, fed models.Federation, q source.Query) ([]models.Tournament, error) {
+	return getTournamentsFromFederationNewApi(ctx, fed, q.DateFrom, q.DateTo, q.CompType)
+}
+
+func (nuLigaNewSource) Capabilities() source.Capabilities {
+	return source.Capabilities{DateFilter: true}
+}
+
+// btvSource serves Bavaria's ZK widget through pkg/btv.
+type btvSource struct{}
+
+func (btvSource) Fetch(ctx context.Context, fed models.Federation, q source.Query) ([]models.Tournament, error) {
+	return getTournamentsFromBTV(ctx, fed, q.DateFrom, q.DateTo)
+}
+
+func (btvSource) Capabilities() source.Capabilities {
+	// The window is pushed into the widget where possible and always applied
+	// locally, so results are restricted either way.
+	return source.Capabilities{DateFilter: true}
+}
@@ -3,10 +3,12 @@ package tournament
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,14 +16,22 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/btv"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/catalog"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/competition"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/daterange"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/geoqueue"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/openstreetmap"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/placename"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/resultcache"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/skilllevel"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/source"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/tournamentid"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/undated"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/unresolved"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/util"
 )
@@ -40,12 +50,51 @@ const (
 )
 
 // geocoder resolves tournament coordinates. The indirection lets tests supply a
-// deterministic implementation instead of performing network lookups.
-type geocoder func(fed models.Federation, tournament models.Tournament) models.Geocoordinates
+// deterministic implementation instead of performing network lookups. ctx is
+// the request's: once it is cancelled no further upstream lookup may start.
+type geocoder func(ctx context.Context, fed models.Federation, tournament models.Tournament) models.Geocoordinates
+
+// defaultGeocoder delegates to the OpenStreetMap cache/lookup. With a geocode
+// queue installed it answers from the cache only and queues misses, returning
+// a Pending result instead of waiting for Nominatim.
+func defaultGeocoder(ctx context.Context, fed models.Federation, tournament models.Tournament) models.Geocoordinates {
+	queue := GeocodeQueue()
+	if queue == nil {
+		return openstreetmap.GetGeocoordinatesForFederation(ctx, fed, tournament)
+	}
+
+	geo, status := openstreetmap.CachedGeocoordinatesForFederation(fed, tournament)
+	if status != openstreetmap.CacheMiss {
+		return geo
+	}
 
-// defaultGeocoder delegates to the OpenStreetMap cache/lookup.
-func defaultGeocoder(fed models.Federation, tournament models.Tournament) models.Geocoordinates {
-	return openstreetmap.GetGeocoordinatesForFederation(fed, tournament)
+	if _, err := queue.Enqueue(fed, tournament, ratelimit.PriorityFrom(ctx)); err != nil {
+		// Without the queue the lookup would never happen; do it now.
+		logger.Error("Failed to queue geocoding for tournament %s: %v", tournament.Id, err)
+		return openstreetmap.GetGeocoordinatesForFederation(ctx, fed, tournament)
+	}
+	return models.Geocoordinates{Pending: true}
+}
+
+// geocodeQueue takes lookups off the request path. It is nil when geocoding
+// runs synchronously, which is what tests and the command-line tools use.
+var (
+	geocodeQueue   *geoqueue.Queue
+	geocodeQueueMu sync.RWMutex
+)
+
+// SetGeocodeQueue installs the queue used by the default geocoder.
+func SetGeocodeQueue(q *geoqueue.Queue) {
+	geocodeQueueMu.Lock()
+	defer geocodeQueueMu.Unlock()
+	geocodeQueue = q
+}
+
+// GeocodeQueue returns the installed queue, or nil.
+func GeocodeQueue() *geoqueue.Queue {
+	geocodeQueueMu.RLock()
+	defer geocodeQueueMu.RUnlock()
+	return geocodeQueue
 }
 
 // FederationResult carries a single federation's outcome.
@@ -61,6 +110,11 @@ type FederationResult struct {
 	Stale bool
 	// Age is how old the returned data is; zero for a fresh fetch.
 	Age time.Duration
+	// Revalidating reports that expired cached data was served while a
+	// background refresh replaces it.
+	Revalidating bool
+	// Segments lists the cached windows a cached result was composed from.
+	Segments []resultcache.Segment
 }
 
 // Status summarises a federation result for API consumers.
@@ -98,6 +152,27 @@ func ResultCache() *resultcache.Cache {
 	return resultCache
 }
 
+// tournamentCatalog records every tournament a refresh returns. It is nil when
+// the catalog is disabled.
+var (
+	tournamentCatalog   *catalog.Catalog
+	tournamentCatalogMu sync.RWMutex
+)
+
+// SetCatalog installs the catalog CollectTournaments upserts into.
+func SetCatalog(c *catalog.Catalog) {
+	tournamentCatalogMu.Lock()
+	defer tournamentCatalogMu.Unlock()
+	tournamentCatalog = c
+}
+
+// Catalog returns the installed catalog, or nil.
+func Catalog() *catalog.Catalog {
+	tournamentCatalogMu.RLock()
+	defer tournamentCatalogMu.RUnlock()
+	return tournamentCatalog
+}
+
 // FederationStatus is the per-federation metadata returned alongside results,
 // so clients can tell "no tournaments" apart from "this source failed".
 type FederationStatus struct {
@@ -109,6 +184,19 @@ type FederationStatus struct {
 	AgeSeconds int `json:"age_seconds,omitempty"`
 	// Message is a short, non-sensitive error description.
 	Message string `json:"message,omitempty"`
+	// Revalidating reports that the data is being refreshed in the
+	// background; the next request gets the new copy.
+	Revalidating bool `json:"revalidating,omitempty"`
+	// Segments lists the cached date windows the result was assembled from,
+	// each with its own age. It is omitted for a fresh fetch.
+	Segments []SegmentStatus `json:"segments,omitempty"`
+}
+
+// SegmentStatus describes one cached date window behind a federation result.
+type SegmentStatus struct {
+	DateFrom   string `json:"date_from"`
+	DateTo     string `json:"date_to"`
+	AgeSeconds int    `json:"age_seconds"`
 }
 
 // TournamentsResponse is the richer response shape.
@@ -129,14 +217,22 @@ func buildFederationStatuses(results []FederationResult) ([]FederationStatus, bo
 
 	for _, res := range results {
 		status := FederationStatus{
-			Id:     res.Federation.Id,
-			Name:   res.Federation.Name,
-			Status: res.Status(),
-			Count:  len(res.Tournaments),
+			Id:           res.Federation.Id,
+			Name:         res.Federation.Name,
+			Status:       res.Status(),
+			Count:        len(res.Tournaments),
+			Revalidating: res.Revalidating,
 		}
 		if res.Age > 0 {
 			status.AgeSeconds = int(res.Age.Seconds())
 		}
+		for _, seg := range res.Segments {
+			status.Segments = append(status.Segments, SegmentStatus{
+				DateFrom:   seg.DateFrom,
+				DateTo:     seg.DateTo,
+				AgeSeconds: int(seg.Age.Seconds()),
+			})
+		}
 		if res.Err != nil {
 			// Keep the message short and free of internal detail.
 			status.Message = "Turnierdaten konnten nicht aktualisiert werden"
@@ -158,11 +254,20 @@ func buildFederationStatuses(results []FederationResult) ([]FederationStatus, bo
 // Entries without a published LK range are kept: a competition that does not
 // state a restriction is open, and hiding it would lose real tournaments.
 func FilterByLK(tournaments []models.Tournament, playerLK float64) []models.Tournament {
+	return filterEntries(tournaments, func(_ models.Tournament, e models.CompetitionEntry) (models.CompetitionEntry, bool) {
+		range_, ok := skilllevel.Parse(e.SkillLevel)
+		return e, !ok || range_.Includes(playerLK)
+	})
+}
+
+// filterEntries keeps the competition entries of each tournament that keep
+// accepts, in the form it returns them, and drops tournaments left without
+// any. A tournament without parsed entries carries no information to filter
+// on, so it stays visible rather than being silently dropped.
+func filterEntries(tournaments []models.Tournament, keep func(t models.Tournament, e models.CompetitionEntry) (models.CompetitionEntry, bool)) []models.Tournament {
 	filtered := make([]models.Tournament, 0, len(tournaments))
 
 	for _, t := range tournaments {
-		// A tournament without parsed entries carries no LK information at
-		// all, so it stays visible rather than being silently dropped.
 		if len(t.Entries) == 0 {
 			filtered = append(filtered, t)
 			continue
@@ -170,8 +275,7 @@ func FilterByLK(tournaments []models.Tournament, playerLK float64) []models.Tour
 
 		kept := make([]models.CompetitionEntry, 0, len(t.Entries))
 		for _, e := range t.Entries {
-			range_, ok := skilllevel.Parse(e.SkillLevel)
-			if !ok || range_.Includes(playerLK) {
+			if e, ok := keep(t, e); ok {
 				kept = append(kept, e)
 			}
 		}
@@ -188,6 +292,42 @@ func FilterByLK(tournaments []models.Tournament, playerLK float64) []models.Tour
 	return filtered
 }
 
+// FilterByRegistrationOpen drops tournaments whose registration deadline lies
+// before today. Tournaments without a parsed deadline are kept, as with the
+// other filters: a deadline that is not published cannot rule a tournament out.
+func FilterByRegistrationOpen(tournaments []models.Tournament, today time.Time) []models.Tournament {
+	day := today.Format(daterange.ISOLayout)
+
+	filtered := make([]models.Tournament, 0, len(tournaments))
+	for _, t := range tournaments {
+		// ISO days compare correctly as strings.
+		if t.RegistrationDeadline == "" || t.RegistrationDeadline >= day {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// FilterByCompType keeps the competition entries matching the frontend's
+// compType filter, e.g. "Herren+Einzel", and drops tournaments left without
+// any. An empty or unrecognised filter keeps everything.
+//
+// Like FilterByLK, it gives the benefit of the doubt: tournaments without
+// parsed entries, and entries whose name does not state a property, stay.
+func FilterByCompType(tournaments []models.Tournament, compType string) []models.Tournament {
+	filter, ok := competition.ParseFilter(compType)
+	if !ok {
+		if compType != "" {
+			logger.Warn("Ignoring unknown competition type: %s", compType)
+		}
+		return tournaments
+	}
+
+	return filterEntries(tournaments, func(_ models.Tournament, e models.CompetitionEntry) (models.CompetitionEntry, bool) {
+		return e, filter.Matches(competition.Parse(e.Competition))
+	})
+}
+
 func GetTournaments(w http.ResponseWriter, r *http.Request) {
 	federations := federation.GetFederations()
 
@@ -204,39 +344,7 @@ func GetTournaments(w http.ResponseWriter, r *http.Request) {
 		openstreetmap.CleanupOldFailedEntries()
 	}
 
-	today := time.Now()
-	dateFrom := r.URL.Query().Get("dateFrom")
-	if dateFrom == "" {
-		dateFrom = today.Format("02.01.2006")
-	}
-	dateTo := r.URL.Query().Get("dateTo")
-	if dateTo == "" {
-		dateIn14Days := today.Add(time.Hour * 336)
-		dateTo = dateIn14Days.Format("02.01.2006")
-	}
-	compType := r.URL.Query().Get("compType")
-	selectedFederations := r.URL.Query().Get("federations")
-	playerLKParam := r.URL.Query().Get("lk")
-
-	logger.Info("Get Tournaments from: %s to: %s, compType: %s, federations: %s, lk: %s",
-		dateFrom, dateTo, compType, selectedFederations, playerLKParam)
-
-	filteredFederations := FilterFederations(federations, selectedFederations)
-
-	tournaments, results := CollectTournaments(r.Context(), filteredFederations, dateFrom, dateTo, compType)
-
-	// LK filtering happens after the cache lookup on purpose: the cache stores
-	// the full federation result, so different player LKs share one cache
-	// entry instead of multiplying it.
-	if playerLKParam != "" {
-		if playerLK, ok := skilllevel.ParsePlayerLK(playerLKParam); ok {
-			before := len(tournaments)
-			tournaments = FilterByLK(tournaments, playerLK)
-			logger.Info("LK filter %.1f: %d -> %d tournaments", playerLK, before, len(tournaments))
-		} else {
-			logger.Warn("Ignoring invalid lk parameter: %q", playerLKParam)
-		}
-	}
+	tournaments, results := searchTournaments(r.Context(), federations, parseSearchQuery(r))
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -261,6 +369,142 @@ func GetTournaments(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// searchQuery holds the query parameters shared by every endpoint that lists
+// tournaments, so the JSON API and its exports always agree on what a search
+// means.
+type searchQuery struct {
+	DateFrom    string
+	DateTo      string
+	CompType    string
+	Federations string
+	PlayerLK    string
+	// BirthYear and Gender, with PlayerLK, form a player profile; see
+	// ParseProfile.
+	BirthYear string
+	Gender    string
+	// RegistrationOpen hides tournaments past their registration deadline.
+	RegistrationOpen bool
+	// Lat, Lon and RadiusKm opt into a radius search; see ParseCircle.
+	Lat      string
+	Lon      string
+	RadiusKm string
+}
+
+// parseSearchQuery reads a searchQuery from the request.
+func parseSearchQuery(r *http.Request) searchQuery {
+	return parseSearchValues(r.URL.Query(), time.Now())
+}
+
+// defaultWindowDays is how far ahead a search without dateTo looks.
+const defaultWindowDays = 14
+
+// maxWindowDays bounds the days parameter.
+const maxWindowDays = 366
+
+// searchParams are the parameters parseSearchValues reads, and so the ones a
+// saved search keeps.
+var searchParams = []string{
+	"dateFrom", "dateTo", "days", "compType", "federations", "lk",
+	"birthYear", "gender", "registrationOpen", "lat", "lon", "radiusKm",
+}
+
+// parseSearchValues reads a searchQuery from GetTournaments parameters. The
+// window starts today and ends days (default 14) from today, so a search
+// saved without dates moves along with the calendar.
+func parseSearchValues(query url.Values, today time.Time) searchQuery {
+	q := searchQuery{
+		DateFrom:         query.Get("dateFrom"),
+		DateTo:           query.Get("dateTo"),
+		CompType:         query.Get("compType"),
+		Federations:      query.Get("federations"),
+		PlayerLK:         query.Get("lk"),
+		BirthYear:        query.Get("birthYear"),
+		Gender:           query.Get("gender"),
+		RegistrationOpen: query.Get("registrationOpen") == "true",
+		Lat:              query.Get("lat"),
+		Lon:              query.Get("lon"),
+		RadiusKm:         query.Get("radiusKm"),
+	}
+	if q.DateFrom == "" {
+		q.DateFrom = today.Format("02.01.2006")
+	}
+	if q.DateTo == "" {
+		days, ok := parseWindowDays(query.Get("days"))
+		if !ok {
+			days = defaultWindowDays
+		}
+		q.DateTo = today.AddDate(0, 0, days).Format("02.01.2006")
+	}
+	return q
+}
+
+// parseWindowDays reads the days parameter: a whole number of days up to
+// maxWindowDays.
+func parseWindowDays(raw string) (int, bool) {
+	days, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || days < 1 || days > maxWindowDays {
+		return 0, false
+	}
+	return days, true
+}
+
+// searchTournaments runs a search against the selected federations.
+func searchTournaments(ctx context.Context, federations []models.Federation, q searchQuery) ([]models.Tournament, []FederationResult) {
+	logger.Info("Get Tournaments from: %s to: %s, compType: %s, federations: %s, lk: %s",
+		q.DateFrom, q.DateTo, q.CompType, q.Federations, q.PlayerLK)
+
+	filteredFederations := FilterFederations(federations, q.Federations)
+
+	// A radius search only fetches the federations the circle reaches, which
+	// is most of its point: a 50 km search touches one or two federations
+	// instead of all of them.
+	circle, radiusSearch := ParseCircle(q.Lat, q.Lon, q.RadiusKm)
+	if radiusSearch {
+		filteredFederations = FederationsNear(filteredFederations, circle)
+	} else if q.Lat != "" || q.Lon != "" || q.RadiusKm != "" {
+		logger.Warn("Ignoring incomplete or invalid radius search: lat=%q lon=%q radiusKm=%q", q.Lat, q.Lon, q.RadiusKm)
+	}
+
+	tournaments, results := CollectTournaments(ctx, filteredFederations, q.DateFrom, q.DateTo, q.CompType)
+
+	// LK and profile filtering happen after the cache lookup on purpose: the
+	// cache stores the full federation result, so different players share one
+	// cache entry instead of multiplying it.
+	if profile, ok := ParseProfile(q.BirthYear, q.Gender, q.PlayerLK); ok {
+		season := time.Now().Year()
+		if from, err := daterange.ParseDay(q.DateFrom); err == nil {
+			season = from.Year()
+		}
+		before := len(tournaments)
+		tournaments = FilterByProfile(tournaments, profile, season)
+		logger.Info("Profile filter (born %d, %s, lk %q): %d -> %d tournaments",
+			profile.Player.BirthYear, profile.Player.Gender, q.PlayerLK, before, len(tournaments))
+	} else if q.PlayerLK != "" {
+		if playerLK, ok := skilllevel.ParsePlayerLK(q.PlayerLK); ok {
+			before := len(tournaments)
+			tournaments = FilterByLK(tournaments, playerLK)
+			logger.Info("LK filter %.1f: %d -> %d tournaments", playerLK, before, len(tournaments))
+		} else {
+			logger.Warn("Ignoring invalid lk parameter: %q", q.PlayerLK)
+		}
+	}
+
+	if q.RegistrationOpen {
+		before := len(tournaments)
+		tournaments = FilterByRegistrationOpen(tournaments, time.Now())
+		logger.Info("Registration open: %d -> %d tournaments", before, len(tournaments))
+	}
+
+	if radiusSearch {
+		before := len(tournaments)
+		tournaments = FilterByRadius(tournaments, circle)
+		logger.Info("Radius %.0f km: %d federations, %d -> %d tournaments",
+			circle.RadiusKm, len(filteredFederations), before, len(tournaments))
+	}
+
+	return tournaments, results
+}
+
 // FilterFederations returns the federations selected by a comma-separated list
 // of IDs. An empty selection means "all federations".
 func FilterFederations(federations []models.Federation, selectedFederations string) []models.Federation {
@@ -285,8 +529,8 @@ func FilterFederations(federations []models.Federation, selectedFederations stri
 	return filtered
 }
 
-// InvalidateCache drops cached entries for the given federations and query, so
-// the next fetch goes upstream.
+// InvalidateCache marks cached entries overlapping the given federations and
+// query as outdated, so the next fetch goes upstream.
 //
 // Scheduled refreshes use this: without it a warmup would read the cache it is
 // meant to refresh and become a no-op.
@@ -297,12 +541,7 @@ func InvalidateCache(federations []models.Federation, dateFrom, dateTo, compType
 	}
 
 	for _, fed := range federations {
-		key := resultcache.Key{
-			FederationID: fed.Id,
-			DateFrom:     dateFrom,
-			DateTo:       dateTo,
-			CompType:     compType,
-		}
+		key := cacheKey(fed, upstreamQuery(fed, source.Query{DateFrom: dateFrom, DateTo: dateTo, CompType: compType}))
 		if err := cache.Invalidate(key); err != nil {
 			logger.Warn("Failed to invalidate cache for %s: %v", fed.Id, err)
 		}
@@ -318,7 +557,9 @@ func InvalidateCache(federations []models.Federation, dateFrom, dateTo, compType
 // one federation never discards results from the others.
 //
 // Results are served from the cache when available, so a user request usually
-// performs no upstream scraping at all.
+// performs no upstream scraping at all. Sources are scraped and cached for
+// their broadest query, and compType is applied to the parsed competitions
+// afterwards, so every competition filter shares one scrape.
 func CollectTournaments(ctx context.Context, federations []models.Federation, dateFrom, dateTo, compType string) ([]models.Tournament, []FederationResult) {
 	results := make([]FederationResult, len(federations))
 	cache := ResultCache()
@@ -340,15 +581,23 @@ func CollectTournaments(ctx context.Context, federations []models.Federation, da
 				}
 			}()
 
-			key := resultcache.Key{
-				FederationID: fed.Id,
-				DateFrom:     dateFrom,
-				DateTo:       dateTo,
-				CompType:     compType,
-			}
-
-			res := cache.Get(ctx, key, func(ctx context.Context, _ resultcache.Key) ([]models.Tournament, error) {
-				return fetchFederation(ctx, fed, dateFrom, dateTo, compType)
+			upstream := upstreamQuery(fed, source.Query{DateFrom: dateFrom, DateTo: dateTo, CompType: compType})
+			res := cache.Get(ctx, cacheKey(fed, upstream), func(ctx context.Context, _ resultcache.Key) ([]models.Tournament, error) {
+				tournaments, err := fetchFederation(ctx, fed, upstream)
+				// Every upstream fetch lands in the catalog, including the
+				// background ones and the pages a failed fetch did get.
+				refresh := catalogRefresh(fed, upstream, tournaments, err)
+				if _, err := Catalog().Upsert(refresh); err != nil {
+					logger.Error("Federation %s: failed to update the tournament catalog: %v", fed.Id, err)
+				} else if refresh.Complete {
+					tournaments = appendPossiblyCancelled(fed, refresh, tournaments)
+				}
+				// A listing the upstream did not restrict to the window
+				// is not complete, but nothing failed: cache it.
+				if errors.Is(err, source.ErrWindowIgnored) {
+					err = nil
+				}
+				return tournaments, err
 			})
 
 			if res.Err != nil {
@@ -360,11 +609,14 @@ func CollectTournaments(ctx context.Context, federations []models.Federation, da
 				}
 			}
 
-			results[idx].Tournaments = res.Tournaments
+			tournaments := withCanonicalIds(fed, refreshPendingLocations(ctx, fed, res.Tournaments))
+			results[idx].Tournaments = FilterByCompType(tournaments, compType)
 			results[idx].Err = res.Err
 			results[idx].Cached = res.Cached
 			results[idx].Stale = res.Stale
 			results[idx].Age = res.Age
+			results[idx].Revalidating = res.Revalidating
+			results[idx].Segments = res.Segments
 		}(i, fed)
 	}
 	wg.Wait()
@@ -377,41 +629,274 @@ func CollectTournaments(ctx context.Context, federations []models.Federation, da
 	return tournaments, results
 }
 
-// fetchFederation dispatches to the correct API implementation.
-func fetchFederation(ctx context.Context, fed models.Federation, dateFrom, dateTo, compType string) ([]models.Tournament, error) {
-	switch fed.ApiVersion {
-	case "old":
-		return getTournamentsFromFederationOldApi(ctx, fed, dateFrom, dateTo, compType)
-	case "new":
-		return getTournamentsFromFederationNewApi(ctx, fed, dateFrom, dateTo, compType)
-	case "btv":
-		// Bavaria runs its own ZK widget rather than nuLiga.
-		return getTournamentsFromBTV(ctx, fed)
-	default:
-		return nil, fmt.Errorf("unknown API version %q for federation %s", fed.ApiVersion, fed.Id)
+// refreshPendingLocations replaces pending pins whose lookup has finished
+// since the result was cached. It reads the geocoding cache only, so a request
+// never waits on the queue. The cached slice is shared between requests and is
+// copied before any change.
+func refreshPendingLocations(ctx context.Context, fed models.Federation, tournaments []models.Tournament) []models.Tournament {
+	out := tournaments
+	copied := false
+
+	for i := range tournaments {
+		if !tournaments[i].LocationPending {
+			continue
+		}
+
+		geo, status := openstreetmap.CachedGeocoordinatesForFederation(fed, tournaments[i])
+		if status == openstreetmap.CacheMiss {
+			// Re-queueing a waiting job only promotes it to this request's
+			// priority, and picks the lookup up again if a failed attempt
+			// may be retried.
+			if queue := GeocodeQueue(); queue != nil {
+				if _, err := queue.Enqueue(fed, tournaments[i], ratelimit.PriorityFrom(ctx)); err != nil {
+					logger.Error("Failed to queue geocoding for tournament %s: %v", tournaments[i].Id, err)
+				}
+			}
+			continue
+		}
+
+		if !copied {
+			out = append([]models.Tournament(nil), tournaments...)
+			copied = true
+		}
+
+		t := &out[i]
+		t.LocationPending = false
+		if status == openstreetmap.CacheHit {
+			t.Lat = geo.Lat
+			t.Lon = geo.Lon
+			t.ApproximateLocation = false
+			t.LocationDisplayName = geo.DisplayName
+			t.LocationSource = geo.Address.Source
+		}
+		// On backoff the federation default the tournament already carries
+		// is the final answer.
 	}
+
+	return out
 }
 
-// getTournamentsFromBTV fetches and geocodes the Bavarian tournament list.
-func getTournamentsFromBTV(ctx context.Context, fed models.Federation) ([]models.Tournament, error) {
-	logger.Info("Get Tournaments in: %s (BTV widget)", fed.Id)
+// withCanonicalIds fills in the canonical ids a cached result may lack, having
+// been stored before annotateIdentity set them. Like refreshPendingLocations
+// it copies the shared cached slice before any change.
+func withCanonicalIds(fed models.Federation, tournaments []models.Tournament) []models.Tournament {
+	out := tournaments
+	copied := false
+
+	for i := range tournaments {
+		if tournaments[i].CanonicalId != "" {
+			continue
+		}
+		if !copied {
+			out = append([]models.Tournament(nil), tournaments...)
+			copied = true
+		}
+		out[i].CanonicalId = tournamentid.Canonical(fed.Id, out[i])
+	}
 
-	tournaments, err := btv.New(fed.Url).GetTournaments(ctx, fed)
+	return out
+}
+
+// upstreamQuery returns the query fed's source is scraped and cached for; see
+// source.Canonical. A federation without a known source keeps q, and fails in
+// fetchFederation.
+func upstreamQuery(fed models.Federation, q source.Query) source.Query {
+	src, err := source.For(fed)
+	if err != nil {
+		return q
+	}
+	return source.Canonical(src, q)
+}
+
+// catalogRefresh describes a fetch for the catalog. Only a successful fetch of
+// every competition type over a readable window is complete: a narrower one
+// leaves out tournaments that still exist. That includes a fetch the upstream
+// did not restrict to the window (source.ErrWindowIgnored).
+func catalogRefresh(fed models.Federation, q source.Query, tournaments []models.Tournament, err error) catalog.Refresh {
+	refresh := catalog.Refresh{Federation: fed.Id, Tournaments: tournaments}
+	from, fromErr := daterange.ParseDay(q.DateFrom)
+	to, toErr := daterange.ParseDay(q.DateTo)
+	if fromErr == nil && toErr == nil {
+		refresh.From, refresh.To = from, to
+		refresh.Complete = err == nil && q.CompType == ""
+	}
+	return refresh
+}
+
+// appendPossiblyCancelled keeps the tournaments a complete refresh no longer
+// returned in the result, flagged, for the catalog's grace period. They are
+// cached with the rest, so every search, filter and export sees them.
+func appendPossiblyCancelled(fed models.Federation, refresh catalog.Refresh, tournaments []models.Tournament) []models.Tournament {
+	cancelled, err := Catalog().PossiblyCancelled(fed.Id, refresh.From, refresh.To)
+	if err != nil {
+		logger.Error("Federation %s: failed to read possibly cancelled tournaments: %v", fed.Id, err)
+		return tournaments
+	}
+	if len(cancelled) > 0 {
+		logger.Info("Federation %s: %d tournaments no longer listed, flagged as possibly cancelled", fed.Id, len(cancelled))
+	}
+	return append(tournaments, cancelled...)
+}
+
+// cacheKey identifies the cached result of an upstream query.
+func cacheKey(fed models.Federation, q source.Query) resultcache.Key {
+	return resultcache.Key{
+		FederationID: fed.Id,
+		DateFrom:     q.DateFrom,
+		DateTo:       q.DateTo,
+		CompType:     q.CompType,
+	}
+}
+
+// fetchFederation hands the query to the source registered for the
+// federation's ApiVersion, and applies the date window itself when the source
+// cannot.
+func fetchFederation(ctx context.Context, fed models.Federation, q source.Query) ([]models.Tournament, error) {
+	src, err := source.For(fed)
 	if err != nil {
 		return nil, err
 	}
 
+	tournaments, err := src.Fetch(ctx, fed, q)
+	if !src.Capabilities().DateFilter {
+		tournaments = filterByDateWindow(fed, tournaments, q.DateFrom, q.DateTo)
+	}
+	annotateDates(fed, tournaments, q.DateFrom)
+	annotateCompetitions(tournaments)
+	annotateIdentity(fed, tournaments)
+	return tournaments, err
+}
+
+// annotateIdentity gives every tournament an id, falling back to a content
+// hash when its link carries none, and the federation-namespaced canonical id.
+// Like annotateDates it runs once per fetch, so every source is covered.
+func annotateIdentity(fed models.Federation, tournaments []models.Tournament) {
+	for i := range tournaments {
+		tournaments[i].Id = tournamentid.Of(tournaments[i])
+		tournaments[i].CanonicalId = tournamentid.Canonical(fed.Id, tournaments[i])
+	}
+}
+
+// annotateCompetitions fills in the structured competition fields. Like
+// annotateDates it runs once per fetch, so every source's names are read by
+// the same parser and the result is cached with them.
+func annotateCompetitions(tournaments []models.Tournament) {
+	for i := range tournaments {
+		for j := range tournaments[i].Entries {
+			e := &tournaments[i].Entries[j]
+			if strings.TrimSpace(e.Competition) == "" {
+				continue
+			}
+			c := competition.Parse(e.Competition)
+			e.Gender = c.Gender.String()
+			e.AgeClass = c.AgeClass.String()
+			e.Discipline = c.Discipline.String()
+			e.DrawType = c.DrawType.String()
+		}
+	}
+}
+
+// annotateDates fills in StartDate/EndDate from the free-text Date.
+//
+// It runs once per fetch rather than in each parser, so every source gets the
+// same parsing. Dates without a year are resolved against the start of the
+// query window. Texts that cannot be parsed are recorded for /stats rather
+// than dropped: the tournament itself is still valid.
+func annotateDates(fed models.Federation, tournaments []models.Tournament, dateFrom string) {
+	ref := referenceDay(dateFrom)
+
+	for i := range tournaments {
+		r, ok := daterange.Parse(tournaments[i].Date, ref)
+		if !ok {
+			undated.Record(tournaments[i].Date, fed.Id, tournamentid.Of(tournaments[i]), tournaments[i].Title)
+			continue
+		}
+		tournaments[i].StartDate = r.Start.Format(daterange.ISOLayout)
+		tournaments[i].EndDate = r.End.Format(daterange.ISOLayout)
+	}
+}
+
+// referenceDay returns the day dates without a year are resolved against: the
+// start of the query window, or today when it cannot be read. The window's
+// start itself defaults to today when the request names none.
+func referenceDay(dateFrom string) time.Time {
+	ref, err := daterange.ParseDay(dateFrom)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return ref
+}
+
+// getTournamentsFromBTV fetches and geocodes the Bavarian tournament list.
+//
+// The widget may ignore the requested window and serve its default one, and
+// the result is cached under the requested window's key, so the list is
+// filtered here before it is geocoded.
+func getTournamentsFromBTV(ctx context.Context, fed models.Federation, dateFrom, dateTo string) ([]models.Tournament, error) {
+	logger.Info("Get Tournaments in: %s (BTV widget)", fed.Id)
+
+	// A listing cut short still carries the pages that were read; they are
+	// placed and returned with the error, like the new API's.
+	tournaments, fetchErr := btv.New(fed.Url).GetTournaments(ctx, fed, dateFrom, dateTo)
+	if len(tournaments) == 0 && fetchErr != nil {
+		return nil, fetchErr
+	}
+	// The widget may have served its default window instead; see
+	// btv.Client.GetTournaments.
+	if fetchErr == nil {
+		fetchErr = fmt.Errorf("federation %s: %w", fed.Id, source.ErrWindowIgnored)
+	}
+
+	tournaments = filterByDateWindow(fed, tournaments, dateFrom, dateTo)
+
 	// The widget supplies a venue city but no coordinates.
 	for i := range tournaments {
-		geoCoords, approximate := resolveGeocoordinates(fed, tournaments[i], defaultGeocoder, tournaments[i].Location)
-		tournaments[i].Lat = geoCoords.Lat
-		tournaments[i].Lon = geoCoords.Lon
-		tournaments[i].ApproximateLocation = approximate
+		placeTournament(ctx, fed, &tournaments[i], defaultGeocoder, tournaments[i].Location)
+	}
+	// Pins placed after a cancellation are defaults, not results; they must
+	// not be cached.
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	logger.Info("Federation %s: Found %d tournaments total", fed.Id, len(tournaments))
 
-	return tournaments, nil
+	return tournaments, fetchErr
+}
+
+// filterByDateWindow keeps the tournaments that share at least one day with
+// dateFrom..dateTo.
+//
+// A tournament whose date cannot be parsed is kept: dropping it would hide a
+// real tournament over a formatting quirk, while keeping it at worst shows one
+// that is slightly out of range. An unparseable window disables filtering.
+func filterByDateWindow(fed models.Federation, tournaments []models.Tournament, dateFrom, dateTo string) []models.Tournament {
+	from, err := daterange.ParseDay(dateFrom)
+	if err != nil {
+		return tournaments
+	}
+	to, err := daterange.ParseDay(dateTo)
+	if err != nil {
+		return tournaments
+	}
+
+	kept := make([]models.Tournament, 0, len(tournaments))
+	for _, t := range tournaments {
+		r, ok := daterange.Parse(t.Date, from)
+		if !ok {
+			logger.Debug("Federation %s: keeping tournament %s with unparseable date %q", fed.Id, t.Id, t.Date)
+			kept = append(kept, t)
+			continue
+		}
+		if r.Overlaps(from, to) {
+			kept = append(kept, t)
+		}
+	}
+
+	if dropped := len(tournaments) - len(kept); dropped > 0 {
+		logger.Debug("Federation %s: dropped %d tournaments outside %s - %s", fed.Id, dropped, dateFrom, dateTo)
+	}
+	return kept
 }
 
 // ageCategoryForCompType maps a competition type to the new API's age category.
@@ -488,7 +973,7 @@ func getTournamentsFromFederationNewApi(ctx context.Context, fed models.Federati
 			break
 		}
 
-		pageTournaments, parseErr := ParseNewApiDocument(body, fed, defaultGeocoder)
+		pageTournaments, parseErr := ParseNewApiDocument(ctx, body, fed, referenceDay(dateFrom), defaultGeocoder)
 		body.Close()
 
 		if parseErr != nil {
@@ -500,11 +985,7 @@ func getTournamentsFromFederationNewApi(ctx context.Context, fed models.Federati
 
 		newOnPage := 0
 		for _, t := range pageTournaments {
-			key := t.Id
-			if key == "" {
-				// Fall back to a composite key when no ID could be parsed.
-				key = t.Title + "|" + t.Date
-			}
+			key := tournamentid.Of(t)
 			if _, dup := seen[key]; dup {
 				continue
 			}
@@ -520,6 +1001,11 @@ func getTournamentsFromFederationNewApi(ctx context.Context, fed models.Federati
 		if len(pageTournaments) < pageSize || newOnPage == 0 {
 			break
 		}
+		if page == maxPages-1 && firstErr == nil {
+			// More pages follow, but the listing is cut here: report it
+			// as partial, not as every tournament there is.
+			firstErr = fmt.Errorf("federation %s: listing capped at %d pages", fed.Id, maxPages)
+		}
 	}
 
 	logger.Info("Federation %s: Found %d tournaments total", fed.Id, len(all))
@@ -540,7 +1026,7 @@ func getTournamentsFromFederationOldApi(ctx context.Context, fed models.Federati
 	}
 	defer body.Close()
 
-	tournaments, err := ParseOldApiDocument(body, fed, defaultGeocoder)
+	tournaments, err := ParseOldApiDocument(ctx, body, fed, referenceDay(dateFrom), defaultGeocoder)
 	if err != nil {
 		return nil, err
 	}
@@ -612,16 +1098,52 @@ func newLimitedReadCloser(rc io.ReadCloser, limit int64) io.ReadCloser {
 func (l *limitedReadCloser) Read(p []byte) (int, error) { return l.reader.Read(p) }
 func (l *limitedReadCloser) Close() error               { return l.closer.Close() }
 
+// locationSourceFederationDefault marks a tournament pinned at its
+// federation's default coordinates. The other location sources are the
+// addressprovider candidate sources.
+const locationSourceFederationDefault = "federation-default"
+
+// placeTournament geocodes a tournament and records the pin together with
+// where it came from.
+func placeTournament(ctx context.Context, fed models.Federation, tournament *models.Tournament, geocode geocoder, subject string) {
+	geoCoords, approximate := resolveGeocoordinates(ctx, fed, *tournament, geocode, subject)
+	tournament.Lat = geoCoords.Lat
+	tournament.Lon = geoCoords.Lon
+	tournament.ApproximateLocation = approximate
+	tournament.LocationDisplayName = geoCoords.DisplayName
+	tournament.LocationSource = geoCoords.Address.Source
+	tournament.LocationPending = geoCoords.Pending
+	if approximate {
+		tournament.LocationSource = locationSourceFederationDefault
+	}
+}
+
 // resolveGeocoordinates looks up coordinates and falls back to the federation
 // default when nothing suitable is found. The second return value reports
 // whether that fallback was used, which callers cannot work out from the
 // coordinates alone: several federation defaults sit exactly on a major city.
-func resolveGeocoordinates(fed models.Federation, tournament models.Tournament, geocode geocoder, subject string) (models.Geocoordinates, bool) {
+func resolveGeocoordinates(ctx context.Context, fed models.Federation, tournament models.Tournament, geocode geocoder, subject string) (models.Geocoordinates, bool) {
 	if geocode == nil {
 		geocode = defaultGeocoder
 	}
 
-	geoCoords := geocode(fed, tournament)
+	// A cancelled request only needs to finish quickly; the caller discards
+	// what it parsed. Not a failure of the club, so nothing is recorded.
+	if ctx.Err() != nil {
+		return fed.Geocoordinates, true
+	}
+
+	geoCoords := geocode(ctx, fed, tournament)
+	if ctx.Err() != nil {
+		return fed.Geocoordinates, true
+	}
+	if geoCoords.Pending {
+		// Not a failure: the lookup is queued. Pin at the default until a
+		// later request finds the answer in the cache.
+		pending := fed.Geocoordinates
+		pending.Pending = true
+		return pending, true
+	}
 	if geoCoords.Lat == "" || geoCoords.Lon == "" {
 		logger.Warn("No Geocoordinates could be found for (%s): '%s'. Falling back to default in '%s'",
 			tournament.Id, subject, fed.State)
@@ -637,7 +1159,9 @@ func resolveGeocoordinates(fed models.Federation, tournament models.Tournament,
 }
 
 // ParseNewApiDocument parses one page of the new API's HTML into tournaments.
-func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) ([]models.Tournament, error) {
+// Dates without a year are resolved against ref; see referenceDay. It returns
+// ctx's error if ctx was cancelled while the page was geocoded.
+func ParseNewApiDocument(ctx context.Context, r io.Reader, fed models.Federation, ref time.Time, geocode geocoder) ([]models.Tournament, error) {
 	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML document: %w", err)
@@ -684,7 +1208,7 @@ func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 				if urlElement.Length() > 0 {
 					tournament.Title = util.NormalizeWhitespace(urlElement.Text())
 					tournament.URL, _ = urlElement.Attr("href")
-					tournament.Id = getTournamentIdByUrl(tournament.URL)
+					tournament.Id = tournamentid.FromURL(tournament.URL)
 				}
 
 				// Extract organizer and location from the paragraph element
@@ -693,48 +1217,30 @@ func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 					paragraphText := paragraphElement.Text()
 
 					// Direct text parsing approach - more reliable than HTML splitting
-					if strings.Contains(paragraphText, "Veranstalter:") {
-						// Extract organizer - everything between "Veranstalter: " and " Austragungsort"
-						extractedOrganizer, organizerExists := util.GetStringInBetweenTwoString(paragraphText, "Veranstalter: ", " Austragungsort")
-						if organizerExists {
-							tournament.Organizer = util.NormalizeWhitespace(extractedOrganizer)
-						}
-					}
-
-					if strings.Contains(paragraphText, "Austragungsort:") {
-						// Try different ending markers for different federations
-						var extractedLocation string
-						var locationExists bool
-
-						// Try "Meldeschluss" first (WTB format)
-						extractedLocation, locationExists = util.GetStringInBetweenTwoString(paragraphText, "Austragungsort: ", " Meldeschluss")
-						if !locationExists {
-							// Try "Offen für" (RLP format)
-							extractedLocation, locationExists = util.GetStringInBetweenTwoString(paragraphText, "Austragungsort: ", " Offen für")
-						}
-						if locationExists {
-							tournament.Location = util.NormalizeWhitespace(extractedLocation)
-						}
-					}
+					fields := paragraphFields(paragraphText)
+					tournament.Organizer = fields["Veranstalter"]
+					tournament.Location = fields["Austragungsort"]
+					tournament.OpenFor = fields["Offen für"]
+					tournament.RegistrationDeadline = registrationDeadline(fields["Meldeschluss"], tournamentDate, ref)
 
 					if debugEnabled {
 						logger.Debug("Paragraph text: '%s'", paragraphText)
 						logger.Debug("Extracted organizer: '%s'", tournament.Organizer)
 						logger.Debug("Extracted location: '%s'", tournament.Location)
+						logger.Debug("Extracted registration deadline: '%s'", fields["Meldeschluss"])
 					}
 				}
 
 				// Get geocoordinates if we have a location
 				if tournament.Location != "" {
-					geoCoords, approximate := resolveGeocoordinates(fed, tournament, geocode, tournament.Location)
-					tournament.Lat = geoCoords.Lat
-					tournament.Lon = geoCoords.Lon
-					tournament.ApproximateLocation = approximate
+					placeTournament(ctx, fed, &tournament, geocode, tournament.Location)
 				} else {
 					logger.Warn("Tournament location missing: %s ; Date: %s", tournament.Title, tournament.Date)
 				}
 
-				if len(tournament.Title) > 0 && tournament.Id != "" {
+				// A row without an id in its link is kept: the tournament still
+				// gets a content hash as its id in fetchFederation.
+				if len(tournament.Title) > 0 {
 					if debugEnabled {
 						logger.Debug("Created tournament: ID='%s', Title='%s', Date='%s'", tournament.Id, tournament.Title, tournament.Date)
 					}
@@ -742,7 +1248,9 @@ func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 					stored := tournament
 					// Store the same pointer in the map and the ordered slice so
 					// competition entries appended later are visible in both.
-					tournamentMap[stored.Id] = &stored
+					if stored.Id != "" {
+						tournamentMap[stored.Id] = &stored
+					}
 					orderedTournaments = append(orderedTournaments, &stored)
 					currentTournament = &stored
 				}
@@ -752,7 +1260,7 @@ func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 				if urlElement.Length() > 0 {
 					tournamentURL, exists := urlElement.Attr("href")
 					if exists {
-						tournamentId := getTournamentIdByUrl(tournamentURL)
+						tournamentId := tournamentid.FromURL(tournamentURL)
 						if tournament, found := tournamentMap[tournamentId]; found {
 							currentTournament = tournament
 						}
@@ -821,11 +1329,16 @@ func ParseNewApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return tournaments, nil
 }
 
 // ParseOldApiDocument parses the old API's result table into tournaments.
-func ParseOldApiDocument(r io.Reader, fed models.Federation, geocode geocoder) ([]models.Tournament, error) {
+// Dates without a year are resolved against ref; see referenceDay. It returns
+// ctx's error if ctx was cancelled while the table was geocoded.
+func ParseOldApiDocument(ctx context.Context, r io.Reader, fed models.Federation, ref time.Time, geocode geocoder) ([]models.Tournament, error) {
 	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML document: %w", err)
@@ -833,6 +1346,18 @@ func ParseOldApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 
 	var tournaments []models.Tournament
 
+	// Some federations' calendars add columns after the LK, such as
+	// "Meldeschluss"; they are found by their header, -1 when absent.
+	deadlineColumn, openForColumn := -1, -1
+	doc.Find(".result-set tr").First().Find("th").Each(func(idx int, header *goquery.Selection) {
+		switch label := strings.ToLower(util.NormalizeWhitespace(header.Text())); {
+		case strings.HasPrefix(label, "meldeschluss"):
+			deadlineColumn = idx
+		case strings.HasPrefix(label, "offen für"):
+			openForColumn = idx
+		}
+	})
+
 	doc.Find(".result-set tr").Each(func(idxRow int, rowTournament *goquery.Selection) {
 		// Skip header row
 		if idxRow == 0 {
@@ -860,20 +1385,21 @@ func ParseOldApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 					urlElement := columnTournament.Find("a")
 					tournament.Title = util.NormalizeWhitespace(urlElement.Text())
 					tournament.URL, _ = urlElement.Attr("href")
-					tournament.Id = getTournamentIdByUrl(tournament.URL)
+					tournament.Id = tournamentid.FromURL(tournament.URL)
 
 					if len(tournament.Title) > 0 {
 						tournament.Organizer = extractOldApiOrganizer(columnTournament, tournament)
 
-						geoCoords, approximate := resolveGeocoordinates(fed, tournament, geocode, tournament.Organizer)
-						tournament.Lat = geoCoords.Lat
-						tournament.Lon = geoCoords.Lon
-						tournament.ApproximateLocation = approximate
+						placeTournament(ctx, fed, &tournament, geocode, tournament.Organizer)
 					}
 				case 2: // Competition (Konkurrenz)
 					currentEntry.Competition = value
 				case 3: // Skill Level (LK)
 					currentEntry.SkillLevel = normalizeSkillLevel(value)
+				case deadlineColumn:
+					tournament.RegistrationDeadline = registrationDeadline(value, tournament.Date, ref)
+				case openForColumn:
+					tournament.OpenFor = value
 				}
 			})
 
@@ -912,9 +1438,55 @@ func ParseOldApiDocument(r io.Reader, fed models.Federation, geocode geocoder) (
 		}
 	})
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return tournaments, nil
 }
 
+// paragraphLabel matches the labels of the new API's tournament paragraph:
+// "Veranstalter: TC Stuttgart Austragungsort: Stuttgart Meldeschluss:
+// 20.08.2026". WTB ends with the deadline, RLP with "Offen für: Alle", and
+// any of them may be missing.
+var paragraphLabel = regexp.MustCompile(`(Veranstalter|Austragungsort|Meldeschluss|Offen für)\s*:`)
+
+// paragraphFields splits the paragraph into its labelled values, each running
+// up to the next label.
+func paragraphFields(text string) map[string]string {
+	fields := make(map[string]string)
+	matches := paragraphLabel.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		if value := util.NormalizeWhitespace(text[m[1]:end]); value != "" {
+			fields[text[m[2]:m[3]]] = value
+		}
+	}
+	return fields
+}
+
+// registrationDeadline parses a published deadline into an ISO day, placing a
+// deadline without a year before the tournament's date, which is resolved
+// against ref like annotateDates does. It is empty when the text cannot be
+// read.
+func registrationDeadline(text, date string, ref time.Time) string {
+	if text == "" {
+		return ""
+	}
+	var event time.Time
+	if r, ok := daterange.Parse(date, ref); ok {
+		event = r.Start
+	}
+	deadline, ok := daterange.ParseDeadline(text, event)
+	if !ok {
+		logger.Debug("Unparseable registration deadline %q", text)
+		return ""
+	}
+	return deadline.Format(daterange.ISOLayout)
+}
+
 // extractOldApiOrganizer pulls the organizer out of the title cell, which
 // contains the tournament link followed by the organizing club.
 func extractOldApiOrganizer(columnTournament *goquery.Selection, tournament models.Tournament) string {
@@ -942,13 +1514,3 @@ func normalizeSkillLevel(value string) string {
 	}
 	return cleaned
 }
-
-func getTournamentIdByUrl(tournamentUrl string) string {
-	// Old Mybigpoint: https://mybigpoint.tennis.de/web/guest/turniersuche?tournamentId=484582
-	// New Tennis.de: https://www.tennis.de/spielen/turniersuche.html#detail/699982
-	array := strings.Split(tournamentUrl, "detail/")
-	if len(array) > 1 {
-		return array[1]
-	}
-	return ""
-}
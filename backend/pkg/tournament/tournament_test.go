@@ -1,15 +1,28 @@
 package tournament
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/timoknapp/tennis-tournament-finder/pkg/competition"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/savedsearch"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/webpush"
 )
 
 // testFederationOld is a stand-in for a liga.nu based federation.
+// testReference is the start of the query window the parser tests resolve
+// dates without a year against.
+var testReference = time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
 var testFederationOld = models.Federation{
 	Id:             "BAD",
 	Name:           "Badischer Tennisverband",
@@ -38,14 +51,14 @@ var testFederationRLP = models.Federation{
 // staticGeocoder returns fixed coordinates so parser tests never touch the
 // network and stay deterministic.
 func staticGeocoder(lat, lon string) geocoder {
-	return func(fed models.Federation, tournament models.Tournament) models.Geocoordinates {
+	return func(_ context.Context, fed models.Federation, tournament models.Tournament) models.Geocoordinates {
 		return models.Geocoordinates{Lat: lat, Lon: lon, DisplayName: fed.State}
 	}
 }
 
 // failingGeocoder simulates a lookup that cannot resolve coordinates.
 func failingGeocoder() geocoder {
-	return func(models.Federation, models.Tournament) models.Geocoordinates {
+	return func(context.Context, models.Federation, models.Tournament) models.Geocoordinates {
 		return models.Geocoordinates{}
 	}
 }
@@ -85,8 +98,8 @@ func competitions(tournament models.Tournament) []string {
 
 func TestParseOldApiDocument(t *testing.T) {
 	tournaments, err := ParseOldApiDocument(
-		loadFixture(t, "old_api_basic.html"),
-		testFederationOld,
+		context.Background(), loadFixture(t, "old_api_basic.html"),
+		testFederationOld, testReference,
 		staticGeocoder("49.0069", "8.4037"),
 	)
 	if err != nil {
@@ -157,8 +170,8 @@ func TestParseOldApiDocument(t *testing.T) {
 
 func TestParseOldApiDocumentFallsBackToFederationCoordinates(t *testing.T) {
 	tournaments, err := ParseOldApiDocument(
-		loadFixture(t, "old_api_basic.html"),
-		testFederationOld,
+		context.Background(), loadFixture(t, "old_api_basic.html"),
+		testFederationOld, testReference,
 		failingGeocoder(),
 	)
 	if err != nil {
@@ -175,13 +188,39 @@ func TestParseOldApiDocumentFallsBackToFederationCoordinates(t *testing.T) {
 				tournament.Id, tournament.Lat, tournament.Lon,
 				testFederationOld.Geocoordinates.Lat, testFederationOld.Geocoordinates.Lon)
 		}
+		if tournament.LocationSource != "federation-default" {
+			t.Errorf("tournament %s LocationSource = %q, want federation-default",
+				tournament.Id, tournament.LocationSource)
+		}
+	}
+}
+
+func TestPlaceTournamentRecordsProvenance(t *testing.T) {
+	geocode := func(context.Context, models.Federation, models.Tournament) models.Geocoordinates {
+		return models.Geocoordinates{
+			Lat: "49.47", Lon: "8.48", DisplayName: "Neckarau, Mannheim, Baden-Württemberg",
+			Address: models.Address{Source: "organizer-derived"},
+		}
+	}
+
+	tournament := models.Tournament{Id: "1", Organizer: "TC Grün-Weiß Neckarau"}
+	placeTournament(context.Background(), testFederationOld, &tournament, geocode, tournament.Organizer)
+
+	if tournament.Lat != "49.47" || tournament.ApproximateLocation {
+		t.Errorf("tournament = %+v, want the geocoded pin", tournament)
+	}
+	if tournament.LocationSource != "organizer-derived" {
+		t.Errorf("LocationSource = %q, want organizer-derived", tournament.LocationSource)
+	}
+	if tournament.LocationDisplayName != "Neckarau, Mannheim, Baden-Württemberg" {
+		t.Errorf("LocationDisplayName = %q", tournament.LocationDisplayName)
 	}
 }
 
 func TestParseNewApiDocumentWTB(t *testing.T) {
 	tournaments, err := ParseNewApiDocument(
-		loadFixture(t, "new_api_wtb.html"),
-		testFederationNew,
+		context.Background(), loadFixture(t, "new_api_wtb.html"),
+		testFederationNew, testReference,
 		staticGeocoder("48.7758", "9.1829"),
 	)
 	if err != nil {
@@ -222,6 +261,10 @@ func TestParseNewApiDocumentWTB(t *testing.T) {
 		}
 	}
 
+	if first.RegistrationDeadline != "2026-08-20" {
+		t.Errorf("first.RegistrationDeadline = %q, want 2026-08-20", first.RegistrationDeadline)
+	}
+
 	second := findTournament(t, tournaments, "800002")
 	if second.Title != "Ulmer Herbstturnier" {
 		t.Errorf("second.Title = %q, want %q", second.Title, "Ulmer Herbstturnier")
@@ -233,8 +276,8 @@ func TestParseNewApiDocumentWTB(t *testing.T) {
 
 func TestParseNewApiDocumentRLPVariant(t *testing.T) {
 	tournaments, err := ParseNewApiDocument(
-		loadFixture(t, "new_api_rlp.html"),
-		testFederationRLP,
+		context.Background(), loadFixture(t, "new_api_rlp.html"),
+		testFederationRLP, testReference,
 		staticGeocoder("49.9929", "8.2473"),
 	)
 	if err != nil {
@@ -256,6 +299,10 @@ func TestParseNewApiDocumentRLPVariant(t *testing.T) {
 	if first.Organizer != "TC Mainz" {
 		t.Errorf("first.Organizer = %q", first.Organizer)
 	}
+	if first.OpenFor != "Alle" || first.RegistrationDeadline != "" {
+		t.Errorf("first.OpenFor = %q, RegistrationDeadline = %q; want Alle and none",
+			first.OpenFor, first.RegistrationDeadline)
+	}
 
 	// Without a location the parser must not call the geocoder, so the
 	// coordinates stay empty here (the caller shows the federation default).
@@ -266,6 +313,81 @@ func TestParseNewApiDocumentRLPVariant(t *testing.T) {
 	if second.Lat != "" || second.Lon != "" {
 		t.Errorf("second coordinates = (%q,%q), want empty", second.Lat, second.Lon)
 	}
+	if second.Organizer != "TC Ohne Ort" {
+		t.Errorf("second.Organizer = %q, want %q", second.Organizer, "TC Ohne Ort")
+	}
+}
+
+func TestParagraphFields(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+	}{
+		{
+			"Veranstalter: TC Stuttgart Austragungsort: Stuttgart Meldeschluss: 20.08.2026",
+			map[string]string{"Veranstalter": "TC Stuttgart", "Austragungsort": "Stuttgart", "Meldeschluss": "20.08.2026"},
+		},
+		{
+			"Veranstalter: TC Mainz Austragungsort: Mainz Meldeschluss: 20.08.2026 12:00 Offen für: Vereinsmitglieder",
+			map[string]string{"Veranstalter": "TC Mainz", "Austragungsort": "Mainz", "Meldeschluss": "20.08.2026 12:00", "Offen für": "Vereinsmitglieder"},
+		},
+		// The location used to be dropped when no label followed it.
+		{"Veranstalter: TC Ulm Austragungsort: Ulm", map[string]string{"Veranstalter": "TC Ulm", "Austragungsort": "Ulm"}},
+		{"Veranstalter: Austragungsort: Ulm", map[string]string{"Austragungsort": "Ulm"}},
+		{"", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		got := paragraphFields(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("paragraphFields(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("paragraphFields(%q)[%q] = %q, want %q", tt.in, k, got[k], v)
+			}
+		}
+	}
+}
+
+// TestRegistrationDeadlineUsesTheReference checks that a tournament date
+// without a year is placed by the query window, not by the clock, so the
+// deadline matches the StartDate annotateDates derives.
+func TestRegistrationDeadlineUsesTheReference(t *testing.T) {
+	ref := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := registrationDeadline("28.12.", "05.01. - 07.01.", ref); got != "2029-12-28" {
+		t.Errorf("registrationDeadline() = %q, want 2029-12-28", got)
+	}
+}
+
+func TestParseOldApiDocumentReadsOptionalColumns(t *testing.T) {
+	const page = `<table class="result-set">
+  <tr><th>Datum</th><th>Turnier</th><th>Konkurrenz</th><th>LK</th><th>Meldeschluss</th><th>Offen für</th></tr>
+  <tr>
+    <td rowspan="2">01.01.2027 - 03.01.2027</td>
+    <td rowspan="2"><a href="https://www.tennis.de/spielen/turniersuche.html#detail/700009">Neujahrsturnier</a> TC Karlsruhe</td>
+    <td>Herren Einzel</td><td>LK 12,0</td>
+    <td rowspan="2">28.12.</td><td rowspan="2">Alle</td>
+  </tr>
+  <tr><td>Damen Einzel</td><td>LK 14,5</td></tr>
+</table>`
+
+	tournaments, err := ParseOldApiDocument(context.Background(), strings.NewReader(page),
+		testFederationOld, testReference, staticGeocoder("49.0069", "8.4037"))
+	if err != nil || len(tournaments) != 1 {
+		t.Fatalf("ParseOldApiDocument() = %d tournaments, %v; want 1", len(tournaments), err)
+	}
+
+	got := tournaments[0]
+	// A deadline without a year lies before the tournament.
+	if got.RegistrationDeadline != "2026-12-28" || got.OpenFor != "Alle" {
+		t.Errorf("RegistrationDeadline = %q, OpenFor = %q; want 2026-12-28 and Alle",
+			got.RegistrationDeadline, got.OpenFor)
+	}
+	if len(got.Entries) != 2 {
+		t.Errorf("entries = %v, want both competitions", competitions(got))
+	}
 }
 
 func TestParseDocumentsHandleEmptyAndMalformedInput(t *testing.T) {
@@ -283,7 +405,7 @@ func TestParseDocumentsHandleEmptyAndMalformedInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name+"/old", func(t *testing.T) {
-			got, err := ParseOldApiDocument(strings.NewReader(tt.html), testFederationOld, staticGeocoder("1", "2"))
+			got, err := ParseOldApiDocument(context.Background(), strings.NewReader(tt.html), testFederationOld, testReference, staticGeocoder("1", "2"))
 			if err != nil {
 				t.Fatalf("ParseOldApiDocument() error = %v", err)
 			}
@@ -293,7 +415,7 @@ func TestParseDocumentsHandleEmptyAndMalformedInput(t *testing.T) {
 		})
 
 		t.Run(tt.name+"/new", func(t *testing.T) {
-			got, err := ParseNewApiDocument(strings.NewReader(tt.html), testFederationNew, staticGeocoder("1", "2"))
+			got, err := ParseNewApiDocument(context.Background(), strings.NewReader(tt.html), testFederationNew, testReference, staticGeocoder("1", "2"))
 			if err != nil {
 				t.Fatalf("ParseNewApiDocument() error = %v", err)
 			}
@@ -308,8 +430,8 @@ func TestParseNewApiDocumentUsesDefaultGeocoderWhenNil(t *testing.T) {
 	// A nil geocoder must not panic; it falls back to the default lookup.
 	// The fixture without a location never triggers a network call.
 	tournaments, err := ParseNewApiDocument(
-		strings.NewReader(`<table class="responsive-individual"><tbody></tbody></table>`),
-		testFederationNew,
+		context.Background(), strings.NewReader(`<table class="responsive-individual"><tbody></tbody></table>`),
+		testFederationNew, testReference,
 		nil,
 	)
 	if err != nil {
@@ -320,25 +442,6 @@ func TestParseNewApiDocumentUsesDefaultGeocoderWhenNil(t *testing.T) {
 	}
 }
 
-func TestGetTournamentIdByUrl(t *testing.T) {
-	tests := []struct {
-		url  string
-		want string
-	}{
-		{"https://www.tennis.de/spielen/turniersuche.html#detail/699982", "699982"},
-		{"https://mybigpoint.tennis.de/web/guest/turniersuche?tournamentId=484582", ""},
-		{"", ""},
-		{"detail/", ""},
-		{"https://example.com/detail/12345", "12345"},
-	}
-
-	for _, tt := range tests {
-		if got := getTournamentIdByUrl(tt.url); got != tt.want {
-			t.Errorf("getTournamentIdByUrl(%q) = %q, want %q", tt.url, got, tt.want)
-		}
-	}
-}
-
 func TestNormalizeSkillLevel(t *testing.T) {
 	tests := []struct{ in, want string }{
 		{"LK 12,0", "LK 12,0"},
@@ -597,3 +700,415 @@ func TestFilterByLKDoesNotMutateInput(t *testing.T) {
 		t.Errorf("input was mutated: %d entries remain, want 2", len(original[0].Entries))
 	}
 }
+
+func TestFilterByCompType(t *testing.T) {
+	tournaments := []models.Tournament{
+		{Id: "herren", Entries: []models.CompetitionEntry{entry("Herren Einzel", ""), entry("Herren Doppel", "")}},
+		{Id: "damen", Entries: []models.CompetitionEntry{entry("Damen Einzel", "")}},
+		// BTV's expanded codes.
+		{Id: "btv", Entries: []models.CompetitionEntry{entry("Damen 30 Einzel", ""), entry("Herren Einzel", "")}},
+		{Id: "no-entries"},
+	}
+
+	tests := []struct {
+		compType string
+		want     []string
+	}{
+		{"", []string{"herren", "damen", "btv", "no-entries"}},
+		{"Herren+Einzel", []string{"herren", "btv", "no-entries"}},
+		{"Damen+Einzel", []string{"damen", "no-entries"}},
+		{"Senioren+Einzel", []string{"btv", "no-entries"}},
+		{"Jugend+Doppel", []string{"no-entries"}},
+		// An unknown filter must not empty the map.
+		{"Bambini+Einzel", []string{"herren", "damen", "btv", "no-entries"}},
+	}
+
+	for _, tt := range tests {
+		got := FilterByCompType(tournaments, tt.compType)
+		ids := make([]string, 0, len(got))
+		for _, tr := range got {
+			ids = append(ids, tr.Id)
+		}
+		if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FilterByCompType(%q) = %v, want %v", tt.compType, ids, tt.want)
+		}
+	}
+
+	// Only the matching competitions survive, without touching the shared
+	// cached slice.
+	got := FilterByCompType(tournaments, "Herren+Einzel")
+	if len(got[0].Entries) != 1 || got[0].Entries[0].Competition != "Herren Einzel" {
+		t.Errorf("entries = %+v, want only Herren Einzel", got[0].Entries)
+	}
+	if len(tournaments[0].Entries) != 2 {
+		t.Error("input was mutated")
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		birthYear, gender, lk string
+		want                  Profile
+		wantOK                bool
+	}{
+		{"1981", "w", "14", Profile{Player: competition.Player{BirthYear: 1981, Gender: competition.GenderWomen}, LK: 14, HasLK: true}, true},
+		{"1981", "", "", Profile{Player: competition.Player{BirthYear: 1981}}, true},
+		{"", "Herren", "LK 8,5", Profile{Player: competition.Player{Gender: competition.GenderMen}, LK: 8.5, HasLK: true}, true},
+		// An LK alone is the plain LK filter.
+		{"", "", "14", Profile{}, false},
+		{"neunzehn", "x", "", Profile{}, false},
+		{"1850", "", "", Profile{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseProfile(tt.birthYear, tt.gender, tt.lk)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseProfile(%q, %q, %q) = %+v, %v; want %+v, %v",
+				tt.birthYear, tt.gender, tt.lk, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestFilterByProfile covers the case from the issue: a 45-year-old woman with
+// LK 14 should not see Herren or U16 competitions.
+func TestFilterByProfile(t *testing.T) {
+	tournaments := []models.Tournament{
+		{
+			Id: "club", StartDate: "2026-08-01",
+			Entries: []models.CompetitionEntry{
+				entry("Herren Einzel", "1,0–25,0"),
+				entry("Damen Einzel", "1,0–25,0"),
+				entry("Damen 40 Einzel", "10,0–25,0"),
+				entry("Damen 50 Einzel", ""),
+				entry("Damen Doppel", "1,0–12,0"),
+			},
+		},
+		{Id: "youth", StartDate: "2026-08-01", Entries: []models.CompetitionEntry{entry("Juniorinnen U16", "")}},
+		{Id: "terse", StartDate: "2026-08-01", Entries: []models.CompetitionEntry{entry("Offene Konkurrenz", "")}},
+		{Id: "no-entries"},
+	}
+	profile := Profile{
+		Player: competition.Player{BirthYear: 1981, Gender: competition.GenderWomen},
+		LK:     14, HasLK: true,
+	}
+
+	got := FilterByProfile(tournaments, profile, 2026)
+
+	ids := make([]string, 0, len(got))
+	for _, tr := range got {
+		ids = append(ids, tr.Id)
+	}
+	if want := "club,terse,no-entries"; strings.Join(ids, ",") != want {
+		t.Fatalf("tournaments = %v, want %s", ids, want)
+	}
+
+	club := got[0]
+	if len(club.Entries) != 2 {
+		t.Fatalf("club kept %+v, want Damen Einzel and Damen 40 Einzel", club.Entries)
+	}
+	senior := club.Entries[1]
+	if senior.Competition != "Damen 40 Einzel" || senior.Eligibility == nil {
+		t.Fatalf("second entry = %+v, want Damen 40 Einzel with a reason", senior)
+	}
+	if want := "Damen, S40: wird 2026 45, LK 14,0 in LK 10,0–25,0"; senior.Eligibility.Reason != want {
+		t.Errorf("reason = %q, want %q", senior.Eligibility.Reason, want)
+	}
+	if len(senior.Eligibility.Unknown) != 0 {
+		t.Errorf("unknown = %v, want none", senior.Eligibility.Unknown)
+	}
+
+	// A terse name is kept, with every unchecked constraint named.
+	terse := got[1].Entries[0].Eligibility
+	if strings.Join(terse.Unknown, ",") != "gender,age,lk" {
+		t.Errorf("unknown = %v, want gender, age and lk", terse.Unknown)
+	}
+
+	// The shared cached slice is left alone.
+	if len(tournaments[0].Entries) != 5 || tournaments[0].Entries[1].Eligibility != nil {
+		t.Error("input was mutated")
+	}
+}
+
+func TestFilterByProfileUsesTheTournamentYear(t *testing.T) {
+	// Born 2011: turns 14 in 2025 and may still play U14, but not in 2026.
+	tournaments := []models.Tournament{
+		{Id: "2025", StartDate: "2025-12-28", Entries: []models.CompetitionEntry{entry("Junioren U14", "")}},
+		{Id: "2026", StartDate: "2026-01-03", Entries: []models.CompetitionEntry{entry("Junioren U14", "")}},
+	}
+	profile := Profile{Player: competition.Player{BirthYear: 2011, Gender: competition.GenderMen}}
+
+	got := FilterByProfile(tournaments, profile, 2026)
+	if len(got) != 1 || got[0].Id != "2025" {
+		t.Errorf("got %+v, want only the 2025 tournament", got)
+	}
+}
+
+func TestFilterByRegistrationOpen(t *testing.T) {
+	tournaments := []models.Tournament{
+		{Id: "closed", RegistrationDeadline: "2026-08-14"},
+		{Id: "closes-today", RegistrationDeadline: "2026-08-15"},
+		{Id: "open", RegistrationDeadline: "2026-09-01"},
+		{Id: "unpublished"},
+	}
+
+	got := FilterByRegistrationOpen(tournaments, time.Date(2026, 8, 15, 18, 0, 0, 0, time.UTC))
+
+	ids := make([]string, 0, len(got))
+	for _, tr := range got {
+		ids = append(ids, tr.Id)
+	}
+	if want := "closes-today,open,unpublished"; strings.Join(ids, ",") != want {
+		t.Errorf("FilterByRegistrationOpen() = %v, want %s", ids, want)
+	}
+}
+
+func TestFilterByDateWindow(t *testing.T) {
+	tournaments := []models.Tournament{
+		{Id: "before", Date: "01.08. - 03.08.2026"},
+		{Id: "inside", Date: "10.08. - 16.08.2026"},
+		{Id: "overlaps-start", Date: "28.07. - 05.08.2026"},
+		{Id: "overlaps-end", Date: "30.08. - 02.09.2026"},
+		{Id: "after", Date: "10.09. - 12.09.2026"},
+		{Id: "unparseable", Date: "demnächst"},
+	}
+
+	got := filterByDateWindow(testFederationOld, tournaments, "04.08.2026", "31.08.2026")
+
+	var ids []string
+	for _, tournament := range got {
+		ids = append(ids, tournament.Id)
+	}
+	want := []string{"inside", "overlaps-start", "overlaps-end", "unparseable"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("kept %v, want %v", ids, want)
+	}
+}
+
+func TestFilterByDateWindowIgnoresInvalidWindow(t *testing.T) {
+	tournaments := []models.Tournament{
+		{Id: "t1", Date: "01.08.2026"},
+		{Id: "t2", Date: "01.12.2026"},
+	}
+
+	if got := filterByDateWindow(testFederationOld, tournaments, "", "31.08.2026"); len(got) != 2 {
+		t.Errorf("got %d tournaments, want the list unfiltered", len(got))
+	}
+}
+
+func TestEventUID(t *testing.T) {
+	withID := models.Tournament{Id: "700001", CanonicalId: "BAD:700001", Title: "Sommer Open"}
+	if got := eventUID(withID); got != "tournament-BAD:700001@tennis-tournament-finder" {
+		t.Errorf("eventUID() = %q", got)
+	}
+	// Federations on one nuLiga instance may share an id space.
+	other := withID
+	other.CanonicalId = "WTB:700001"
+	if eventUID(other) == eventUID(withID) {
+		t.Error("UIDs of the same id in two federations collide")
+	}
+
+	// Without an id the UID is derived from the content, so it is the same
+	// in every export, and the dates of a recurring tournament stay apart.
+	fetched := func(date string) models.Tournament {
+		tournaments := []models.Tournament{{
+			Title: "Sommer Open", Organizer: "TC X", URL: "https://example.org/turniere", Date: date,
+		}}
+		annotateDates(models.Federation{Id: "BAD"}, tournaments, "01.08.2026")
+		annotateIdentity(models.Federation{Id: "BAD"}, tournaments)
+		return tournaments[0]
+	}
+	august, september := fetched("01.08.2026"), fetched("05.09.2026")
+	if august.StartDate == september.StartDate {
+		t.Fatalf("fixtures share the start date %s", august.StartDate)
+	}
+	if eventUID(august) == eventUID(september) {
+		t.Errorf("UIDs of an id-less recurring tournament collapse: %s", eventUID(august))
+	}
+	if got, want := eventUID(fetched("01.08.2026")), eventUID(august); got != want {
+		t.Errorf("UID of a re-fetched id-less tournament = %s, want %s", got, want)
+	}
+}
+
+// TestWithCanonicalIds covers results cached before canonical ids were set.
+func TestWithCanonicalIds(t *testing.T) {
+	fed := models.Federation{Id: "BAD"}
+	cached := []models.Tournament{
+		{Id: "700001", CanonicalId: "BAD:700001"},
+		{Id: "700002"},
+	}
+
+	got := withCanonicalIds(fed, cached)
+	if got[0].CanonicalId != "BAD:700001" || got[1].CanonicalId != "BAD:700002" {
+		t.Errorf("canonical ids = %q, %q", got[0].CanonicalId, got[1].CanonicalId)
+	}
+	// The shared cached slice is left alone.
+	if cached[1].CanonicalId != "" {
+		t.Error("input was mutated")
+	}
+}
+
+func TestParseCircle(t *testing.T) {
+	if c, ok := ParseCircle("49.0", "8.4", "50"); !ok || c.Lat != 49 || c.Lon != 8.4 || c.RadiusKm != 50 {
+		t.Errorf("ParseCircle() = %+v, %v", c, ok)
+	}
+
+	for _, in := range [][3]string{
+		{"", "8.4", "50"},
+		{"49.0", "8.4", ""},
+		{"abc", "8.4", "50"},
+		{"91", "8.4", "50"},
+		{"49.0", "8.4", "0"},
+		{"49.0", "8.4", "-5"},
+	} {
+		if _, ok := ParseCircle(in[0], in[1], in[2]); ok {
+			t.Errorf("ParseCircle(%q) accepted invalid input", in)
+		}
+	}
+}
+
+func TestFederationsNear(t *testing.T) {
+	saar := models.Federation{Id: "STB", Bounds: &models.Bounds{South: 49.11, West: 6.35, North: 49.64, East: 7.41}}
+	hamburg := models.Federation{Id: "HAM", Bounds: &models.Bounds{South: 53.39, West: 9.73, North: 53.97, East: 10.33}}
+	unbounded := models.Federation{Id: "XXX"}
+
+	// 30 km around Saarbrücken.
+	got := FederationsNear([]models.Federation{saar, hamburg, unbounded}, Circle{Lat: 49.24, Lon: 7.0, RadiusKm: 30})
+
+	var ids []string
+	for _, fed := range got {
+		ids = append(ids, fed.Id)
+	}
+	if strings.Join(ids, ",") != "STB,XXX" {
+		t.Errorf("got %v, want STB and the federation without bounds", ids)
+	}
+}
+
+func TestFilterByRadius(t *testing.T) {
+	// Around Karlsruhe; Stuttgart is about 62 km away.
+	c := Circle{Lat: 49.0069, Lon: 8.4037, RadiusKm: 70}
+
+	got := FilterByRadius([]models.Tournament{
+		{Id: "stuttgart", Lat: "48.7758", Lon: "9.1829"},
+		{Id: "karlsruhe", Lat: "49.0069", Lon: "8.4037"},
+		{Id: "default-pin", Lat: "49.0", Lon: "8.4", ApproximateLocation: true},
+		{Id: "hamburg", Lat: "53.5503", Lon: "10.0007"},
+		{Id: "no-coordinates"},
+	}, c)
+
+	var ids []string
+	for _, tournament := range got {
+		ids = append(ids, tournament.Id)
+	}
+	if strings.Join(ids, ",") != "karlsruhe,stuttgart,default-pin" {
+		t.Fatalf("got %v, want nearest first and the default pin last", ids)
+	}
+
+	if got[0].DistanceKm == nil || *got[0].DistanceKm != 0 {
+		t.Errorf("karlsruhe distance = %v, want 0", got[0].DistanceKm)
+	}
+	if d := got[1].DistanceKm; d == nil || *d < 55 || *d > 70 {
+		t.Errorf("stuttgart distance = %v, want about 62 km", d)
+	}
+	if got[2].DistanceKm != nil {
+		t.Error("a tournament at its federation's default pin got a distance")
+	}
+}
+
+func TestFilterByRadiusReturnsEmptySlice(t *testing.T) {
+	got := FilterByRadius(nil, Circle{Lat: 49, Lon: 8, RadiusKm: 10})
+	if got == nil {
+		t.Error("got nil, want an empty slice so the API encodes []")
+	}
+}
+
+func TestParseSearchValuesWindow(t *testing.T) {
+	today := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query    url.Values
+		from, to string
+	}{
+		{url.Values{}, "01.08.2026", "15.08.2026"},
+		{url.Values{"days": {"56"}}, "01.08.2026", "26.09.2026"},
+		// An explicit end wins over days, an invalid days falls back.
+		{url.Values{"days": {"56"}, "dateTo": {"10.08.2026"}}, "01.08.2026", "10.08.2026"},
+		{url.Values{"days": {"0"}}, "01.08.2026", "15.08.2026"},
+		{url.Values{"days": {"1000"}}, "01.08.2026", "15.08.2026"},
+		{url.Values{"dateFrom": {"05.08.2026"}}, "05.08.2026", "15.08.2026"},
+	}
+	for _, tc := range tests {
+		q := parseSearchValues(tc.query, today)
+		if q.DateFrom != tc.from || q.DateTo != tc.to {
+			t.Errorf("parseSearchValues(%v) window = %s..%s, want %s..%s", tc.query, q.DateFrom, q.DateTo, tc.from, tc.to)
+		}
+	}
+}
+
+func TestNotification(t *testing.T) {
+	search := savedsearch.Search{Token: "abc", Name: "Jugend"}
+	many := make([]models.Tournament, 7)
+	for i := range many {
+		many[i] = models.Tournament{Title: fmt.Sprintf("Cup %d", i+1), Date: "01.09.2026"}
+	}
+
+	tests := []struct {
+		search      savedsearch.Search
+		fresh       []models.Tournament
+		title, body string
+		url         string
+	}{
+		{
+			search: search,
+			fresh:  []models.Tournament{{Title: "Sommer Open", Date: "05.08.2026", URL: "https://example.org/1"}},
+			title:  "Neues Turnier: Jugend", body: "05.08.2026 Sommer Open", url: "https://example.org/1",
+		},
+		{
+			search: savedsearch.Search{Token: "abc"},
+			fresh:  []models.Tournament{{Title: "A", URL: "https://example.org/a"}, {Title: "B"}},
+			title:  "2 neue Turniere", body: "A\nB",
+		},
+		// Long lists are cut, so the payload stays small.
+		{
+			search: search,
+			fresh:  many,
+			title:  "7 neue Turniere: Jugend",
+			body:   "01.09.2026 Cup 1\n01.09.2026 Cup 2\n01.09.2026 Cup 3\n01.09.2026 Cup 4\n01.09.2026 Cup 5\nund 2 weitere",
+		},
+	}
+	for _, tc := range tests {
+		got := notification(tc.search, tc.fresh)
+		if got.Title != tc.title || got.Body != tc.body || got.URL != tc.url || got.Tag != pushTopic("abc") {
+			t.Errorf("notification() = %+v, want title %q, body %q, url %q", got, tc.title, tc.body, tc.url)
+		}
+	}
+}
+
+// TestNotificationFitsPayload covers names and titles that would otherwise
+// make a notification too large to ever be delivered.
+func TestNotificationFitsPayload(t *testing.T) {
+	search := savedsearch.Search{Token: "abc", Name: strings.Repeat("<Jugend>", 100)}
+	fresh := make([]models.Tournament, 7)
+	for i := range fresh {
+		fresh[i] = models.Tournament{Title: strings.Repeat("Ü&", 500), Date: "01.09.2026"}
+	}
+
+	for _, fresh := range [][]models.Tournament{fresh, {{Title: fresh[0].Title, URL: "https://example.org/?" + strings.Repeat("&a", 2000)}}} {
+		got := notification(search, fresh)
+		payload, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payload) > webpush.MaxPayload {
+			t.Errorf("payload of %d bytes exceeds %d", len(payload), webpush.MaxPayload)
+		}
+		if !strings.HasSuffix(got.Title, "…") || utf8.RuneCountInString(got.Title) != maxNotificationText {
+			t.Errorf("title %q is not shortened", got.Title)
+		}
+	}
+
+	if got := shorten("Sommer Open", 20); got != "Sommer Open" {
+		t.Errorf("shorten() = %q", got)
+	}
+	if got := shorten("Sommer Open Bad Homburg", 12); got != "Sommer Open…" {
+		t.Errorf("shorten() = %q", got)
+	}
+}
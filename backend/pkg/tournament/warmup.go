@@ -6,6 +6,7 @@ import (
 
 	"github.com/timoknapp/tennis-tournament-finder/pkg/federation"
 	"github.com/timoknapp/tennis-tournament-finder/pkg/logger"
+	"github.com/timoknapp/tennis-tournament-finder/pkg/ratelimit"
 )
 
 // Warmup preloads tournaments for the given date range and optional filters.
@@ -41,6 +42,8 @@ func WarmupContext(ctx context.Context, dateFrom, dateTo, compType, selectedFede
 	// scheduled refresh a no-op and let entries age into staleness.
 	InvalidateCache(filteredFederations, dateFrom, dateTo, compType)
 
+	// Geocoding for a warmup yields to lookups a user is waiting on.
+	ctx = ratelimit.WithPriority(ctx, ratelimit.PriorityBackground)
 	tournaments, results := CollectTournaments(ctx, filteredFederations, dateFrom, dateTo, compType)
 
 	var failed int
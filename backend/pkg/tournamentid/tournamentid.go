@@ -0,0 +1,65 @@
+// Package tournamentid gives every tournament a stable identity, whichever
+// source it came from.
+//
+// Upstream ids are read from the tournament's link, in every shape the sources
+// emit:
+//
+//	https://www.tennis.de/spielen/turniersuche.html#detail/699982        new API, BTV
+//	https://mybigpoint.tennis.de/web/guest/turniersuche?tournamentId=484582   old nuLiga
+//
+// An upstream id is only unique within its federation's listing, so the
+// canonical id is namespaced by federation: "BAD:700001". A tournament whose
+// link carries no id gets a content hash instead, so it can still be looked
+// up, deduplicated and exported.
+package tournamentid
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+// idPattern matches the id in a tennis.de detail link or a mybigpoint
+// tournamentId parameter, in the query or the fragment.
+var idPattern = regexp.MustCompile(`(?i)(?:detail/|[?&#]tournamentId=)([0-9A-Za-z_-]+)`)
+
+// FromURL returns the upstream id in a tournament link, or "" when the link
+// carries none.
+func FromURL(link string) string {
+	if m := idPattern.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Hash derives an id from the fields that identify a tournament when its link
+// carries none. The start date is one of them, or the date text while it is
+// unparsed: without an id, a club's monthly tournament is only told apart by
+// its date. A postponed tournament therefore gets a new id. The "h" prefix
+// keeps it apart from numeric upstream ids.
+func Hash(t models.Tournament) string {
+	day := t.StartDate
+	if day == "" {
+		day = t.Date
+	}
+	key := strings.Join([]string{t.URL, t.Title, t.Organizer, day}, "|")
+	sum := sha1.Sum([]byte(key))
+	return "h" + hex.EncodeToString(sum[:8])
+}
+
+// Of returns t's id: the upstream one when known, otherwise its content hash.
+func Of(t models.Tournament) string {
+	if t.Id != "" {
+		return t.Id
+	}
+	return Hash(t)
+}
+
+// Canonical namespaces t's id by federation, e.g. "BAD:700001". It is unique
+// across all sources.
+func Canonical(federationID string, t models.Tournament) string {
+	return federationID + ":" + Of(t)
+}
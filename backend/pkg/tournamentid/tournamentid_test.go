@@ -0,0 +1,70 @@
+package tournamentid
+
+import (
+	"testing"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/models"
+)
+
+func TestFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.tennis.de/spielen/turniersuche.html#detail/699982", "699982"},
+		{"https://mybigpoint.tennis.de/web/guest/turniersuche?tournamentId=484582", "484582"},
+		{"https://mybigpoint.tennis.de/web/guest/turniersuche?lang=de&tournamentId=484582#top", "484582"},
+		{"https://www.tennis.de/spielen/turniersuche.html#detail/699982?tab=info", "699982"},
+		{"https://example.com/detail/12345", "12345"},
+		{"", ""},
+		{"detail/", ""},
+		{"https://example.invalid/turnier/ohne-id", ""},
+	}
+
+	for _, tt := range tests {
+		if got := FromURL(tt.url); got != tt.want {
+			t.Errorf("FromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := models.Tournament{URL: "https://example.invalid/x", Title: "Sommer Open", Organizer: "TC X", Date: "01.08.2026"}
+
+	// A recurring tournament is told apart by its date.
+	b := a
+	b.Date = "08.08.2026"
+	if Hash(a) == Hash(b) {
+		t.Error("recurring tournaments share a hash")
+	}
+	// Once parsed, the start date counts, not how the date was written.
+	b.Date = "Sa., 01.08.2026"
+	a.StartDate, b.StartDate = "2026-08-01", "2026-08-01"
+	if Hash(a) != Hash(b) {
+		t.Error("hash changed with the date text")
+	}
+
+	c := a
+	c.Title = "Winter Open"
+	if Hash(a) == Hash(c) {
+		t.Error("different tournaments share a hash")
+	}
+	if got := Hash(a); len(got) != 17 || got[0] != 'h' {
+		t.Errorf("Hash() = %q, want h and 16 hex digits", got)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	if got := Canonical("BAD", models.Tournament{Id: "700001"}); got != "BAD:700001" {
+		t.Errorf("Canonical() = %q, want BAD:700001", got)
+	}
+
+	idless := models.Tournament{Title: "Turnier ohne ID", Organizer: "TC Ohne Kennung"}
+	if got := Canonical("BAD", idless); got != "BAD:"+Hash(idless) {
+		t.Errorf("Canonical() = %q, want the content hash", got)
+	}
+	// The same upstream id in two federations stays apart.
+	if Canonical("BAD", models.Tournament{Id: "1"}) == Canonical("WTB", models.Tournament{Id: "1"}) {
+		t.Error("canonical ids collide across federations")
+	}
+}
@@ -0,0 +1,128 @@
+// Package undated records tournament dates that could not be parsed into a
+// start and end day.
+//
+// Federations publish dates as free text, and a new format ("ab 12.09.",
+// "verschoben") would otherwise just leave StartDate empty. Such tournaments
+// still show up on the map, but they drop out of date sorting and calendar
+// export without anyone noticing. Recording the raw text makes a new format
+// visible the day it appears.
+package undated
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the registry. Date texts come from upstream and are
+// untrusted; the real number of distinct formats is small.
+const maxEntries = 200
+
+// maxTournaments bounds the tournaments counted per text. A text affecting
+// that many is a format to fix, whatever the exact number.
+const maxTournaments = 1000
+
+// Entry is one date text that could not be parsed.
+type Entry struct {
+	// Text is the date exactly as the federation published it.
+	Text       string `json:"text"`
+	Federation string `json:"federation"`
+	// Example names one affected tournament, so the text can be checked
+	// against the upstream page.
+	Example string `json:"example,omitempty"`
+	// Count is how many distinct tournaments were affected since the process
+	// started. Seeing one again on the next refresh does not count.
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// record is an entry with the tournaments counted for it.
+type record struct {
+	Entry
+	tournaments map[string]struct{}
+}
+
+type registry struct {
+	mu      sync.RWMutex
+	entries map[string]*record
+	dropped int
+}
+
+var reg = &registry{entries: make(map[string]*record)}
+
+// Record notes that the date text of the tournament with the given id could
+// not be parsed. It is safe to call from the goroutines that fetch
+// federations in parallel.
+func Record(text, federation, id, example string) {
+	now := time.Now().UTC()
+	key := federation + "|" + text
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if entry, ok := reg.entries[key]; ok {
+		if len(entry.tournaments) < maxTournaments {
+			entry.tournaments[id] = struct{}{}
+			entry.Count = len(entry.tournaments)
+		}
+		entry.LastSeen = now
+		return
+	}
+
+	if len(reg.entries) >= maxEntries {
+		reg.dropped++
+		return
+	}
+
+	reg.entries[key] = &record{
+		Entry: Entry{
+			Text:       text,
+			Federation: federation,
+			Example:    example,
+			Count:      1,
+			FirstSeen:  now,
+			LastSeen:   now,
+		},
+		tournaments: map[string]struct{}{id: {}},
+	}
+}
+
+// Snapshot returns the recorded texts, most frequent first.
+func Snapshot() []Entry {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	list := make([]Entry, 0, len(reg.entries))
+	for _, entry := range reg.entries {
+		list = append(list, entry.Entry)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Federation != list[j].Federation {
+			return list[i].Federation < list[j].Federation
+		}
+		return list[i].Text < list[j].Text
+	})
+
+	return list
+}
+
+// Dropped reports how many distinct texts were discarded because the registry
+// was full.
+func Dropped() int {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.dropped
+}
+
+// Reset clears the registry. Used by tests.
+func Reset() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.entries = make(map[string]*record)
+	reg.dropped = 0
+}
@@ -0,0 +1,69 @@
+package undated
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecordCountsRepeatsWithoutDuplicating(t *testing.T) {
+	Reset()
+
+	Record("demnächst", "BAD", "1", "Sommer-Cup")
+	Record("demnächst", "BAD", "2", "Herbst-Cup")
+	// The next refresh sees the same tournaments again.
+	Record("demnächst", "BAD", "1", "Sommer-Cup")
+	Record("demnächst", "BAD", "2", "Herbst-Cup")
+
+	snapshot := Snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(snapshot))
+	}
+	if snapshot[0].Count != 2 {
+		t.Errorf("expected a count of 2, got %d", snapshot[0].Count)
+	}
+	// The first example is kept, so the entry stays stable.
+	if snapshot[0].Example != "Sommer-Cup" {
+		t.Errorf("example = %q, want the first one recorded", snapshot[0].Example)
+	}
+}
+
+func TestSnapshotOrdersMostFrequentFirst(t *testing.T) {
+	Reset()
+
+	Record("selten", "BAD", "1", "")
+	for i := 0; i < 3; i++ {
+		Record("häufig", "WTB", fmt.Sprint(i), "")
+	}
+
+	snapshot := Snapshot()
+	if snapshot[0].Text != "häufig" || snapshot[1].Text != "selten" {
+		t.Errorf("unexpected order: %q, %q", snapshot[0].Text, snapshot[1].Text)
+	}
+}
+
+func TestRegistryIsBounded(t *testing.T) {
+	Reset()
+
+	for i := 0; i < maxEntries+10; i++ {
+		Record(fmt.Sprintf("text %d", i), "BAD", "1", "")
+	}
+
+	if got := len(Snapshot()); got != maxEntries {
+		t.Errorf("expected %d entries, got %d", maxEntries, got)
+	}
+	if got := Dropped(); got != 10 {
+		t.Errorf("expected 10 dropped, got %d", got)
+	}
+}
+
+func TestCountIsBounded(t *testing.T) {
+	Reset()
+
+	for i := 0; i < maxTournaments+10; i++ {
+		Record("demnächst", "BAD", fmt.Sprint(i), "")
+	}
+
+	if got := Snapshot()[0].Count; got != maxTournaments {
+		t.Errorf("expected a count of %d, got %d", maxTournaments, got)
+	}
+}
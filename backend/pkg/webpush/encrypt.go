@@ -0,0 +1,108 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// recordSize is the aes128gcm record size. A payload is sent as a single
+// record, so it bounds the payload too.
+const recordSize = 4096
+
+// MaxPayload is the largest payload Send accepts. Push services only
+// guarantee a 4096-byte body, which holds the 86-byte header, the payload,
+// the padding delimiter and the 16-byte GCM tag.
+const MaxPayload = 4096 - 86 - 1 - 16
+
+// encrypt encrypts plaintext for the subscription (RFC 8291), returning the
+// aes128gcm body (RFC 8188) with its header.
+func encrypt(sub Subscription, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > MaxPayload {
+		return nil, fmt.Errorf("payload of %d bytes exceeds %d", len(plaintext), MaxPayload)
+	}
+
+	uaRaw, err := decodeBase64(sub.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Keys.Auth)
+	if err != nil || len(authSecret) == 0 {
+		return nil, errors.New("invalid auth secret")
+	}
+
+	// A fresh key pair and salt per message, as the RFC requires.
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptWith(asPrivate, salt, uaPublic, authSecret, plaintext)
+}
+
+// encryptWith is encrypt with the random inputs given, so tests can check it
+// against the RFC 8291 example.
+func encryptWith(asPrivate *ecdh.PrivateKey, salt []byte, uaPublic *ecdh.PublicKey, authSecret, plaintext []byte) ([]byte, error) {
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	// RFC 8291 section 3.4: mix the auth secret and both public keys into
+	// the input keying material.
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic.Bytes()...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	// RFC 8188 section 2.2 and 2.3.
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single, and so last, record: the content is followed by the 0x02
+	// delimiter and no padding.
+	record := append(append([]byte{}, plaintext...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// hkdf is HKDF-SHA256 (RFC 5869) for outputs of up to one hash length, which
+// is all Web Push needs.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
@@ -0,0 +1,280 @@
+package webpush
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// subscriptionsBucket holds one Record per endpoint, keyed by Key.
+	subscriptionsBucket = "push_subscriptions"
+	// vapidBucket holds the VAPID private key under vapidKey.
+	vapidBucket = "vapid"
+	vapidKey    = "private_key"
+)
+
+// Record is a stored subscription and what it is subscribed to.
+type Record struct {
+	Subscription Subscription `json:"subscription"`
+	// Search is the token of the saved search the subscriber follows.
+	Search    string    `json:"search"`
+	CreatedAt time.Time `json:"created_at"`
+	// LastNotified is when the subscriber was last told about new
+	// tournaments; only tournaments first seen after it are news. It starts
+	// at CreatedAt.
+	LastNotified time.Time `json:"last_notified"`
+}
+
+// Key returns the store key for an endpoint. Endpoints are long URLs, so they
+// are hashed.
+func Key(endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store persists subscriptions and the VAPID key.
+type Store interface {
+	Get(endpoint string) (Record, bool, error)
+	Put(record Record) error
+	Delete(endpoint string) error
+	ForEach(fn func(record Record) error) error
+	// PrivateKey returns the stored VAPID private key, or "".
+	PrivateKey() (string, error)
+	PutPrivateKey(key string) error
+	Close() error
+}
+
+// LoadKeys returns the VAPID keys. A key given in override (the
+// TTF_VAPID_PRIVATE_KEY variable) wins; otherwise the stored key is used, and
+// on first start a new one is generated and stored.
+func LoadKeys(store Store, override string) (*Keys, error) {
+	if override = strings.TrimSpace(override); override != "" {
+		return ParsePrivateKey(override)
+	}
+
+	stored, err := store.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	if stored != "" {
+		return ParsePrivateKey(stored)
+	}
+
+	keys, err := GenerateKeys()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.PutPrivateKey(keys.PrivateKey()); err != nil {
+		return nil, fmt.Errorf("failed to store VAPID key: %w", err)
+	}
+	return keys, nil
+}
+
+// BoltStore persists subscriptions in BoltDB.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (or creates) the push database at dbPath.
+func NewBoltStore(dbPath string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create push directory: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open push subscriptions at %s: %w", dbPath, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{subscriptionsBucket, vapidBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create push buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(endpoint string) (Record, bool, error) {
+	var record Record
+	var found bool
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(subscriptionsBucket))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(Key(endpoint)))
+		if data == nil {
+			return nil
+		}
+		// An unreadable subscription is a miss.
+		if err := json.Unmarshal(data, &record); err == nil {
+			found = true
+		}
+		return nil
+	})
+
+	return record, found, err
+}
+
+func (s *BoltStore) Put(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(subscriptionsBucket))
+		if bucket == nil {
+			return fmt.Errorf("push subscriptions bucket does not exist")
+		}
+		return bucket.Put([]byte(Key(record.Subscription.Endpoint)), data)
+	})
+}
+
+func (s *BoltStore) Delete(endpoint string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(subscriptionsBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(Key(endpoint)))
+	})
+}
+
+// ForEach visits a snapshot of the subscriptions, so fn may send messages
+// and update or delete records without holding a transaction open.
+func (s *BoltStore) ForEach(fn func(record Record) error) error {
+	var records []Record
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(subscriptionsBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return nil // skip corrupt subscriptions
+			}
+			records = append(records, record)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) PrivateKey() (string, error) {
+	var key string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(vapidBucket)); bucket != nil {
+			key = string(bucket.Get([]byte(vapidKey)))
+		}
+		return nil
+	})
+	return key, err
+}
+
+func (s *BoltStore) PutPrivateKey(key string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(vapidBucket))
+		if bucket == nil {
+			return fmt.Errorf("vapid bucket does not exist")
+		}
+		return bucket.Put([]byte(vapidKey), []byte(key))
+	})
+}
+
+func (s *BoltStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// MemoryStore keeps subscriptions in memory. It is used by tests and as a
+// fallback when the persistent store cannot be opened; the VAPID key then
+// changes on every restart unless TTF_VAPID_PRIVATE_KEY is set.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	records    map[string]Record
+	privateKey string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) Get(endpoint string) (Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[Key(endpoint)]
+	return record, ok, nil
+}
+
+func (s *MemoryStore) Put(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[Key(record.Subscription.Endpoint)] = record
+	return nil
+}
+
+func (s *MemoryStore) Delete(endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, Key(endpoint))
+	return nil
+}
+
+func (s *MemoryStore) ForEach(fn func(record Record) error) error {
+	s.mu.RLock()
+	snapshot := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		snapshot = append(snapshot, record)
+	}
+	s.mu.RUnlock()
+
+	for _, record := range snapshot {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) PrivateKey() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.privateKey, nil
+}
+
+func (s *MemoryStore) PutPrivateKey(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.privateKey = key
+	return nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
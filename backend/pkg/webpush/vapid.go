@@ -0,0 +1,120 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// Keys is the application server's VAPID key pair (RFC 8292). Browsers bind a
+// subscription to its public key, so it must survive restarts: a new key
+// silently invalidates every subscription.
+type Keys struct {
+	private *ecdsa.PrivateKey
+}
+
+// GenerateKeys creates a new P-256 key pair.
+func GenerateKeys() (*Keys, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{private: private}, nil
+}
+
+// ParsePrivateKey reads a key in the format web-push libraries exchange: the
+// 32-byte private scalar, base64url-encoded.
+func ParsePrivateKey(s string) (*Keys, error) {
+	raw, err := decodeBase64(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	// crypto/ecdh validates the scalar and derives the public point.
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	pub := ecdhKey.PublicKey().Bytes()
+	private := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &Keys{private: private}, nil
+}
+
+// PrivateKey returns the private scalar, base64url-encoded; see
+// ParsePrivateKey.
+func (k *Keys) PrivateKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// PublicKey returns the uncompressed public point, base64url-encoded. It is
+// the applicationServerKey the frontend passes to pushManager.subscribe.
+func (k *Keys) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.publicBytes())
+}
+
+func (k *Keys) publicBytes() []byte {
+	pub := make([]byte, 65)
+	pub[0] = 4 // uncompressed
+	k.private.X.FillBytes(pub[1:33])
+	k.private.Y.FillBytes(pub[33:])
+	return pub
+}
+
+// authorization returns the Authorization header for a push to endpoint: a
+// JWT signed for the push service's origin, with the subject push services
+// contact when a sender misbehaves.
+func (k *Keys) authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.New("invalid push endpoint")
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		// Push services reject tokens valid for more than 24 hours.
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS wants the fixed-size r||s form, not ASN.1.
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + k.PublicKey(), nil
+}
+
+// decodeBase64 reads base64url with or without padding. Browsers emit it
+// unpadded, some libraries padded.
+func decodeBase64(s string) ([]byte, error) {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,156 @@
+// Package webpush delivers Web Push messages (RFC 8030) to browser
+// subscriptions, signed with VAPID (RFC 8292) and encrypted with aes128gcm
+// (RFC 8291).
+//
+// The package only speaks the protocol and stores subscriptions; what to send
+// and when is decided by its callers.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
+)
+
+// DefaultSubject is the VAPID contact sent when TTF_VAPID_SUBJECT is unset.
+// Push services use it to reach the operator of a misbehaving sender.
+const DefaultSubject = "https://github.com/timoknapp/tennis-tournament-finder"
+
+// ErrGone is returned by Send when the push service reports that the
+// subscription no longer exists (404 or 410). It will never accept a message
+// again and should be deleted.
+var ErrGone = errors.New("push subscription has expired")
+
+// ErrEndpoint is returned for a subscription whose endpoint is not a public
+// https URL. Endpoints come from browsers, and a server must not be made to
+// post to its own network on a client's say-so.
+var ErrEndpoint = errors.New("push endpoint must be a public https URL")
+
+// Enabled reports whether Web Push is available. It is on by default; set
+// TTF_WEB_PUSH=false to turn it off.
+func Enabled() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("TTF_WEB_PUSH")))
+	return v != "false" && v != "0" && v != "off"
+}
+
+// Subject returns the VAPID contact, a mailto: or https: URL.
+func Subject() string {
+	if v := strings.TrimSpace(os.Getenv("TTF_VAPID_SUBJECT")); v != "" {
+		return v
+	}
+	return DefaultSubject
+}
+
+// Subscription is a browser push subscription, in the shape
+// PushSubscription.toJSON() produces.
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	// ExpirationTime is milliseconds since the epoch, or null.
+	ExpirationTime *int64 `json:"expirationTime,omitempty"`
+	Keys           struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Validate reports whether a message could be encrypted for and sent to the
+// subscription. The endpoint must be an https URL whose host is not localhost
+// or a loopback, link-local or private address. A host name is checked again
+// where Send connects, against the addresses it resolves to.
+func (s Subscription) Validate() error {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" {
+		return ErrEndpoint
+	}
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrEndpoint, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !httpclient.IsPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrEndpoint, host)
+	}
+	_, err = encrypt(s, nil)
+	return err
+}
+
+// Message is one push message.
+type Message struct {
+	// Payload is handed to the service worker's push event as is.
+	Payload []byte
+	// TTL is how long the push service keeps an undelivered message.
+	TTL time.Duration
+	// Topic, if set, replaces an undelivered message with the same topic,
+	// so a device that was offline receives only the latest one.
+	Topic string
+}
+
+// Sender sends messages signed with its keys.
+type Sender struct {
+	Keys    *Keys
+	Subject string
+	Client  *http.Client
+}
+
+// NewSender returns a sender using the shared push HTTP client and the
+// configured subject.
+func NewSender(keys *Keys) *Sender {
+	return &Sender{Keys: keys, Subject: Subject(), Client: httpclient.Push()}
+}
+
+// Send encrypts and delivers msg to sub. It returns an error wrapping ErrGone
+// when the subscription has expired, and one wrapping ErrEndpoint when the
+// client refused to connect because the endpoint resolves to a non-public
+// address.
+func (s *Sender) Send(ctx context.Context, sub Subscription, msg Message) error {
+	body, err := encrypt(sub, msg.Payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := s.Keys.authorization(sub.Endpoint, s.Subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(msg.TTL/time.Second)))
+	req.Header.Set("Urgency", "normal")
+	if msg.Topic != "" {
+		req.Header.Set("Topic", msg.Topic)
+	}
+	httpclient.ApplyDefaultHeaders(req)
+
+	resp, err := s.Client.Do(req)
+	if errors.Is(err, httpclient.ErrNonPublicAddress) {
+		return fmt.Errorf("%w: %v", ErrEndpoint, err)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: %s", ErrGone, resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
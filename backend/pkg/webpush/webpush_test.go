@@ -0,0 +1,395 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timoknapp/tennis-tournament-finder/pkg/httpclient"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := decodeBase64(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return b
+}
+
+// TestEncryptRFC8291Example checks the encryption against the worked example
+// in RFC 8291, appendix A.
+func TestEncryptRFC8291Example(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(mustDecode(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := encryptWith(asPrivate,
+		mustDecode(t, "DGv6ra1nlYgDCS1FRnbzlw"),
+		uaPublic,
+		mustDecode(t, "BTBZMqHH6r4Tts7J_aSIgg"),
+		[]byte("When I grow up, I want to be a watermelon"))
+	if err != nil {
+		t.Fatalf("encryptWith() error = %v", err)
+	}
+
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Errorf("encryptWith() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// subscriber is the browser end of a subscription.
+type subscriber struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newSubscriber(t *testing.T, endpoint string) (*subscriber, Subscription) {
+	t.Helper()
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &subscriber{private: private, auth: make([]byte, 16)}
+	rand.Read(s.auth)
+
+	var sub Subscription
+	sub.Endpoint = endpoint
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(private.PublicKey().Bytes())
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(s.auth)
+	return s, sub
+}
+
+// decrypt reverses encrypt the way a browser does.
+func (s *subscriber) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 || int(body[20]) != 65 || len(body) < 21+65 {
+		t.Fatalf("body of %d bytes has no valid header", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Errorf("record size = %d, want %d", rs, recordSize)
+	}
+	asPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+65])
+	if err != nil {
+		t.Fatalf("invalid sender key: %v", err)
+	}
+	secret, err := s.private.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), s.private.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublic.Bytes()...)
+	ikm := hkdf(s.auth, secret, keyInfo, 32)
+	block, _ := aes.NewCipher(hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16))
+	gcm, _ := cipher.NewGCM(block)
+
+	record, err := gcm.Open(nil, hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12), body[21+65:], nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		t.Fatalf("record does not end with the last-record delimiter")
+	}
+	return record[:len(record)-1]
+}
+
+// verifyVAPID checks the Authorization header the way a push service does and
+// returns the JWT claims.
+func verifyVAPID(t *testing.T, header string) map[string]any {
+	t.Helper()
+	rest, ok := strings.CutPrefix(header, "vapid t=")
+	if !ok {
+		t.Fatalf("Authorization = %q, want vapid scheme", header)
+	}
+	token, key, ok := strings.Cut(rest, ", k=")
+	if !ok {
+		t.Fatalf("Authorization = %q has no key", header)
+	}
+
+	pub := mustDecode(t, key)
+	x, y := new(big.Int).SetBytes(pub[1:33]), new(big.Int).SetBytes(pub[33:])
+	publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT %q has %d parts", token, len(parts))
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature := mustDecode(t, parts[2])
+	if len(signature) != 64 ||
+		!ecdsa.Verify(publicKey, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		t.Fatalf("JWT signature does not verify")
+	}
+
+	var claims map[string]any
+	if err := json.Unmarshal(mustDecode(t, parts[1]), &claims); err != nil {
+		t.Fatalf("JWT claims: %v", err)
+	}
+	return claims
+}
+
+func TestSendToFakePushService(t *testing.T) {
+	keys, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake push service records the request; it is checked here, on the
+	// test goroutine.
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	deliveries := make(chan delivery, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{header: r.Header, body: body}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	browser, subscription := newSubscriber(t, server.URL+"/push/abc")
+	sender := &Sender{Keys: keys, Subject: "mailto:ops@example.org", Client: server.Client()}
+
+	err = sender.Send(context.Background(), subscription, Message{
+		Payload: []byte(`{"title":"2 neue Turniere"}`),
+		TTL:     24 * time.Hour,
+		Topic:   "search-abc",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := <-deliveries
+	if payload := browser.decrypt(t, got.body); string(payload) != `{"title":"2 neue Turniere"}` {
+		t.Errorf("payload = %q", payload)
+	}
+	claims := verifyVAPID(t, got.header.Get("Authorization"))
+	for header, want := range map[string]string{
+		"Content-Encoding": "aes128gcm",
+		"Ttl":              "86400",
+		"Topic":            "search-abc",
+	} {
+		if v := got.header.Get(header); v != want {
+			t.Errorf("%s = %q, want %q", header, v, want)
+		}
+	}
+	if claims["aud"] != server.URL || claims["sub"] != "mailto:ops@example.org" {
+		t.Errorf("claims = %v, want aud %s", claims, server.URL)
+	}
+	if exp, _ := claims["exp"].(float64); time.Until(time.Unix(int64(exp), 0)) > 24*time.Hour {
+		t.Errorf("exp %v is more than 24 hours away", exp)
+	}
+	if !strings.HasSuffix(got.header.Get("Authorization"), "k="+keys.PublicKey()) {
+		t.Errorf("Authorization does not carry the public key")
+	}
+}
+
+func TestSendReportsExpiredSubscriptions(t *testing.T) {
+	keys, _ := GenerateKeys()
+
+	for status, gone := range map[int]bool{
+		http.StatusNotFound:            true,
+		http.StatusGone:                true,
+		http.StatusTooManyRequests:     false,
+		http.StatusInternalServerError: false,
+	} {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		_, subscription := newSubscriber(t, server.URL)
+		sender := &Sender{Keys: keys, Subject: DefaultSubject, Client: server.Client()}
+
+		err := sender.Send(context.Background(), subscription, Message{Payload: []byte("x")})
+		if err == nil {
+			t.Errorf("status %d: Send() succeeded", status)
+		} else if errors.Is(err, ErrGone) != gone {
+			t.Errorf("status %d: errors.Is(%v, ErrGone) = %v, want %v", status, err, !gone, gone)
+		}
+		server.Close()
+	}
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	_, valid := newSubscriber(t, "https://push.example.org/abc")
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	badKey := valid
+	badKey.Keys.P256dh = "AAAA"
+	noAuth := valid
+	noAuth.Keys.Auth = ""
+	for name, sub := range map[string]Subscription{"p256dh": badKey, "auth": noAuth} {
+		if err := sub.Validate(); err == nil {
+			t.Errorf("%s: Validate() accepted an invalid subscription", name)
+		}
+	}
+
+	for _, endpoint := range []string{
+		"javascript:alert(1)",
+		"http://push.example.org/abc",
+		"https:///abc",
+		"https://localhost/abc",
+		"https://push.localhost./abc",
+		"https://127.0.0.1:8080/abc",
+		"https://[::1]/abc",
+		"https://[::ffff:10.0.0.1]/abc",
+		"https://10.1.2.3/abc",
+		"https://192.168.0.1/abc",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[fe80::1]/abc",
+		"https://0.0.0.0/abc",
+	} {
+		sub := valid
+		sub.Endpoint = endpoint
+		if err := sub.Validate(); !errors.Is(err, ErrEndpoint) {
+			t.Errorf("Validate(%s) error = %v, want ErrEndpoint", endpoint, err)
+		}
+	}
+
+	large := make([]byte, MaxPayload+1)
+	if _, err := encrypt(valid, large); err == nil {
+		t.Errorf("encrypt() accepted a payload over MaxPayload")
+	}
+}
+
+func TestSendRefusesNonPublicAddresses(t *testing.T) {
+	keys, _ := GenerateKeys()
+	var delivered atomic.Bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Store(true)
+	}))
+	defer server.Close()
+
+	// The shared push client, as NewSender uses it; the server is on
+	// loopback.
+	_, subscription := newSubscriber(t, server.URL+"/push/abc")
+	sender := &Sender{Keys: keys, Subject: DefaultSubject, Client: httpclient.Push()}
+
+	err := sender.Send(context.Background(), subscription, Message{Payload: []byte("x")})
+	if !errors.Is(err, ErrEndpoint) {
+		t.Errorf("Send() error = %v, want ErrEndpoint", err)
+	}
+	if delivered.Load() {
+		t.Errorf("the message was delivered to a loopback address")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "push.bolt")
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+
+	first, err := LoadKeys(store, "")
+	if err != nil {
+		t.Fatalf("LoadKeys() error = %v", err)
+	}
+	if pub := mustDecode(t, first.PublicKey()); len(pub) != 65 || pub[0] != 4 {
+		t.Errorf("PublicKey() is not an uncompressed P-256 point")
+	}
+	store.Close()
+
+	// The key survives a restart, so subscriptions stay valid.
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	again, err := LoadKeys(store, "")
+	if err != nil || again.PublicKey() != first.PublicKey() {
+		t.Errorf("LoadKeys() after reopen = %v, %v; want the stored key", again, err)
+	}
+
+	// A configured key wins over the stored one.
+	configured, _ := GenerateKeys()
+	got, err := LoadKeys(store, configured.PrivateKey())
+	if err != nil || got.PublicKey() != configured.PublicKey() {
+		t.Errorf("LoadKeys() with override = %v, %v; want the configured key", got, err)
+	}
+	if _, err := LoadKeys(store, "not a key"); err == nil {
+		t.Errorf("LoadKeys() accepted an invalid key")
+	}
+}
+
+func TestStores(t *testing.T) {
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "push.bolt"))
+	if err != nil {
+		t.Fatalf("NewBoltStore() error = %v", err)
+	}
+	defer bolt.Close()
+
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "bolt": bolt} {
+		_, sub := newSubscriber(t, "https://push.example.org/"+name)
+		record := Record{Subscription: sub, Search: "token", CreatedAt: now, LastNotified: now}
+		if err := store.Put(record); err != nil {
+			t.Fatalf("%s: Put() error = %v", name, err)
+		}
+
+		got, found, err := store.Get(sub.Endpoint)
+		if err != nil || !found {
+			t.Fatalf("%s: Get() = %v, %v", name, found, err)
+		}
+		if got.Search != "token" || got.Subscription.Keys != sub.Keys || !got.LastNotified.Equal(now) {
+			t.Errorf("%s: Get() = %+v", name, got)
+		}
+
+		// ForEach may write to the store.
+		count := 0
+		if err := store.ForEach(func(r Record) error {
+			count++
+			r.LastNotified = now.Add(time.Hour)
+			return store.Put(r)
+		}); err != nil {
+			t.Fatalf("%s: ForEach() error = %v", name, err)
+		}
+		if got, _, _ := store.Get(sub.Endpoint); count != 1 || !got.LastNotified.Equal(now.Add(time.Hour)) {
+			t.Errorf("%s: ForEach() visited %d records, update = %v", name, count, got.LastNotified)
+		}
+
+		if err := store.Delete(sub.Endpoint); err != nil {
+			t.Fatalf("%s: Delete() error = %v", name, err)
+		}
+		if _, found, _ := store.Get(sub.Endpoint); found {
+			t.Errorf("%s: subscription still found after Delete()", name)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false, "0": false, "off": false} {
+		t.Setenv("TTF_WEB_PUSH", value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with %q = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -138,6 +138,14 @@ let distanceOrigin = null;
 // popup on the map.
 let markerById = new Map();
 let currentView = 'map';
+// searchGeneration lets a pending-location refresh notice that the user has
+// searched again since, and drop its now irrelevant result.
+let searchGeneration = 0;
+// Pins the backend is still geocoding are refetched quietly a few times. The
+// queue works through about one club per second, so a handful of attempts
+// covers the usual backlog without polling forever.
+const PENDING_REFRESH_DELAY_MS = 15000;
+const PENDING_REFRESH_ATTEMPTS = 4;
 
 function getTournamentsByDate(dateFrom, dateTo, compType, federations, playerLK) {
     // On a phone the filter is a sheet covering the results, so submitting it
@@ -147,8 +155,12 @@ function getTournamentsByDate(dateFrom, dateTo, compType, federations, playerLK)
     if (dateFrom != "" && dateTo != "") {
         dateFrom = formatDateToAPI(dateFrom);
         dateTo = formatDateToAPI(dateTo);
+        const generation = ++searchGeneration;
         getTournaments(dateFrom, dateTo, compType, federations, playerLK)
         .then(response => {
+            if (generation !== searchGeneration) {
+                return;
+            }
             const tournaments = response.tournaments || [];
             renderDataNotice(response, tournaments.length);
 
@@ -161,10 +173,46 @@ function getTournamentsByDate(dateFrom, dateTo, compType, federations, playerLK)
 
             renderTournaments();
             renderTournamentList();
+
+            if (hasPendingLocations(currentTournaments)) {
+                refreshPendingLocations([dateFrom, dateTo, compType, federations, playerLK],
+                    generation, PENDING_REFRESH_ATTEMPTS);
+            }
         });
     }
 }
 
+// refreshPendingLocations refetches the same search in the background and
+// moves pins whose location the backend has since resolved. It shows no
+// spinner and leaves the filter panel alone: the user is already looking at
+// results.
+function refreshPendingLocations(query, generation, attemptsLeft) {
+    setTimeout(() => {
+        if (generation !== searchGeneration) {
+            return;
+        }
+        getTournaments(...query, { quiet: true }).then(response => {
+            if (generation !== searchGeneration || response.failed) {
+                return;
+            }
+
+            const merged = mergeResolvedLocations(currentTournaments, response.tournaments || []);
+            if (merged.resolved > 0) {
+                currentTournaments = merged.tournaments;
+                for (const tournament of currentTournaments) {
+                    tournament._distanceKm = tournamentDistanceKm(tournament, distanceOrigin);
+                }
+                renderTournaments();
+                renderTournamentList();
+            }
+
+            if (attemptsLeft > 1 && hasPendingLocations(currentTournaments)) {
+                refreshPendingLocations(query, generation, attemptsLeft - 1);
+            }
+        });
+    }, PENDING_REFRESH_DELAY_MS);
+}
+
 // renderTournaments draws the markers for whatever is currently visible.
 //
 // Split out of the fetch so the radius filter can redraw without re-querying:
@@ -205,7 +253,7 @@ function renderTournaments() {
             }
         }
 
-        const marker = L.marker([tournament["lat"], tournament["lon"]], { icon: tournamentIcon() })
+        const marker = L.marker([tournament["lat"], tournament["lon"]], { icon: tournamentIcon(tournament) })
         .bindPopup(`
         <span class="popupTitle">${tournament["title"]}</span><br><br>
         <div class="popup-info-text">
@@ -225,8 +273,10 @@ function renderTournaments() {
     map.addLayer(markers);
 }
 
-async function getTournaments(dateFrom, dateTo, compType, federations, playerLK) {
-    showSpinner();
+async function getTournaments(dateFrom, dateTo, compType, federations, playerLK, { quiet = false } = {}) {
+    if (!quiet) {
+        showSpinner();
+    }
     let url = urlBackend + `?dateFrom=${dateFrom}&dateTo=${dateTo}&format=full`;
     if (compType && compType !== "") {
         url += `&compType=${encodeURIComponent(compType)}`;
@@ -247,7 +297,9 @@ async function getTournaments(dateFrom, dateTo, compType, federations, playerLK)
             return res.json();
         })
         .then(result => {
-            hideSpinner();
+            if (!quiet) {
+                hideSpinner();
+            }
             // The backend still returns a bare array for older clients; accept both.
             if (Array.isArray(result)) {
                 return { tournaments: result, federations: [], partial: false };
@@ -259,7 +311,9 @@ async function getTournaments(dateFrom, dateTo, compType, federations, playerLK)
             };
         })
         .catch(error => {
-            hideSpinner();
+            if (!quiet) {
+                hideSpinner();
+            }
             console.error('Failed to load tournaments:', error);
             // Showing invented data would be worse than showing nothing: the
             // user cannot tell a real tournament from a placeholder.
@@ -527,6 +581,9 @@ function attachFilterAutoCloseToMarkers(group) {
 
 const MARKER_ACCENT = '#2f6f4e';
 const MARKER_ACCENT_DARK = '#255a3f';
+// MARKER_ACCENT_MUTED marks pins whose location is a guess.
+const MARKER_ACCENT_MUTED = '#8a9a90';
+const LOW_CONFIDENCE_SOURCES = ['organizer-derived', 'federation-default'];
 
 // tennisBallPin returns an SVG pin with a tennis ball at its centre.
 // The white ring around the pin lifts it off busy map tiles.
@@ -561,10 +618,54 @@ function svgIcon(svg, size, height, anchorY) {
     });
 }
 
-function tournamentIcon() {
+function tournamentIcon(tournament) {
     const size = 34;
     const height = Math.round(size * 1.25);
-    return svgIcon(tennisBallPin({ size }), size, height, height - 2);
+    const accent = tournament && isLowConfidenceLocation(tournament)
+        ? MARKER_ACCENT_MUTED
+        : MARKER_ACCENT;
+    return svgIcon(tennisBallPin({ size, accent }), size, height, height - 2);
+}
+
+// isLowConfidenceLocation reports a pin that is a guess rather than a known
+// place: derived from the club name alone, or the federation's default. Those
+// are drawn muted so a user does not drive to the wrong town on their word.
+function isLowConfidenceLocation(tournament) {
+    if (tournament.approximate_location) {
+        return true;
+    }
+    return LOW_CONFIDENCE_SOURCES.includes(tournament.location_source);
+}
+
+// hasPendingLocations reports whether any pin is waiting for the backend's
+// background geocoding.
+function hasPendingLocations(tournaments) {
+    return tournaments.some(tournament => tournament.location_pending);
+}
+
+// mergeResolvedLocations copies finished locations from a fresh response onto
+// the pending tournaments of the current one. Only location fields change, so
+// anything computed in the browser (distances) survives.
+function mergeResolvedLocations(current, fresh) {
+    const freshById = new Map(fresh.map(tournament => [String(tournament.id), tournament]));
+    let resolved = 0;
+    const tournaments = current.map(tournament => {
+        const update = tournament.location_pending && freshById.get(String(tournament.id));
+        if (!update || update.location_pending) {
+            return tournament;
+        }
+        resolved++;
+        return {
+            ...tournament,
+            lat: update.lat,
+            lon: update.lon,
+            approximate_location: update.approximate_location,
+            location_source: update.location_source,
+            location_display_name: update.location_display_name,
+            location_pending: false,
+        };
+    });
+    return { tournaments, resolved };
 }
 
 // clusterIcon draws the group count inside a tennis ball, so a cluster reads
@@ -650,6 +751,17 @@ function parseTournamentDate(dateText) {
     return isNaN(parsed.getTime()) ? null : parsed;
 }
 
+// tournamentStartDate prefers the start_date the backend parsed (ISO
+// "2026-08-16") and falls back to parsing the free text, so responses from
+// an older backend or cache still sort.
+function tournamentStartDate(tournament) {
+    const iso = /^(\d{4})-(\d{2})-(\d{2})$/.exec(tournament.start_date || '');
+    if (iso) {
+        return new Date(parseInt(iso[1], 10), parseInt(iso[2], 10) - 1, parseInt(iso[3], 10));
+    }
+    return parseTournamentDate(tournament.date);
+}
+
 // ===== Distance =====
 
 // Mean earth radius in kilometres. Good enough for "is this within 50km": the
@@ -734,8 +846,8 @@ function compareTournaments(a, b, sortBy) {
     }
 
     // Date: unparseable entries sort last rather than to 1970.
-    const da = parseTournamentDate(a.date);
-    const db = parseTournamentDate(b.date);
+    const da = tournamentStartDate(a);
+    const db = tournamentStartDate(b);
     if (!da && !db) {
         return (a.title || '').localeCompare(b.title || '', 'de');
     }
@@ -43,9 +43,9 @@ const sandbox = {
 vm.createContext(sandbox);
 vm.runInContext(listSection, sandbox);
 
-const { parseTournamentDate, compareTournaments, escapeHtml, isValidPlayerLK,
-        tennisBallPin, clusterIcon, distanceKm, tournamentDistanceKm,
-        formatDistance } = sandbox;
+const { parseTournamentDate, tournamentStartDate, compareTournaments, escapeHtml, isValidPlayerLK,
+        tennisBallPin, clusterIcon, tournamentIcon, isLowConfidenceLocation, distanceKm, tournamentDistanceKm,
+        formatDistance, hasPendingLocations, mergeResolvedLocations } = sandbox;
 
 let failures = 0;
 function test(name, fn) {
@@ -96,6 +96,18 @@ test('rejects impossible months', () => {
     assert.strictEqual(parseTournamentDate('01.13.2026'), null);
 });
 
+test('prefers the parsed start_date over the free text', () => {
+    const d = tournamentStartDate({ date: 'demnächst', start_date: '2026-09-12' });
+    assert.strictEqual(d.getFullYear(), 2026);
+    assert.strictEqual(d.getMonth(), 8);
+    assert.strictEqual(d.getDate(), 12);
+});
+
+test('falls back to the free text without start_date', () => {
+    const d = tournamentStartDate({ date: 'Sa, 15.8.2026' });
+    assert.strictEqual(d.getDate(), 15);
+});
+
 console.log('compareTournaments');
 
 test('sorts by date ascending', () => {
@@ -339,6 +351,60 @@ test('pin is anchored at its tip so it points at the venue', () => {
     assert.ok(svg.includes('width="34"'));
 });
 
+test('guessed locations are low confidence', () => {
+    assert.strictEqual(isLowConfidenceLocation({ location_source: 'organizer-derived' }), true);
+    assert.strictEqual(isLowConfidenceLocation({ location_source: 'federation-default' }), true);
+    assert.strictEqual(isLowConfidenceLocation({ approximate_location: true }), true);
+    assert.strictEqual(isLowConfidenceLocation({ location_source: 'override' }), false);
+    assert.strictEqual(isLowConfidenceLocation({ location_source: 'location' }), false);
+    // Pins cached before sources were recorded are not penalised.
+    assert.strictEqual(isLowConfidenceLocation({}), false);
+});
+
+test('low-confidence pins use the muted accent', () => {
+    const decode = icon => decodeURIComponent(icon.options.iconUrl);
+    const guessed = decode(tournamentIcon({ location_source: 'organizer-derived' }));
+    const known = decode(tournamentIcon({ location_source: 'location' }));
+    assert.ok(guessed.includes('#8a9a90'), 'guessed pin should be muted');
+    assert.ok(!known.includes('#8a9a90'), 'known pin should keep the accent');
+});
+
+console.log('pending locations');
+
+test('detects pins waiting for geocoding', () => {
+    assert.strictEqual(hasPendingLocations([{ id: '1' }, { id: '2', location_pending: true }]), true);
+    assert.strictEqual(hasPendingLocations([{ id: '1' }]), false);
+    assert.strictEqual(hasPendingLocations([]), false);
+});
+
+test('merges resolved locations onto pending pins only', () => {
+    const current = [
+        { id: '1', title: 'A', lat: '49.0', lon: '8.4', location_pending: true, approximate_location: true, _distanceKm: 12 },
+        { id: '2', title: 'B', lat: '48.7', lon: '9.1', location_source: 'location' },
+        { id: '3', title: 'C', lat: '49.0', lon: '8.4', location_pending: true, approximate_location: true },
+    ];
+    const fresh = [
+        { id: '1', lat: '49.0069', lon: '8.4037', location_source: 'location', location_display_name: 'Karlsruhe' },
+        { id: '2', lat: '0', lon: '0' },
+        { id: '3', lat: '49.0', lon: '8.4', location_pending: true, approximate_location: true },
+    ];
+
+    const merged = mergeResolvedLocations(current, fresh);
+    assert.strictEqual(merged.resolved, 1);
+
+    const [first, second, third] = merged.tournaments;
+    assert.strictEqual(first.lat, '49.0069');
+    assert.strictEqual(first.location_pending, false);
+    assert.strictEqual(first.approximate_location, undefined);
+    assert.strictEqual(first.title, 'A');
+    assert.strictEqual(first._distanceKm, 12);
+    // Settled pins are never touched, even if the fresh copy differs.
+    assert.strictEqual(second.lat, '48.7');
+    assert.strictEqual(third.location_pending, true);
+    // The current list is not mutated.
+    assert.strictEqual(current[0].lat, '49.0');
+});
+
 if (failures > 0) {
     console.error(`\n${failures} test(s) failed`);
     process.exit(1);
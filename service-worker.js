@@ -109,6 +109,45 @@
       }
     });
 
+    /**
+     *  @Functional Push
+     *  New tournaments for a saved search, sent by the backend after its
+     *  nightly warmup. The payload is { title, body, url?, tag }.
+     */
+    self.addEventListener('push', event => {
+      let data = {};
+      try {
+        data = event.data ? event.data.json() : {};
+      } catch (_) {
+        data = { body: event.data.text() };
+      }
+      event.waitUntil(
+        self.registration.showNotification(data.title || 'Neue Turniere', {
+          body: data.body || '',
+          tag: data.tag,
+          // A newer notification for the same search replaces the old one;
+          // still let the user know something changed.
+          renotify: !!data.tag,
+          icon: 'images/icon192.png',
+          data: { url: data.url }
+        })
+      );
+    });
+
+    /**
+     * Open the announced tournament, or the app when several were announced.
+     */
+    self.addEventListener('notificationclick', event => {
+      event.notification.close();
+      const target = (event.notification.data && event.notification.data.url) || self.registration.scope;
+      event.waitUntil(
+        self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then(clients => {
+          const open = clients.find(client => client.url === target);
+          return open ? open.focus() : self.clients.openWindow(target);
+        })
+      );
+    });
+
     /**
      *  @Functional Fetch
      *  All network requests are being intercepted here.